@@ -2,43 +2,94 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
 	"sync"
+	"time"
 
 	"github.com/shayanderson/go-project/app/config"
 	"github.com/shayanderson/go-project/app/handler"
 	"github.com/shayanderson/go-project/app/middleware"
+	"github.com/shayanderson/go-project/infra/blob"
+	"github.com/shayanderson/go-project/internal/metrics"
+	"github.com/shayanderson/go-project/internal/probe"
+	"github.com/shayanderson/go-project/internal/shutdown"
+	"github.com/shayanderson/go-project/internal/timex"
+	"github.com/shayanderson/go-project/internal/work"
 	"github.com/shayanderson/go-project/server"
 )
 
 // App is the main application
 type App struct {
-	cancel  func(error)
-	err     error
-	errOnce sync.Once
-	wg      sync.WaitGroup
+	cancel   func(error)
+	err      error
+	errOnce  sync.Once
+	modules  []Module
+	probes   map[string]probe.Func
+	shutdown *shutdown.Coordinator
+	wg       sync.WaitGroup
 }
 
-// New creates a new App
-func New() *App {
-	return &App{}
+// New creates a new App with modules
+func New(modules ...Module) *App {
+	return &App{
+		modules:  modules,
+		probes:   map[string]probe.Func{},
+		shutdown: shutdown.New(),
+	}
+}
+
+// OnShutdown registers a named shutdown hook for a phase, hooks run when the app stops
+func (a *App) OnShutdown(p shutdown.Phase, name string, fn shutdown.Func) {
+	a.shutdown.Register(p, name, fn)
+}
+
+// Require registers a required dependency probe, probes run at startup before the app serves
+// traffic and the app fails to start when a dependency is not available before the deadline
+func (a *App) Require(name string, fn probe.Func) {
+	a.probes[name] = fn
 }
 
 // init initializes the app
 func (a *App) init(ctx context.Context) error {
-	return nil
+	return a.probe(ctx)
+}
+
+// probe waits for the required dependencies concurrently, returns the first error
+func (a *App) probe(ctx context.Context) error {
+	errs := make(chan error, len(a.probes))
+	for name, fn := range a.probes {
+		work.Go(ctx, func(ctx context.Context) {
+			errs <- work.Run(ctx, func(ctx context.Context) error {
+				return probe.Wait(ctx, name, fn, probe.Options{})
+			})
+		})
+	}
+
+	var err error
+	for range a.probes {
+		if e := <-errs; e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
 }
 
 // run runs a function and handles errors
-// sets the first error to the app error
+// sets the first error to the app error, a panic is recovered and handled as an error
 func (a *App) run(fn func() error) {
 	a.wg.Add(1)
-	go func() {
+	work.Go(context.Background(), func(ctx context.Context) {
 		defer a.wg.Done()
 
-		if err := fn(); err != nil {
+		err := work.Run(ctx, func(context.Context) error {
+			return fn()
+		})
+		if err != nil {
 			a.errOnce.Do(func() {
 				a.err = err
 				if a.cancel != nil {
@@ -46,7 +97,7 @@ func (a *App) run(fn func() error) {
 				}
 			})
 		}
-	}()
+	})
 }
 
 // Run runs the app
@@ -60,25 +111,119 @@ func (a *App) Run(ctx context.Context) error {
 
 	ctx, a.cancel = context.WithCancelCause(ctx)
 
+	// default time zone
+	loc, err := time.LoadLocation(config.Config.TimeZone)
+	if err != nil {
+		return fmt.Errorf("invalid time zone: %w", err)
+	}
+
+	// runtime stats
+	runtimeStats := metrics.NewRuntimeCollector(metrics.Default, 10*time.Second)
+	a.run(func() error {
+		return runtimeStats.Run(ctx)
+	})
+
 	// http server
 	srv := server.New(config.Config.ServerPort)
 
 	// http middleware
 	srv.Router.Use(server.LoggerMiddleware)
 	srv.Router.Use(server.RecoverMiddleware)
+	srv.Router.Use(server.ForwardedMiddleware(config.Config.TrustedProxies...))
+	srv.Router.Use(server.MetricsMiddleware(metrics.Default))
+	srv.Router.Use(timex.Middleware(loc, timex.FromHeader("Time-Zone")))
 	srv.Router.Use(middleware.ExampleMiddleware)
 
 	// http handlers
 	exampleHandler := handler.NewExampleHandler()
+	configHandler := handler.NewConfigHandler()
+	admin := middleware.AdminMiddleware(config.Config.AdminToken)
 
 	// http routes
-	srv.Router.Get("/example", exampleHandler.Get, middleware.ExampleHandlerMiddleware)
-	srv.Router.Get("/example/{name}", exampleHandler.GetEchoName)
+	var metricsMW []server.Middleware
+	if config.Config.MetricsToken != "" {
+		metricsMW = append(metricsMW, middleware.AdminMiddleware(config.Config.MetricsToken))
+	}
+	srv.Router.Get("/metrics", handler.Metrics(metrics.Default), metricsMW...)
+
+	// admin routes
+	adminRoutes := srv.Router.Group("/admin", admin)
+	adminRoutes.Get("/runtime", handler.RuntimeStats(runtimeStats)).
+		Describe(server.RouteDoc{Summary: "Get runtime stats", Tags: []string{"admin"}})
+	adminRoutes.Get("/config", configHandler.Get).
+		Describe(server.RouteDoc{Summary: "Get runtime configuration", Tags: []string{"admin"}})
+	adminRoutes.Patch("/config", configHandler.Patch).
+		Describe(server.RouteDoc{
+			Summary: "Change tunable settings",
+			Tags:    []string{"admin"},
+			Request: map[string]string{"features": "beta", "log_level": "debug"},
+		})
+
+	srv.Router.Get("/example", exampleHandler.Get, middleware.ExampleHandlerMiddleware).
+		Describe(server.RouteDoc{
+			Summary:  "Example",
+			Tags:     []string{"example"},
+			Response: map[string]string{"message": "example"},
+		})
+	srv.Router.Get("/example/{name}", exampleHandler.GetEchoName).
+		Describe(server.RouteDoc{
+			Summary:  "Echo name",
+			Tags:     []string{"example"},
+			Response: map[string]string{"name": "name"},
+		})
+	// file routes write to disk, so they are disabled unless a bucket secret is configured
+	// and are admin only
+	if config.Config.BlobSecret != "" {
+		uploads, err := blob.NewFileBucket(
+			config.Config.UploadDir,
+			config.Config.BlobURL,
+			[]byte(config.Config.BlobSecret),
+		)
+		if err != nil {
+			return err
+		}
+		fileHandler := handler.NewFileHandler(uploads, int64(config.Config.UploadQuota))
+		fileRoutes := srv.Router.Group("/files", admin)
+		fileRoutes.Post("", fileHandler.Upload).
+			Describe(server.RouteDoc{Summary: "Upload file", Tags: []string{"files"}})
+		fileRoutes.Get("/{key}", fileHandler.Download).
+			Describe(server.RouteDoc{Summary: "Download file", Tags: []string{"files"}})
+	}
+
+	// modules
+	for _, m := range a.modules {
+		m.Routes(srv)
+		a.OnShutdown(shutdown.PhaseStopWorkers, m.Name(), m.Stop)
+		a.run(func() error {
+			if err := m.Start(ctx); err != nil {
+				return fmt.Errorf("module %s failed: %w", m.Name(), err)
+			}
+			return nil
+		})
+	}
+
+	// shutdown hooks
+	a.OnShutdown(shutdown.PhaseDrain, "http server", srv.Stop)
+
+	// the server is stopped by the drain phase, not by the app context
+	a.run(func() error {
+		err := srv.StartCtx(context.WithoutCancel(ctx))
+		if !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	})
+	select {
+	case <-srv.Ready():
+		slog.Info("app ready", "addr", srv.Addr().String())
+	case <-ctx.Done():
+		// the server failed to start or the app was stopped
+	}
 
-	a.run(srv.Start)
 	a.run(func() error {
 		<-ctx.Done()
-		return srv.Stop(ctx)
+		// shutdown must not inherit the cancelled app context
+		return a.shutdown.Shutdown(context.WithoutCancel(ctx))
 	})
 
 	return a.wait()
@@ -1,8 +1,12 @@
 package config
 
 import (
+	"fmt"
+	"log/slog"
 	"os"
+	"reflect"
 	"strconv"
+	"strings"
 )
 
 // Config is the global configuration for the application
@@ -14,19 +18,72 @@ func init() {
 }
 
 // config is the configuration for the application
+// fields tagged secret are redacted by Redacted
 type config struct {
+	// AdminToken is the bearer token for admin endpoints, admin endpoints are disabled when
+	// empty
+	AdminToken string `json:"admin_token" secret:"true"`
+
+	// BlobSecret is the signing secret of the file upload bucket, the file routes are disabled
+	// when empty
+	BlobSecret string `json:"blob_secret" secret:"true"`
+
+	// BlobURL is the externally visible base URL of the file routes used in signed URLs, for
+	// example "https://api.example.com/files", defaults to the local server
+	BlobURL string `json:"blob_url"`
+
 	// Debug is the debug mode flag
-	Debug bool
+	Debug bool `json:"debug"`
+
+	// Features are the enabled feature flags, they can be changed at runtime
+	Features *Flags `json:"features"`
+
+	// LogLevel is the log level, it can be changed at runtime
+	LogLevel *slog.LevelVar `json:"log_level"`
+
+	// MetricsToken is the bearer token for the metrics endpoint, the endpoint is public when
+	// empty
+	MetricsToken string `json:"metrics_token" secret:"true"`
 
 	// ServerPort is the http server port
-	ServerPort int
+	ServerPort int `json:"server_port"`
+
+	// TimeZone is the app default time zone name, used for requests without a time zone
+	TimeZone string `json:"time_zone"`
+
+	// TrustedProxies are the proxy IP addresses or CIDR prefixes trusted to set forwarded
+	// headers
+	TrustedProxies []string `json:"trusted_proxies"`
+
+	// UploadDir is the directory of the local file upload bucket
+	UploadDir string `json:"upload_dir"`
+
+	// UploadQuota is the max total size in bytes of the files in the upload bucket
+	UploadQuota int `json:"upload_quota"`
 }
 
 // newConfig creates a new config with default values
 func newConfig() config {
+	debug := envVar("DEBUG", "0") == "1"
+	level := "info"
+	if debug {
+		level = "debug"
+	}
+	port := envVarInt("PORT", 8080)
+
 	return config{
-		Debug:      envVar("DEBUG", "0") == "1",
-		ServerPort: envVarInt("PORT", 8080),
+		AdminToken:     envVar("ADMIN_TOKEN", ""),
+		BlobSecret:     envVar("BLOB_SECRET", ""),
+		BlobURL:        envVar("BLOB_URL", fmt.Sprintf("http://localhost:%d/files", port)),
+		Debug:          debug,
+		Features:       newFlags(envVarList("FEATURES")),
+		LogLevel:       envVarLevel("LOG_LEVEL", level),
+		MetricsToken:   envVar("METRICS_TOKEN", ""),
+		ServerPort:     port,
+		TimeZone:       envVar("TIMEZONE", "UTC"),
+		TrustedProxies: envVarList("TRUSTED_PROXIES"),
+		UploadDir:      envVar("UPLOAD_DIR", "data/uploads"),
+		UploadQuota:    envVarInt("UPLOAD_QUOTA", 1<<30), // 1GB
 	}
 }
 
@@ -52,3 +109,56 @@ func envVarInt(key string, fallback int) int {
 	}
 	return i
 }
+
+// envVarLevel returns the environment variable value as a log level or the fallback value if
+// not set or empty, panics if the value is not a valid log level
+func envVarLevel(key, fallback string) *slog.LevelVar {
+	l := &slog.LevelVar{}
+	if err := l.UnmarshalText([]byte(envVar(key, fallback))); err != nil {
+		panic("invalid log level value for " + key)
+	}
+	return l
+}
+
+// envVarList returns the environment variable value as a comma separated list, empty items
+// are skipped
+func envVarList(key string) []string {
+	var list []string
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// Redacted returns the configuration as a map keyed by JSON field name, secret values are
+// redacted so the map is safe to expose on admin endpoints
+func (c *config) Redacted() map[string]any {
+	m := map[string]any{}
+	v := reflect.ValueOf(c).Elem()
+	for i := range v.NumField() {
+		f := v.Type().Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+
+		fv := v.Field(i)
+		switch {
+		case f.Tag.Get("secret") == "true":
+			if !fv.IsZero() {
+				m[name] = "[redacted]"
+			} else {
+				m[name] = ""
+			}
+		case fv.Type() == reflect.TypeOf(&slog.LevelVar{}):
+			m[name] = fmt.Sprint(fv.Interface().(*slog.LevelVar).Level())
+		case fv.Type() == reflect.TypeOf(&Flags{}):
+			m[name] = fv.Interface().(*Flags).List()
+		default:
+			m[name] = fv.Interface()
+		}
+	}
+	return m
+}
@@ -0,0 +1,99 @@
+package config
+
+import (
+	"log/slog"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// fuzzEnvKey is the environment variable used by the fuzz targets
+const fuzzEnvKey = "GO_PROJECT_FUZZ_VALUE"
+
+// panics checks if fn panics
+func panics(fn func()) (panicked bool) {
+	defer func() {
+		panicked = recover() != nil
+	}()
+	fn()
+	return false
+}
+
+func FuzzEnvVarInt(f *testing.F) {
+	for _, s := range []string{
+		"",
+		"0",
+		"8080",
+		"-1",
+		"+1",
+		" 1",
+		"1.5",
+		"0x10",
+		"9223372036854775808",
+	} {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, v string) {
+		if strings.ContainsRune(v, 0) {
+			t.Skip("environment values cannot contain NUL")
+		}
+		t.Setenv(fuzzEnvKey, v)
+
+		var got int
+		panicked := panics(func() { got = envVarInt(fuzzEnvKey, 42) })
+		want, err := strconv.Atoi(v)
+		switch {
+		case v == "":
+			if panicked || got != 42 {
+				t.Errorf("got %d, want fallback", got)
+			}
+		case err != nil:
+			if !panicked {
+				t.Errorf("expected panic for %q", v)
+			}
+		case panicked || got != want:
+			t.Errorf("got %d, want %d", got, want)
+		}
+	})
+}
+
+func FuzzEnvVarLevel(f *testing.F) {
+	for _, s := range []string{"", "debug", "INFO", "warn", "error", "info+2", "debug-1", "x"} {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, v string) {
+		if strings.ContainsRune(v, 0) {
+			t.Skip("environment values cannot contain NUL")
+		}
+		t.Setenv(fuzzEnvKey, v)
+
+		var l *slog.LevelVar
+		if panics(func() { l = envVarLevel(fuzzEnvKey, "info") }) {
+			return
+		}
+		// a parsed level must parse again to the same level from its string form
+		want := l.Level()
+		t.Setenv(fuzzEnvKey, want.String())
+		if panics(func() { l = envVarLevel(fuzzEnvKey, "info") }) || l.Level() != want {
+			t.Errorf("level %s from %q does not parse", want, v)
+		}
+	})
+}
+
+func FuzzEnvVarList(f *testing.F) {
+	for _, s := range []string{"", "a", "a,b", " a , b ", ",,", "10.0.0.0/8, 127.0.0.1"} {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, v string) {
+		if strings.ContainsRune(v, 0) {
+			t.Skip("environment values cannot contain NUL")
+		}
+		t.Setenv(fuzzEnvKey, v)
+
+		for _, item := range envVarList(fuzzEnvKey) {
+			if item == "" || item != strings.TrimSpace(item) || strings.Contains(item, ",") {
+				t.Errorf("invalid item %q from %q", item, v)
+			}
+		}
+	})
+}
@@ -0,0 +1,50 @@
+package config
+
+import (
+	"sort"
+	"sync"
+)
+
+// Flags is a set of enabled feature flags, it is safe for concurrent use so flags can be
+// changed at runtime
+type Flags struct {
+	mu    sync.RWMutex
+	names map[string]bool
+}
+
+// newFlags creates a new set of enabled feature flags
+func newFlags(names []string) *Flags {
+	f := &Flags{}
+	f.Set(names)
+	return f
+}
+
+// Enabled checks if a feature flag is enabled
+func (f *Flags) Enabled(name string) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.names[name]
+}
+
+// List returns the enabled feature flags sorted by name
+func (f *Flags) List() []string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	list := make([]string, 0, len(f.names))
+	for name := range f.names {
+		list = append(list, name)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// Set replaces the enabled feature flags
+func (f *Flags) Set(names []string) {
+	m := make(map[string]bool, len(names))
+	for _, name := range names {
+		m[name] = true
+	}
+	f.mu.Lock()
+	f.names = m
+	f.mu.Unlock()
+}
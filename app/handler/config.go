@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/shayanderson/go-project/app/config"
+	"github.com/shayanderson/go-project/internal/errs"
+	"github.com/shayanderson/go-project/server"
+)
+
+// TunableFunc parses and validates a setting value and returns the function applying it
+type TunableFunc func(v string) (apply func(), err error)
+
+// ConfigHandler handles the runtime configuration admin endpoints
+type ConfigHandler struct {
+	tunables map[string]TunableFunc
+}
+
+// NewConfigHandler creates a new ConfigHandler, the log level and the feature flags are
+// tunable by default, the feature flags value is a comma separated list of the enabled flags
+func NewConfigHandler() *ConfigHandler {
+	h := &ConfigHandler{
+		tunables: map[string]TunableFunc{},
+	}
+	h.Tunable("log_level", func(v string) (func(), error) {
+		var l slog.Level
+		if err := l.UnmarshalText([]byte(v)); err != nil {
+			return nil, err
+		}
+		return func() { config.Config.LogLevel.Set(l) }, nil
+	})
+	h.Tunable("features", func(v string) (func(), error) {
+		var names []string
+		for _, name := range strings.Split(v, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+		return func() { config.Config.Features.Set(names) }, nil
+	})
+	return h
+}
+
+// Tunable adds a setting that can be changed with PATCH, values of all settings in a request
+// are parsed before any is applied, apply must be safe for concurrent use with requests
+// reading the setting
+func (h *ConfigHandler) Tunable(name string, parse TunableFunc) {
+	h.tunables[name] = parse
+}
+
+// Get writes the effective configuration with secrets redacted
+func (h *ConfigHandler) Get(w http.ResponseWriter, r *http.Request) error {
+	return server.WriteJSON(w, http.StatusOK, config.Config.Redacted())
+}
+
+// Patch changes tunable settings, the request body is an object of setting names and string
+// values, no setting is changed when any setting is not tunable or any value is invalid
+func (h *ConfigHandler) Patch(w http.ResponseWriter, r *http.Request) error {
+	var req map[string]string
+	if err := server.JSONCodec.Decode(r.Body, &req); err != nil {
+		return errs.Wrap(err, errs.Invalid, "invalid_body", "invalid request body")
+	}
+
+	apply := make(map[string]func(), len(req))
+	for name, v := range req {
+		parse, ok := h.tunables[name]
+		if !ok {
+			return errs.New(errs.Invalid, "not_tunable", "setting is not tunable").
+				With("setting", name)
+		}
+		fn, err := parse(v)
+		if err != nil {
+			return errs.Wrap(err, errs.Invalid, "invalid_value", "invalid setting value").
+				With("setting", name)
+		}
+		apply[name] = fn
+	}
+
+	for name, fn := range apply {
+		fn()
+		slog.Info("config changed", "setting", name, "value", req[name])
+	}
+	return h.Get(w, r)
+}
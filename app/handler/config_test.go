@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/shayanderson/go-project/app/config"
+	"github.com/shayanderson/go-project/server"
+)
+
+func TestConfigHandlerPatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		status   int
+		level    slog.Level
+		features string
+	}{
+		{
+			name:     "apply all",
+			body:     `{"log_level":"debug","features":"a, b"}`,
+			status:   http.StatusOK,
+			level:    slog.LevelDebug,
+			features: "a,b",
+		},
+		{
+			name:     "invalid value changes nothing",
+			body:     `{"features":"a","log_level":"loud"}`,
+			status:   http.StatusBadRequest,
+			level:    slog.LevelWarn,
+			features: "old",
+		},
+		{
+			name:     "not tunable changes nothing",
+			body:     `{"log_level":"debug","server_port":"1"}`,
+			status:   http.StatusBadRequest,
+			level:    slog.LevelWarn,
+			features: "old",
+		},
+		{
+			name:     "invalid body",
+			body:     `{"log_level":1}`,
+			status:   http.StatusBadRequest,
+			level:    slog.LevelWarn,
+			features: "old",
+		},
+	}
+
+	level := config.Config.LogLevel.Level()
+	features := config.Config.Features.List()
+	t.Cleanup(func() {
+		config.Config.LogLevel.Set(level)
+		config.Config.Features.Set(features)
+	})
+
+	h := NewConfigHandler()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.Config.LogLevel.Set(slog.LevelWarn)
+			config.Config.Features.Set([]string{"old"})
+			for range 20 {
+				// map iteration order varies, so the request is repeated
+				r := httptest.NewRequest(http.MethodPatch, "/", strings.NewReader(tt.body))
+				w := httptest.NewRecorder()
+				server.Handler(h.Patch).ServeHTTP(w, r)
+				if w.Code != tt.status {
+					t.Fatalf("got status %d, want %d", w.Code, tt.status)
+				}
+				if got := config.Config.LogLevel.Level(); got != tt.level {
+					t.Fatalf("got level %v, want %v", got, tt.level)
+				}
+				if got := strings.Join(config.Config.Features.List(), ","); got != tt.features {
+					t.Fatalf("got features %q, want %q", got, tt.features)
+				}
+			}
+		})
+	}
+}
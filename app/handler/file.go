@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/shayanderson/go-project/infra/blob"
+	"github.com/shayanderson/go-project/internal/errs"
+	"github.com/shayanderson/go-project/internal/id"
+	"github.com/shayanderson/go-project/server"
+)
+
+// maxFileSize is the max upload file size
+const maxFileSize = 10 << 20
+
+// FileHandler handles file uploads and downloads stored in a bucket
+type FileHandler struct {
+	bucket blob.Bucket
+	mu     sync.Mutex
+	opts   server.UploadOptions
+	quota  int64
+	seeded bool
+	used   int64
+}
+
+// NewFileHandler creates a new FileHandler, quota is the max total size in bytes of the files
+// in the bucket, the bucket usage is listed once on the first upload and then kept as a
+// running total, so files added to the bucket by other writers are not counted
+func NewFileHandler(bucket blob.Bucket, quota int64) *FileHandler {
+	return &FileHandler{
+		bucket: bucket,
+		opts: server.UploadOptions{
+			Field:   "file",
+			MaxSize: maxFileSize,
+		},
+		quota: quota,
+	}
+}
+
+// fileResponse is the upload response
+type fileResponse struct {
+	*server.UploadedFile
+	Key string `json:"key"`
+}
+
+// Upload receives a multipart file and stores it in the bucket
+func (h *FileHandler) Upload(w http.ResponseWriter, r *http.Request) error {
+	f, err := server.ReceiveFile(r, h.opts)
+	if err != nil {
+		return err
+	}
+	defer f.Remove()
+
+	ok, err := h.reserve(r, f.Size)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return server.WriteJSON(
+			w,
+			http.StatusInsufficientStorage,
+			map[string]string{"error": "upload quota exceeded"},
+		)
+	}
+
+	key := id.NewV7().String()
+	if err := blob.UploadFile(r.Context(), h.bucket, key, f.Path); err != nil {
+		h.release(f.Size)
+		return err
+	}
+
+	w.Header().Set("Location", server.AbsoluteURL(r, "/files/"+key))
+	return server.WriteJSON(w, http.StatusCreated, fileResponse{UploadedFile: f, Key: key})
+}
+
+// reserve adds size bytes to the bucket usage unless the quota would be exceeded, the usage
+// is reserved before the store so concurrent uploads cannot exceed the quota while they are
+// stored concurrently, returns false when the quota would be exceeded
+func (h *FileHandler) reserve(r *http.Request, size int64) (bool, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.seeded {
+		objs, err := h.bucket.List(r.Context(), "")
+		if err != nil {
+			return false, err
+		}
+		for _, o := range objs {
+			h.used += o.Size
+		}
+		h.seeded = true
+	}
+	if h.used+size > h.quota {
+		return false, nil
+	}
+	h.used += size
+	return true, nil
+}
+
+// release removes size bytes reserved for a failed upload from the bucket usage
+func (h *FileHandler) release(size int64) {
+	h.mu.Lock()
+	h.used -= size
+	h.mu.Unlock()
+}
+
+// Download writes a file from the bucket, range requests and resumable downloads are
+// supported when the bucket reader is seekable
+func (h *FileHandler) Download(w http.ResponseWriter, r *http.Request) error {
+	key := r.PathValue("key")
+	rc, err := h.bucket.Get(r.Context(), key)
+	if errors.Is(err, blob.ErrNotFound) || errors.Is(err, blob.ErrInvalidKey) {
+		return errs.New(errs.NotFound, "not_found", "file not found")
+	}
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	// uploads are untrusted, never render them inline
+	w.Header().Set("Content-Disposition", "attachment")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	rs, ok := rc.(io.ReadSeeker)
+	if !ok {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		_, err := io.Copy(w, rc)
+		return err
+	}
+
+	var modtime time.Time
+	if st, ok := rc.(interface{ Stat() (fs.FileInfo, error) }); ok {
+		if fi, err := st.Stat(); err == nil {
+			modtime = fi.ModTime()
+		}
+	}
+	server.ServeContent(w, r, key, modtime, rs)
+	return nil
+}
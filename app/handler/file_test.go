@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/shayanderson/go-project/infra/blob"
+	"github.com/shayanderson/go-project/server"
+)
+
+// testBucket is a bucket counting List calls and failing Put when fail is set
+type testBucket struct {
+	blob.Bucket
+	fail  bool
+	lists int
+}
+
+// List implements the blob.Bucket interface
+func (b *testBucket) List(ctx context.Context, prefix string) ([]blob.Object, error) {
+	b.lists++
+	return b.Bucket.List(ctx, prefix)
+}
+
+// Put implements the blob.Bucket interface
+func (b *testBucket) Put(ctx context.Context, key string, r io.Reader) error {
+	if b.fail {
+		return errors.New("put failed")
+	}
+	return b.Bucket.Put(ctx, key, r)
+}
+
+// uploadRequest returns a multipart upload request of a file of size bytes
+func uploadRequest(t *testing.T, size int) *http.Request {
+	t.Helper()
+	var b bytes.Buffer
+	mw := multipart.NewWriter(&b)
+	fw, err := mw.CreateFormFile("file", "a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fw.Write([]byte(strings.Repeat("a", size))); err != nil {
+		t.Fatal(err)
+	}
+	if err := mw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	r := httptest.NewRequest(http.MethodPost, "/files", &b)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+	return r
+}
+
+func TestFileHandlerQuota(t *testing.T) {
+	fb, err := blob.NewFileBucket(t.TempDir(), "http://localhost/files", []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fb.Put(context.Background(), "existing", strings.NewReader("12345")); err != nil {
+		t.Fatal(err)
+	}
+	bucket := &testBucket{Bucket: fb}
+	h := NewFileHandler(bucket, 20)
+
+	tests := []struct {
+		name   string
+		size   int
+		fail   bool
+		status int
+		used   int64
+	}{
+		{"fits", 10, false, http.StatusCreated, 15},
+		{"failed store released", 5, true, http.StatusInternalServerError, 15},
+		{"exceeds", 6, false, http.StatusInsufficientStorage, 15},
+		{"fills", 5, false, http.StatusCreated, 20},
+		{"full", 1, false, http.StatusInsufficientStorage, 20},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bucket.fail = tt.fail
+			w := httptest.NewRecorder()
+			server.Handler(h.Upload).ServeHTTP(w, uploadRequest(t, tt.size))
+			if w.Code != tt.status {
+				t.Errorf("got status %d, want %d", w.Code, tt.status)
+			}
+			if h.used != tt.used {
+				t.Errorf("got used %d, want %d", h.used, tt.used)
+			}
+		})
+	}
+
+	if bucket.lists != 1 {
+		t.Errorf("got %d bucket lists, want 1", bucket.lists)
+	}
+}
@@ -0,0 +1,23 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/shayanderson/go-project/internal/metrics"
+	"github.com/shayanderson/go-project/server"
+)
+
+// Metrics returns a handler that writes the registry metrics in the Prometheus text format
+func Metrics(reg *metrics.Registry) server.Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		return reg.WritePrometheus(w)
+	}
+}
+
+// RuntimeStats returns a handler that writes the last sampled runtime stats as JSON
+func RuntimeStats(c *metrics.RuntimeCollector) server.Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		return server.WriteJSON(w, http.StatusOK, c.Stats())
+	}
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/shayanderson/go-project/infra/crypto"
+	"github.com/shayanderson/go-project/server"
+)
+
+// AdminMiddleware allows requests with the admin bearer token, other requests receive a 401
+// response, all requests receive a 404 response when the token is empty so admin endpoints
+// are disabled by default
+func AdminMiddleware(token string) server.Middleware {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			if token == "" {
+				http.NotFound(w, r)
+				return
+			}
+
+			t, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || !crypto.Equal(t, token) {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				_ = server.WriteJSON(
+					w,
+					http.StatusUnauthorized,
+					map[string]string{"error": "unauthorized"},
+				)
+				return
+			}
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
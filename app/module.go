@@ -0,0 +1,25 @@
+package app
+
+import (
+	"context"
+
+	"github.com/shayanderson/go-project/server"
+)
+
+// Module is a self-contained service registered with the app, for example "orders", so
+// adding a service does not require editing the app wiring
+type Module interface {
+	// Name returns the module name, used in logs and shutdown hooks
+	Name() string
+
+	// Routes registers the module routes on the server
+	Routes(srv *server.Server)
+
+	// Start starts the module, it runs in its own goroutine and may block until ctx is done,
+	// an error stops the app
+	Start(ctx context.Context) error
+
+	// Stop stops the module, it runs in the stop workers shutdown phase after the server
+	// stopped accepting requests
+	Stop(ctx context.Context) error
+}
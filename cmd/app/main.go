@@ -7,6 +7,9 @@ import (
 	"os"
 	"time"
 
+	// embed the time zone database, the runtime image has none
+	_ "time/tzdata"
+
 	"github.com/shayanderson/go-project/app"
 	"github.com/shayanderson/go-project/app/config"
 )
@@ -25,9 +28,7 @@ var loggerOptions = &slog.HandlerOptions{
 }
 
 func init() {
-	if config.Config.Debug {
-		loggerOptions.Level = slog.LevelDebug
-	}
+	loggerOptions.Level = config.Config.LogLevel
 	slog.SetDefault(
 		slog.New(slog.NewJSONHandler(os.Stdout, loggerOptions)),
 	)
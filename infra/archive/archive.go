@@ -0,0 +1,171 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrTooLarge is returned when an archive exceeds an extract limit
+var ErrTooLarge = errors.New("archive: too large")
+
+// ErrUnsafePath is returned when an archive entry path escapes the destination directory
+var ErrUnsafePath = errors.New("archive: unsafe path")
+
+// ExtractOptions are the extract options
+type ExtractOptions struct {
+	// MaxEntries is the max number of entries, default 10000
+	MaxEntries int
+
+	// MaxFileSize is the max extracted size of a single file in bytes, default MaxSize
+	MaxFileSize int64
+
+	// MaxSize is the max total extracted size in bytes, sizes are counted while extracting so
+	// archive headers cannot understate them, default 1GB
+	MaxSize int64
+}
+
+// defaults sets the default options
+func (o *ExtractOptions) defaults() {
+	if o.MaxEntries <= 0 {
+		o.MaxEntries = 10000
+	}
+	if o.MaxSize <= 0 {
+		o.MaxSize = 1 << 30 // 1GB
+	}
+	if o.MaxFileSize <= 0 || o.MaxFileSize > o.MaxSize {
+		o.MaxFileSize = o.MaxSize
+	}
+}
+
+// entry is a file or directory found by walk
+type entry struct {
+	info fs.FileInfo
+	name string
+	path string
+}
+
+// walk calls fn for the directories and regular files in dir with their slash separated
+// name relative to dir, symlinks and other special files are skipped
+func walk(ctx context.Context, dir string, fn func(e entry) error) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if path == dir || (!d.IsDir() && !d.Type().IsRegular()) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+		if d.IsDir() {
+			name += "/"
+		}
+		return fn(entry{info: info, name: name, path: path})
+	})
+}
+
+// extractor writes archive entries to a directory within the extract limits
+type extractor struct {
+	dir     string
+	entries int
+	opts    ExtractOptions
+	size    int64
+}
+
+// newExtractor creates the destination directory and returns an extractor
+func newExtractor(dir string, opts ExtractOptions) (*extractor, error) {
+	opts.defaults()
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("archive: create directory: %w", err)
+	}
+	return &extractor{dir: dir, opts: opts}, nil
+}
+
+// path returns the destination path of an entry name, names must be local and no existing
+// path component may be a symlink, so entries cannot be written outside the directory
+func (x *extractor) path(name string) (string, error) {
+	name = strings.TrimSuffix(name, "/")
+	p := filepath.FromSlash(name)
+	if name == "" || strings.Contains(name, `\`) || !filepath.IsLocal(p) {
+		return "", fmt.Errorf("%w: %q", ErrUnsafePath, name)
+	}
+
+	path := x.dir
+	for _, part := range strings.Split(filepath.Clean(p), string(filepath.Separator)) {
+		path = filepath.Join(path, part)
+		fi, err := os.Lstat(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		if fi.Mode()&fs.ModeSymlink != 0 {
+			return "", fmt.Errorf("%w: %q", ErrUnsafePath, name)
+		}
+	}
+	return filepath.Join(x.dir, p), nil
+}
+
+// entry counts an entry against the entry limit, skipped entries are counted too
+func (x *extractor) entry() error {
+	x.entries++
+	if x.entries > x.opts.MaxEntries {
+		return fmt.Errorf("%w: more than %d entries", ErrTooLarge, x.opts.MaxEntries)
+	}
+	return nil
+}
+
+// mkdir creates a directory entry
+func (x *extractor) mkdir(name string) error {
+	p, err := x.path(name)
+	if err != nil {
+		return err
+	}
+	return os.MkdirAll(p, 0o750)
+}
+
+// file writes a file entry, the permissions of the entry are kept without group and other
+// write permissions
+func (x *extractor) file(name string, mode fs.FileMode, r io.Reader) error {
+	p, err := x.path(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o750); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(p, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode.Perm()&0o755|0o600)
+	if err != nil {
+		return err
+	}
+	max := min(x.opts.MaxFileSize, x.opts.MaxSize-x.size)
+	n, err := io.CopyN(f, r, max+1)
+	x.size += n
+	if cerr := f.Close(); err == nil || errors.Is(err, io.EOF) {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	if n > max {
+		return fmt.Errorf("%w: %q exceeds the size limit", ErrTooLarge, name)
+	}
+	return nil
+}
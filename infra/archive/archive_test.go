@@ -0,0 +1,283 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testEntry is an archive entry written by the test archive builders
+type testEntry struct {
+	body string
+	link string
+	name string
+}
+
+// buildZip returns a zip archive of the entries
+func buildZip(t *testing.T, entries []testEntry) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	zw := zip.NewWriter(&b)
+	for _, e := range entries {
+		h := &zip.FileHeader{Name: e.name, Method: zip.Deflate}
+		body := e.body
+		h.SetMode(0o644)
+		if e.link != "" {
+			h.SetMode(fs.ModeSymlink | 0o777)
+			body = e.link
+		}
+		w, err := zw.CreateHeader(h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
+// buildTarGz returns a tar.gz archive of the entries
+func buildTarGz(t *testing.T, entries []testEntry) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	gw := gzip.NewWriter(&b)
+	tw := tar.NewWriter(gw)
+	for _, e := range entries {
+		h := &tar.Header{Name: e.name, Mode: 0o644, Size: int64(len(e.body))}
+		if e.link != "" {
+			h = &tar.Header{Name: e.name, Typeflag: tar.TypeSymlink, Linkname: e.link}
+		}
+		if err := tw.WriteHeader(h); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(e.body)); err != nil && e.link == "" {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
+// formats are the archive formats under test
+var formats = []struct {
+	name    string
+	build   func(t *testing.T, entries []testEntry) []byte
+	create  func(ctx context.Context, b *bytes.Buffer, dir string) error
+	extract func(ctx context.Context, b []byte, dir string, opts ExtractOptions) error
+}{
+	{
+		name:  "zip",
+		build: buildZip,
+		create: func(ctx context.Context, b *bytes.Buffer, dir string) error {
+			return CreateZip(ctx, b, dir)
+		},
+		extract: func(ctx context.Context, b []byte, dir string, opts ExtractOptions) error {
+			return ExtractZip(ctx, bytes.NewReader(b), int64(len(b)), dir, opts)
+		},
+	},
+	{
+		name:  "tar.gz",
+		build: buildTarGz,
+		create: func(ctx context.Context, b *bytes.Buffer, dir string) error {
+			return CreateTarGz(ctx, b, dir)
+		},
+		extract: func(ctx context.Context, b []byte, dir string, opts ExtractOptions) error {
+			return ExtractTarGz(ctx, bytes.NewReader(b), dir, opts)
+		},
+	},
+}
+
+// readTree returns the regular files in dir by slash separated relative path
+func readTree(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	files := map[string]string{}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, path)
+		files[filepath.ToSlash(rel)] = string(b)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func TestRoundTrip(t *testing.T) {
+	src := t.TempDir()
+	files := map[string]string{
+		"a.txt":         "a",
+		"dir/b.txt":     strings.Repeat("b", 100000),
+		"dir/sub/c.txt": "",
+	}
+	for name, body := range files {
+		p := filepath.Join(src, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(body), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Join(src, "empty"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("/etc/passwd", filepath.Join(src, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, f := range formats {
+		t.Run(f.name, func(t *testing.T) {
+			var b bytes.Buffer
+			if err := f.create(context.Background(), &b, src); err != nil {
+				t.Fatalf("create: %v", err)
+			}
+			dst := filepath.Join(t.TempDir(), "out")
+			if err := f.extract(context.Background(), b.Bytes(), dst, ExtractOptions{}); err != nil {
+				t.Fatalf("extract: %v", err)
+			}
+
+			got := readTree(t, dst)
+			if len(got) != len(files) {
+				t.Errorf("got %d files, want %d", len(got), len(files))
+			}
+			for name, body := range files {
+				if got[name] != body {
+					t.Errorf("file %s: got %d bytes, want %d", name, len(got[name]), len(body))
+				}
+			}
+			if fi, err := os.Stat(filepath.Join(dst, "empty")); err != nil || !fi.IsDir() {
+				t.Errorf("empty directory not extracted: %v", err)
+			}
+			if _, err := os.Lstat(filepath.Join(dst, "link")); !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("symlink extracted: %v", err)
+			}
+		})
+	}
+}
+
+func TestExtractErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []testEntry
+		opts    ExtractOptions
+		want    error
+	}{
+		{
+			"parent path",
+			[]testEntry{{name: "../evil.txt", body: "x"}},
+			ExtractOptions{},
+			ErrUnsafePath,
+		},
+		{
+			"nested parent path",
+			[]testEntry{{name: "a/../../evil.txt", body: "x"}},
+			ExtractOptions{},
+			ErrUnsafePath,
+		},
+		{
+			"absolute path",
+			[]testEntry{{name: "/tmp/evil.txt", body: "x"}},
+			ExtractOptions{},
+			ErrUnsafePath,
+		},
+		{
+			"backslash path",
+			[]testEntry{{name: `..\evil.txt`, body: "x"}},
+			ExtractOptions{},
+			ErrUnsafePath,
+		},
+		{
+			"file too large",
+			[]testEntry{{name: "a", body: "12345"}},
+			ExtractOptions{MaxFileSize: 4},
+			ErrTooLarge,
+		},
+		{
+			"total too large",
+			[]testEntry{{name: "a", body: "123"}, {name: "b", body: "123"}},
+			ExtractOptions{MaxSize: 5},
+			ErrTooLarge,
+		},
+		{
+			"too many entries",
+			[]testEntry{{name: "a"}, {name: "b"}, {name: "c", link: "a"}},
+			ExtractOptions{MaxEntries: 2},
+			ErrTooLarge,
+		},
+	}
+
+	for _, f := range formats {
+		for _, tt := range tests {
+			t.Run(f.name+"/"+tt.name, func(t *testing.T) {
+				dir := t.TempDir()
+				dst := filepath.Join(dir, "out")
+				err := f.extract(context.Background(), f.build(t, tt.entries), dst, tt.opts)
+				if !errors.Is(err, tt.want) {
+					t.Fatalf("got error %v, want %v", err, tt.want)
+				}
+				if _, err := os.Stat(filepath.Join(dir, "evil.txt")); err == nil {
+					t.Error("file written outside of the destination")
+				}
+			})
+		}
+	}
+}
+
+func TestExtractSymlinks(t *testing.T) {
+	for _, f := range formats {
+		t.Run(f.name, func(t *testing.T) {
+			outside := t.TempDir()
+			dst := t.TempDir()
+			// an archive symlink entry is skipped, so a later entry cannot write through it
+			entries := []testEntry{
+				{name: "link", link: outside},
+				{name: "link/evil.txt", body: "x"},
+			}
+			err := f.extract(context.Background(), f.build(t, entries), dst, ExtractOptions{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if _, err := os.Stat(filepath.Join(outside, "evil.txt")); err == nil {
+				t.Error("file written through archive symlink")
+			}
+
+			// an existing symlink in the destination is not followed
+			dst = t.TempDir()
+			if err := os.Symlink(outside, filepath.Join(dst, "link")); err != nil {
+				t.Fatal(err)
+			}
+			entries = []testEntry{{name: "link/evil.txt", body: "x"}}
+			err = f.extract(context.Background(), f.build(t, entries), dst, ExtractOptions{})
+			if !errors.Is(err, ErrUnsafePath) {
+				t.Errorf("got error %v, want %v", err, ErrUnsafePath)
+			}
+			if _, err := os.Stat(filepath.Join(outside, "evil.txt")); err == nil {
+				t.Error("file written through destination symlink")
+			}
+		})
+	}
+}
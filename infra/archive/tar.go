@@ -0,0 +1,94 @@
+package archive
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// CreateTarGz writes a gzip compressed tar archive of the directories and regular files in
+// dir to w, symlinks and other special files are skipped
+func CreateTarGz(ctx context.Context, w io.Writer, dir string) error {
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+	err := walk(ctx, dir, func(e entry) error {
+		h, err := tar.FileInfoHeader(e.info, "")
+		if err != nil {
+			return err
+		}
+		h.Name = e.name
+		if err := tw.WriteHeader(h); err != nil {
+			return err
+		}
+		if e.info.IsDir() {
+			return nil
+		}
+
+		f, err := os.Open(e.path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		// the header size is written first, so a file growing while it is archived is cut
+		_, err = io.CopyN(tw, f, h.Size)
+		return err
+	})
+	if err == nil {
+		err = tw.Close()
+	}
+	if err == nil {
+		err = gw.Close()
+	}
+	if err != nil {
+		return fmt.Errorf("archive: create tar.gz: %w", err)
+	}
+	return nil
+}
+
+// ExtractTarGz extracts a gzip compressed tar archive to dir, entries with paths escaping dir
+// return ErrUnsafePath and archives exceeding the options limits return ErrTooLarge,
+// symlinks, hard links and other special files are skipped, files extracted before an error
+// are kept so extract to a new directory
+func ExtractTarGz(ctx context.Context, r io.Reader, dir string, opts ExtractOptions) error {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("archive: extract tar.gz: %w", err)
+	}
+	defer gr.Close()
+	x, err := newExtractor(dir, opts)
+	if err != nil {
+		return err
+	}
+
+	tr := tar.NewReader(gr)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		h, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("archive: extract tar.gz: %w", err)
+		}
+
+		mode := h.FileInfo().Mode()
+		if err := x.entry(); err != nil {
+			return fmt.Errorf("archive: extract tar.gz: %w", err)
+		}
+		switch {
+		case mode.IsDir():
+			err = x.mkdir(h.Name)
+		case mode.IsRegular():
+			err = x.file(h.Name, mode, tr)
+		}
+		if err != nil {
+			return fmt.Errorf("archive: extract tar.gz: %w", err)
+		}
+	}
+}
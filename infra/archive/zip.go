@@ -0,0 +1,105 @@
+package archive
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+)
+
+// CreateZip writes a zip archive of the directories and regular files in dir to w, symlinks
+// and other special files are skipped
+func CreateZip(ctx context.Context, w io.Writer, dir string) error {
+	zw := zip.NewWriter(w)
+	err := walk(ctx, dir, func(e entry) error {
+		h, err := zip.FileInfoHeader(e.info)
+		if err != nil {
+			return err
+		}
+		h.Name = e.name
+		if e.info.IsDir() {
+			_, err = zw.CreateHeader(h)
+			return err
+		}
+		h.Method = zip.Deflate
+
+		fw, err := zw.CreateHeader(h)
+		if err != nil {
+			return err
+		}
+		f, err := os.Open(e.path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(fw, f)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("archive: create zip: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("archive: create zip: %w", err)
+	}
+	return nil
+}
+
+// ExtractZip extracts a zip archive of size bytes to dir, entries with paths escaping dir
+// return ErrUnsafePath and archives exceeding the options limits return ErrTooLarge,
+// symlinks and other special files are skipped, files extracted before an error are kept so
+// extract to a new directory
+func ExtractZip(
+	ctx context.Context,
+	r io.ReaderAt,
+	size int64,
+	dir string,
+	opts ExtractOptions,
+) error {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return fmt.Errorf("archive: extract zip: %w", err)
+	}
+	x, err := newExtractor(dir, opts)
+	if err != nil {
+		return err
+	}
+	// the entry count is known upfront, so too many entries fail before any extraction
+	if len(zr.File) > x.opts.MaxEntries {
+		return fmt.Errorf(
+			"archive: extract zip: %w: more than %d entries",
+			ErrTooLarge,
+			x.opts.MaxEntries,
+		)
+	}
+
+	for _, f := range zr.File {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		mode := f.Mode()
+		if err := x.entry(); err != nil {
+			return fmt.Errorf("archive: extract zip: %w", err)
+		}
+		switch {
+		case mode.IsDir():
+			err = x.mkdir(f.Name)
+		case mode.IsRegular():
+			err = extractZipFile(x, f)
+		}
+		if err != nil {
+			return fmt.Errorf("archive: extract zip: %w", err)
+		}
+	}
+	return nil
+}
+
+// extractZipFile extracts a zip file entry
+func extractZipFile(x *extractor, f *zip.File) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return x.file(f.Name, f.Mode(), rc)
+}
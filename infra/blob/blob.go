@@ -0,0 +1,105 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// ErrNotFound is returned when an object does not exist
+var ErrNotFound = errors.New("blob: object not found")
+
+// ErrInvalidKey is returned when an object key is empty, escapes the bucket or is the bucket
+// root
+var ErrInvalidKey = errors.New("blob: invalid key")
+
+// ErrTooLarge is returned by Upload when the reader exceeds the size limit
+var ErrTooLarge = errors.New("blob: object too large")
+
+// Object is an object stored in a bucket
+type Object struct {
+	// Key is the object key
+	Key string `json:"key"`
+
+	// Size is the object size in bytes
+	Size int64 `json:"size"`
+
+	// ModTime is the last modification time
+	ModTime time.Time `json:"mod_time"`
+}
+
+// Bucket is an object storage bucket, implementations must be safe for concurrent use
+type Bucket interface {
+	// Delete deletes an object, deleting an object that does not exist is not an error
+	Delete(ctx context.Context, key string) error
+
+	// Get returns a reader for an object, the caller must close the reader
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+
+	// List returns the objects with the key prefix, sorted by key
+	List(ctx context.Context, prefix string) ([]Object, error)
+
+	// Put writes an object from a reader, replacing any existing object
+	Put(ctx context.Context, key string, r io.Reader) error
+
+	// SignedURL returns a URL that grants temporary read access to an object
+	SignedURL(ctx context.Context, key string, expires time.Duration) (string, error)
+}
+
+// Download streams an object to a writer and returns the number of bytes written
+func Download(ctx context.Context, b Bucket, key string, w io.Writer) (int64, error) {
+	rc, err := b.Get(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	defer rc.Close()
+
+	n, err := io.Copy(w, rc)
+	if err != nil {
+		return n, fmt.Errorf("blob: download %q: %w", key, err)
+	}
+	return n, nil
+}
+
+// Upload streams a reader to an object, limiting the object size to max bytes when max > 0
+func Upload(ctx context.Context, b Bucket, key string, r io.Reader, max int64) error {
+	if max > 0 {
+		r = &limitReader{r: r, n: max}
+	}
+	return b.Put(ctx, key, r)
+}
+
+// UploadFile streams a local file to an object
+func UploadFile(ctx context.Context, b Bucket, key, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("blob: upload file: %w", err)
+	}
+	defer f.Close()
+	return b.Put(ctx, key, f)
+}
+
+// limitReader is an io.Reader that fails when more than n bytes are read
+type limitReader struct {
+	r io.Reader
+	n int64
+}
+
+// Read implements the io.Reader interface
+func (l *limitReader) Read(p []byte) (int, error) {
+	if l.n < 0 {
+		return 0, ErrTooLarge
+	}
+	if int64(len(p)) > l.n+1 {
+		p = p[:l.n+1]
+	}
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	if l.n < 0 {
+		return n, ErrTooLarge
+	}
+	return n, err
+}
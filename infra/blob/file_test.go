@@ -0,0 +1,43 @@
+package blob
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func FuzzFileBucketPath(f *testing.F) {
+	for _, s := range []string{
+		"a.txt",
+		"dir/b.txt",
+		"../a",
+		"/etc/passwd",
+		"a/../../b",
+		`..\a`,
+		"a/./b",
+		".",
+		"a/..",
+		"",
+		"NUL",
+	} {
+		f.Add(s)
+	}
+	root := filepath.Join(f.TempDir(), "bucket")
+	b := &FileBucket{root: root}
+	f.Fuzz(func(t *testing.T, key string) {
+		p, err := b.path(key)
+		if err != nil {
+			if !errors.Is(err, ErrInvalidKey) {
+				t.Fatalf("got error %v, want %v", err, ErrInvalidKey)
+			}
+			return
+		}
+		// valid keys must stay inside the bucket root
+		rel, err := filepath.Rel(root, p)
+		if err != nil || rel == "." || rel == ".." ||
+			strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			t.Fatalf("key %q resolves to %q outside of %q", key, p, root)
+		}
+	})
+}
@@ -0,0 +1,181 @@
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// ErrDecrypt is returned when a ciphertext cannot be decrypted
+var ErrDecrypt = errors.New("crypto: decrypt failed")
+
+// ErrUnknownKey is returned when a ciphertext was encrypted with a key not in the keyring
+var ErrUnknownKey = errors.New("crypto: unknown key")
+
+// version is the ciphertext format version
+const version byte = 1
+
+// headerSize is the ciphertext header size: version (1) and key ID (4)
+const headerSize = 5
+
+// Key is an AES key, the secret must be 16, 24 or 32 bytes (AES-128, AES-192 or AES-256)
+type Key struct {
+	// ID identifies the key in ciphertexts, it must be unique in a keyring
+	ID uint32
+
+	// Secret is the key material
+	Secret []byte
+}
+
+// Keyring encrypts with a primary key and decrypts with any of its keys, so keys can be
+// rotated by making a new key primary and keeping old keys until data is re-encrypted
+type Keyring struct {
+	aeads   map[uint32]cipher.AEAD
+	primary uint32
+}
+
+// NewKeyring creates a new Keyring with the primary key and old decrypt-only keys
+func NewKeyring(primary Key, old ...Key) (*Keyring, error) {
+	k := &Keyring{
+		aeads:   map[uint32]cipher.AEAD{},
+		primary: primary.ID,
+	}
+	for _, key := range append([]Key{primary}, old...) {
+		if _, ok := k.aeads[key.ID]; ok {
+			return nil, fmt.Errorf("crypto: duplicate key id %d", key.ID)
+		}
+		block, err := aes.NewCipher(key.Secret)
+		if err != nil {
+			return nil, fmt.Errorf("crypto: key %d: %w", key.ID, err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("crypto: key %d: %w", key.ID, err)
+		}
+		k.aeads[key.ID] = aead
+	}
+	return k, nil
+}
+
+// Decrypt decrypts a ciphertext returned by Encrypt with the same additional data
+func (k *Keyring) Decrypt(ciphertext, aad []byte) ([]byte, error) {
+	if len(ciphertext) < headerSize || ciphertext[0] != version {
+		return nil, ErrDecrypt
+	}
+	aead, ok := k.aeads[binary.BigEndian.Uint32(ciphertext[1:headerSize])]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	ct := ciphertext[headerSize:]
+	if len(ct) < aead.NonceSize() {
+		return nil, ErrDecrypt
+	}
+	// the header is authenticated with the additional data
+	data := append(append([]byte{}, ciphertext[:headerSize]...), aad...)
+	plaintext, err := aead.Open(nil, ct[:aead.NonceSize()], ct[aead.NonceSize():], data)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plaintext, nil
+}
+
+// DecryptString decrypts a string returned by EncryptString
+func (k *Keyring) DecryptString(s string, aad []byte) ([]byte, error) {
+	ct, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return k.Decrypt(ct, aad)
+}
+
+// Encrypt encrypts the plaintext with the primary key using AES-GCM, the additional data is
+// authenticated but not encrypted and must be passed unchanged to Decrypt, the ciphertext is
+// version | key ID | nonce | sealed data
+func (k *Keyring) Encrypt(plaintext, aad []byte) ([]byte, error) {
+	aead := k.aeads[k.primary]
+	out := make([]byte, headerSize, headerSize+aead.NonceSize()+len(plaintext)+aead.Overhead())
+	out[0] = version
+	binary.BigEndian.PutUint32(out[1:headerSize], k.primary)
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("crypto: nonce: %w", err)
+	}
+	data := append(append([]byte{}, out...), aad...)
+	out = append(out, nonce...)
+	return aead.Seal(out, nonce, plaintext, data), nil
+}
+
+// EncryptString encrypts the plaintext and returns it base64 (URL safe, no padding) encoded
+func (k *Keyring) EncryptString(plaintext, aad []byte) (string, error) {
+	ct, err := k.Encrypt(plaintext, aad)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(ct), nil
+}
+
+// NeedsRotation returns true if the ciphertext was not encrypted with the primary key
+func (k *Keyring) NeedsRotation(ciphertext []byte) bool {
+	if len(ciphertext) < headerSize {
+		return false
+	}
+	return binary.BigEndian.Uint32(ciphertext[1:headerSize]) != k.primary
+}
+
+// Equal compares two strings in constant time
+func Equal(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// RandomBytes returns n cryptographically secure random bytes
+func RandomBytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return nil, fmt.Errorf("crypto: random: %w", err)
+	}
+	return b, nil
+}
+
+// RandomToken returns a token of n random bytes base64 (URL safe, no padding) encoded, use at
+// least 32 bytes for secrets like API keys and session IDs
+func RandomToken(n int) (string, error) {
+	b, err := RandomBytes(n)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Sign returns the HMAC-SHA256 of the message
+func Sign(key, msg []byte) []byte {
+	m := hmac.New(sha256.New, key)
+	m.Write(msg)
+	return m.Sum(nil)
+}
+
+// SignString returns the HMAC-SHA256 of the message base64 (URL safe, no padding) encoded
+func SignString(key, msg []byte) string {
+	return base64.RawURLEncoding.EncodeToString(Sign(key, msg))
+}
+
+// Verify verifies the HMAC-SHA256 signature of the message in constant time
+func Verify(key, msg, sig []byte) bool {
+	return hmac.Equal(Sign(key, msg), sig)
+}
+
+// VerifyString verifies a signature returned by SignString in constant time
+func VerifyString(key, msg []byte, sig string) bool {
+	b, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil {
+		return false
+	}
+	return Verify(key, msg, b)
+}
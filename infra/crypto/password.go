@@ -0,0 +1,140 @@
+package crypto
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrPasswordHash is returned when a password hash is malformed
+var ErrPasswordHash = errors.New("crypto: invalid password hash")
+
+// DefaultPasswordIterations is the default PBKDF2-HMAC-SHA256 iteration count, the OWASP
+// recommended minimum
+const DefaultPasswordIterations = 600_000
+
+// MinPasswordIterations is the min iteration count accepted by HashPasswordCost
+const MinPasswordIterations = 100_000
+
+// MaxPasswordLength is the max password length in bytes
+const MaxPasswordLength = 1024
+
+// passwordPrefix is the password hash algorithm identifier
+const passwordPrefix = "$pbkdf2-sha256$"
+
+// passwordSaltSize is the password salt size in bytes
+const passwordSaltSize = 16
+
+// passwordKeySize is the derived key size in bytes
+const passwordKeySize = 32
+
+// HashPassword hashes a password with DefaultPasswordIterations
+func HashPassword(password string) (string, error) {
+	return HashPasswordCost(password, DefaultPasswordIterations)
+}
+
+// HashPasswordCost hashes a password using PBKDF2-HMAC-SHA256 with a random salt and returns
+// it in the format "$pbkdf2-sha256$i=<iterations>$<salt>$<key>", bcrypt and argon2id are
+// not used since they are not in the standard library
+func HashPasswordCost(password string, iterations int) (string, error) {
+	if iterations < MinPasswordIterations {
+		return "", fmt.Errorf("crypto: password iterations must be at least %d", MinPasswordIterations)
+	}
+	if len(password) > MaxPasswordLength {
+		return "", errors.New("crypto: password too long")
+	}
+	salt, err := RandomBytes(passwordSaltSize)
+	if err != nil {
+		return "", err
+	}
+	key := pbkdf2([]byte(password), salt, iterations, passwordKeySize)
+	return fmt.Sprintf(
+		"%si=%d$%s$%s",
+		passwordPrefix,
+		iterations,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// PasswordNeedsRehash returns true if the hash uses fewer iterations than the iterations or
+// is malformed, rehash the password on the next successful login
+func PasswordNeedsRehash(hash string, iterations int) bool {
+	i, _, _, err := parsePasswordHash(hash)
+	return err != nil || i < iterations
+}
+
+// VerifyPassword returns true if the password matches the hash, the comparison is constant
+// time
+func VerifyPassword(hash, password string) (bool, error) {
+	iterations, salt, key, err := parsePasswordHash(hash)
+	if err != nil {
+		return false, err
+	}
+	if len(password) > MaxPasswordLength {
+		return false, nil
+	}
+	k := pbkdf2([]byte(password), salt, iterations, len(key))
+	return subtle.ConstantTimeCompare(k, key) == 1, nil
+}
+
+// parsePasswordHash parses a hash returned by HashPasswordCost
+func parsePasswordHash(hash string) (int, []byte, []byte, error) {
+	rest, ok := strings.CutPrefix(hash, passwordPrefix)
+	if !ok {
+		return 0, nil, nil, ErrPasswordHash
+	}
+	parts := strings.Split(rest, "$")
+	if len(parts) != 3 || !strings.HasPrefix(parts[0], "i=") {
+		return 0, nil, nil, ErrPasswordHash
+	}
+	iterations, err := strconv.Atoi(parts[0][2:])
+	if err != nil || iterations < 1 {
+		return 0, nil, nil, ErrPasswordHash
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return 0, nil, nil, ErrPasswordHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil || len(key) == 0 {
+		return 0, nil, nil, ErrPasswordHash
+	}
+	return iterations, salt, key, nil
+}
+
+// pbkdf2 derives a key using PBKDF2 (RFC 8018) with HMAC-SHA256
+func pbkdf2(password, salt []byte, iterations, keyLen int) []byte {
+	prf := hmac.New(sha256.New, password)
+	size := prf.Size()
+	blocks := (keyLen + size - 1) / size
+
+	var n [4]byte
+	dk := make([]byte, 0, blocks*size)
+	u := make([]byte, size)
+	for block := 1; block <= blocks; block++ {
+		prf.Reset()
+		prf.Write(salt)
+		binary.BigEndian.PutUint32(n[:], uint32(block))
+		prf.Write(n[:])
+		dk = prf.Sum(dk)
+		t := dk[len(dk)-size:]
+		copy(u, t)
+
+		for i := 2; i <= iterations; i++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for j := range u {
+				t[j] ^= u[j]
+			}
+		}
+	}
+	return dk[:keyLen]
+}
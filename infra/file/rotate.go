@@ -0,0 +1,273 @@
+package file
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shayanderson/go-project/internal/work"
+)
+
+// backupTimeFormat is the time format of backup file names, it sorts by time and contains no
+// characters that are invalid in file names
+const backupTimeFormat = "20060102T150405.000"
+
+// ErrClosed is returned when writing to a closed RotatingWriter
+var ErrClosed = errors.New("file: writer closed")
+
+// RotateOptions are the rotating writer options
+type RotateOptions struct {
+	// Compress gzips rotated files in the background
+	Compress bool
+
+	// MaxAge is the max age of rotated files, older files are removed, 0 keeps rotated files
+	// regardless of age
+	MaxAge time.Duration
+
+	// MaxBackups is the max number of rotated files, the oldest files are removed, 0 keeps
+	// all rotated files
+	MaxBackups int
+
+	// MaxSize is the size in bytes at which the file is rotated, default 100MB
+	MaxSize int64
+}
+
+// RotatingWriter is an io.Writer appending to a file that is rotated when it reaches the max
+// size, rotated files are renamed with their rotation time, for example app.log is rotated
+// to app-20240102T030405.000.log, it is safe for concurrent use
+type RotatingWriter struct {
+	file   *os.File
+	millMu sync.Mutex
+	mu     sync.Mutex
+	opts   RotateOptions
+	path   string
+	size   int64
+	wg     sync.WaitGroup
+}
+
+// NewRotatingWriter opens or creates the file at path for appending, the directory is created
+// when it does not exist
+func NewRotatingWriter(path string, opts RotateOptions) (*RotatingWriter, error) {
+	if opts.MaxSize <= 0 {
+		opts.MaxSize = 100 << 20 // 100MB
+	}
+	w := &RotatingWriter{opts: opts, path: path}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Write implements the io.Writer interface, the file is rotated first when the write would
+// exceed the max size, a write larger than the max size is written to a new file as is
+func (w *RotatingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return 0, ErrClosed
+	}
+	if w.size > 0 && w.size+int64(len(p)) > w.opts.MaxSize {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Rotate rotates the file, for example on SIGHUP
+func (w *RotatingWriter) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return ErrClosed
+	}
+	return w.rotate()
+}
+
+// Close closes the file and waits for background compression and removal of rotated files
+func (w *RotatingWriter) Close() error {
+	w.mu.Lock()
+	var err error
+	if w.file != nil {
+		err = w.file.Close()
+		w.file = nil
+	}
+	w.mu.Unlock()
+	w.wg.Wait()
+	return err
+}
+
+// open opens the file for appending, w.mu must be held
+func (w *RotatingWriter) open() error {
+	if err := os.MkdirAll(filepath.Dir(w.path), 0o750); err != nil {
+		return fmt.Errorf("file: create directory: %w", err)
+	}
+	f, err := os.OpenFile(w.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o640)
+	if err != nil {
+		return fmt.Errorf("file: open: %w", err)
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("file: open: %w", err)
+	}
+	w.file = f
+	w.size = fi.Size()
+	return nil
+}
+
+// rotate renames the file to a backup name and opens a new file, rotated files are compressed
+// and removed in the background, w.mu must be held
+func (w *RotatingWriter) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return fmt.Errorf("file: rotate: %w", err)
+	}
+	w.file = nil
+
+	name := w.backupName(time.Now().UTC())
+	if err := os.Rename(w.path, name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		// keep appending to the current file rather than losing writes
+		if oerr := w.open(); oerr != nil {
+			return errors.Join(fmt.Errorf("file: rotate: %w", err), oerr)
+		}
+		return fmt.Errorf("file: rotate: %w", err)
+	}
+	if err := w.open(); err != nil {
+		return err
+	}
+
+	w.wg.Add(1)
+	work.Go(context.Background(), func(context.Context) {
+		defer w.wg.Done()
+		w.mill()
+	})
+	return nil
+}
+
+// backupName returns an unused backup file name for the rotation time
+func (w *RotatingWriter) backupName(t time.Time) string {
+	prefix, ext := w.backupPrefix()
+	for {
+		name := prefix + t.Format(backupTimeFormat) + ext
+		_, err := os.Lstat(name)
+		_, gzErr := os.Lstat(name + ".gz")
+		if errors.Is(err, fs.ErrNotExist) && errors.Is(gzErr, fs.ErrNotExist) {
+			return name
+		}
+		t = t.Add(time.Millisecond)
+	}
+}
+
+// backupPrefix returns the backup file name prefix including the directory, and the file
+// extension
+func (w *RotatingWriter) backupPrefix() (prefix, ext string) {
+	ext = filepath.Ext(w.path)
+	return strings.TrimSuffix(w.path, ext) + "-", ext
+}
+
+// backup is a rotated file
+type backup struct {
+	path string
+	time time.Time
+}
+
+// backups returns the rotated files, newest first
+func (w *RotatingWriter) backups() ([]backup, error) {
+	prefix, ext := w.backupPrefix()
+	entries, err := os.ReadDir(filepath.Dir(w.path))
+	if err != nil {
+		return nil, err
+	}
+
+	var list []backup
+	for _, e := range entries {
+		path := filepath.Join(filepath.Dir(w.path), e.Name())
+		if !e.Type().IsRegular() || !strings.HasPrefix(path, prefix) {
+			continue
+		}
+		ts := strings.TrimSuffix(strings.TrimPrefix(path, prefix), ".gz")
+		if !strings.HasSuffix(ts, ext) {
+			continue
+		}
+		t, err := time.Parse(backupTimeFormat, strings.TrimSuffix(ts, ext))
+		if err != nil {
+			continue
+		}
+		list = append(list, backup{path: path, time: t})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].time.After(list[j].time)
+	})
+	return list, nil
+}
+
+// mill removes rotated files over the max backups or max age and compresses the others,
+// errors are ignored so a failed cleanup is retried on the next rotation
+func (w *RotatingWriter) mill() {
+	w.millMu.Lock()
+	defer w.millMu.Unlock()
+
+	list, err := w.backups()
+	if err != nil {
+		return
+	}
+	cutoff := time.Now().Add(-w.opts.MaxAge)
+	for i, b := range list {
+		if (w.opts.MaxBackups > 0 && i >= w.opts.MaxBackups) ||
+			(w.opts.MaxAge > 0 && b.time.Before(cutoff)) {
+			_ = os.Remove(b.path)
+			continue
+		}
+		if w.opts.Compress && !strings.HasSuffix(b.path, ".gz") {
+			_ = compress(b.path)
+		}
+	}
+}
+
+// compress gzips a file to the file path with a .gz suffix and removes the file
+func compress(path string) (err error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	tmp := path + ".gz.tmp"
+	dst, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o640)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(tmp)
+		}
+	}()
+
+	gw := gzip.NewWriter(dst)
+	if _, err := io.Copy(gw, src); err != nil {
+		dst.Close()
+		return err
+	}
+	if err := gw.Close(); err != nil {
+		dst.Close()
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path+".gz"); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
@@ -0,0 +1,208 @@
+package file
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readBackups returns the contents of the rotated files of path, oldest first
+func readBackups(t *testing.T, path string) []string {
+	t.Helper()
+	w := &RotatingWriter{path: path}
+	list, err := w.backups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].time.Before(list[j].time)
+	})
+
+	var bodies []string
+	for _, b := range list {
+		f, err := os.Open(b.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var r io.Reader = f
+		if strings.HasSuffix(b.path, ".gz") {
+			gr, err := gzip.NewReader(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r = gr
+		}
+		body, err := io.ReadAll(r)
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		bodies = append(bodies, string(body))
+	}
+	return bodies
+}
+
+func TestRotatingWriter(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    RotateOptions
+		writes  []string
+		current string
+		backups []string
+	}{
+		{
+			"no rotation",
+			RotateOptions{MaxSize: 10},
+			[]string{"12345", "12345"},
+			"1234512345",
+			nil,
+		},
+		{
+			"rotate at max size",
+			RotateOptions{MaxSize: 10},
+			[]string{"12345", "12345", "a", "bcdefghij", "k"},
+			"k",
+			[]string{"1234512345", "abcdefghij"},
+		},
+		{
+			"write larger than max size",
+			RotateOptions{MaxSize: 4},
+			[]string{"ab", "cdefgh", "ij"},
+			"ij",
+			[]string{"ab", "cdefgh"},
+		},
+		{
+			"max backups",
+			RotateOptions{MaxBackups: 2, MaxSize: 1},
+			[]string{"a", "b", "c", "d", "e"},
+			"e",
+			[]string{"c", "d"},
+		},
+		{
+			"compress",
+			RotateOptions{Compress: true, MaxSize: 1},
+			[]string{"a", "b", "c"},
+			"c",
+			[]string{"a", "b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "logs", "app.log")
+			w, err := NewRotatingWriter(path, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, s := range tt.writes {
+				if n, err := w.Write([]byte(s)); err != nil || n != len(s) {
+					t.Fatalf("write %q: got %d, %v", s, n, err)
+				}
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			b, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != tt.current {
+				t.Errorf("got current %q, want %q", b, tt.current)
+			}
+			got := readBackups(t, path)
+			if strings.Join(got, ",") != strings.Join(tt.backups, ",") {
+				t.Errorf("got backups %q, want %q", got, tt.backups)
+			}
+			if tt.opts.Compress {
+				matches, _ := filepath.Glob(filepath.Join(filepath.Dir(path), "app-*.log"))
+				if len(matches) > 0 {
+					t.Errorf("uncompressed backups: %v", matches)
+				}
+			}
+		})
+	}
+}
+
+func TestRotatingWriterAppend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(path, []byte("12345"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	w, err := NewRotatingWriter(path, RotateOptions{MaxSize: 8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the existing file size counts toward the max size
+	if _, err := w.Write([]byte("6789")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := readBackups(t, path); len(got) != 1 || got[0] != "12345" {
+		t.Errorf("got backups %q, want [12345]", got)
+	}
+}
+
+func TestRotatingWriterMaxAge(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	ts := time.Now().Add(-48 * time.Hour).UTC().Format(backupTimeFormat)
+	old := filepath.Join(dir, "app-"+ts+".log")
+	other := filepath.Join(dir, "other-20000101T000000.000.log")
+	for _, p := range []string{old, other} {
+		if err := os.WriteFile(p, []byte("old"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w, err := NewRotatingWriter(path, RotateOptions{MaxAge: 24 * time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("new")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(old); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("old backup not removed: %v", err)
+	}
+	if _, err := os.Stat(other); err != nil {
+		t.Errorf("unrelated file removed: %v", err)
+	}
+	if got := readBackups(t, path); len(got) != 1 || got[0] != "new" {
+		t.Errorf("got backups %q, want [new]", got)
+	}
+}
+
+func TestRotatingWriterClosed(t *testing.T) {
+	w, err := NewRotatingWriter(filepath.Join(t.TempDir(), "app.log"), RotateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("x")); !errors.Is(err, ErrClosed) {
+		t.Errorf("got error %v, want %v", err, ErrClosed)
+	}
+	if err := w.Rotate(); !errors.Is(err, ErrClosed) {
+		t.Errorf("got error %v, want %v", err, ErrClosed)
+	}
+	if err := w.Close(); err != nil {
+		t.Errorf("got error %v on second close", err)
+	}
+}
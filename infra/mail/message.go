@@ -0,0 +1,269 @@
+package mail
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Attachment is a message attachment
+type Attachment struct {
+	// ContentType is the attachment MIME type
+	ContentType string
+
+	// Data is the attachment content
+	Data []byte
+
+	// Name is the attachment file name
+	Name string
+}
+
+// Message is an email message
+type Message struct {
+	// Attachments are the message attachments
+	Attachments []Attachment
+
+	// Bcc are the blind carbon copy recipients
+	Bcc []string
+
+	// Cc are the carbon copy recipients
+	Cc []string
+
+	// From is the sender address
+	From string
+
+	// HTML is the HTML body
+	HTML string
+
+	// Subject is the message subject
+	Subject string
+
+	// Text is the plain text body
+	Text string
+
+	// To are the recipients
+	To []string
+}
+
+// NewMessage creates a new Message
+func NewMessage(from, subject string, to ...string) *Message {
+	return &Message{
+		From:    from,
+		Subject: subject,
+		To:      to,
+	}
+}
+
+// Attach adds an attachment to the message
+func (m *Message) Attach(name, contentType string, data []byte) *Message {
+	m.Attachments = append(m.Attachments, Attachment{
+		ContentType: contentType,
+		Data:        data,
+		Name:        name,
+	})
+	return m
+}
+
+// SetHTML sets the HTML body
+func (m *Message) SetHTML(html string) *Message {
+	m.HTML = html
+	return m
+}
+
+// SetText sets the plain text body
+func (m *Message) SetText(text string) *Message {
+	m.Text = text
+	return m
+}
+
+// Recipients returns all recipient addresses (To, Cc and Bcc)
+func (m *Message) Recipients() []string {
+	r := make([]string, 0, len(m.To)+len(m.Cc)+len(m.Bcc))
+	r = append(r, m.To...)
+	r = append(r, m.Cc...)
+	return append(r, m.Bcc...)
+}
+
+// Validate checks the message has a sender, recipients, valid addresses and a body
+func (m *Message) Validate() error {
+	if m.From == "" {
+		return errors.New("mail: missing sender")
+	}
+	if len(m.Recipients()) == 0 {
+		return errors.New("mail: missing recipients")
+	}
+	for _, a := range append([]string{m.From}, m.Recipients()...) {
+		if _, err := mail.ParseAddress(a); err != nil {
+			return fmt.Errorf("mail: invalid address %q: %w", a, err)
+		}
+		if strings.ContainsAny(a, "\r\n") {
+			return fmt.Errorf("mail: invalid address %q", a)
+		}
+	}
+	if strings.ContainsAny(m.Subject, "\r\n") {
+		return errors.New("mail: invalid subject")
+	}
+	if m.Text == "" && m.HTML == "" {
+		return errors.New("mail: missing body")
+	}
+	return nil
+}
+
+// Bytes returns the message encoded as RFC 5322 with MIME parts, Bcc recipients are not
+// included in the headers
+func (m *Message) Bytes() ([]byte, error) {
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+
+	buf := &bytes.Buffer{}
+	h := textproto.MIMEHeader{}
+	h.Set("From", m.From)
+	h.Set("To", strings.Join(m.To, ", "))
+	if len(m.Cc) > 0 {
+		h.Set("Cc", strings.Join(m.Cc, ", "))
+	}
+	h.Set("Subject", mime.QEncoding.Encode("utf-8", m.Subject))
+	h.Set("Date", time.Now().Format(time.RFC1123Z))
+	h.Set("MIME-Version", "1.0")
+
+	bh, body, err := m.body()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(m.Attachments) == 0 {
+		for k, v := range bh {
+			h[k] = v
+		}
+		writeHeader(buf, h)
+		buf.Write(body)
+		return buf.Bytes(), nil
+	}
+
+	mw := multipart.NewWriter(buf)
+	h.Set("Content-Type", "multipart/mixed; boundary="+mw.Boundary())
+	writeHeader(buf, h)
+
+	part, err := mw.CreatePart(bh)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := part.Write(body); err != nil {
+		return nil, err
+	}
+
+	for _, a := range m.Attachments {
+		ah := textproto.MIMEHeader{}
+		ct := a.ContentType
+		if ct == "" {
+			ct = "application/octet-stream"
+		}
+		ah.Set("Content-Type", ct)
+		ah.Set("Content-Transfer-Encoding", "base64")
+		ah.Set(
+			"Content-Disposition",
+			mime.FormatMediaType("attachment", map[string]string{"filename": a.Name}),
+		)
+		part, err := mw.CreatePart(ah)
+		if err != nil {
+			return nil, err
+		}
+		if err := writeBase64(part, a.Data); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// body returns the content headers and encoded text and/or HTML body, when both bodies are
+// set a multipart/alternative body is returned
+func (m *Message) body() (textproto.MIMEHeader, []byte, error) {
+	h := textproto.MIMEHeader{}
+	buf := &bytes.Buffer{}
+
+	if m.Text == "" || m.HTML == "" {
+		ct, body := "text/plain; charset=utf-8", m.Text
+		if m.HTML != "" {
+			ct, body = "text/html; charset=utf-8", m.HTML
+		}
+		h.Set("Content-Type", ct)
+		h.Set("Content-Transfer-Encoding", "quoted-printable")
+		if err := writeQuotedPrintable(buf, body); err != nil {
+			return nil, nil, err
+		}
+		return h, buf.Bytes(), nil
+	}
+
+	mw := multipart.NewWriter(buf)
+	h.Set("Content-Type", "multipart/alternative; boundary="+mw.Boundary())
+	for _, p := range []struct{ ct, body string }{
+		{"text/plain; charset=utf-8", m.Text},
+		{"text/html; charset=utf-8", m.HTML},
+	} {
+		ph := textproto.MIMEHeader{}
+		ph.Set("Content-Type", p.ct)
+		ph.Set("Content-Transfer-Encoding", "quoted-printable")
+		part, err := mw.CreatePart(ph)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := writeQuotedPrintable(part, p.body); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, nil, err
+	}
+	return h, buf.Bytes(), nil
+}
+
+// writeHeader writes MIME headers sorted by key followed by a blank line
+func writeHeader(w io.Writer, h textproto.MIMEHeader) {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, s := range h[k] {
+			fmt.Fprintf(w, "%s: %s\r\n", k, s)
+		}
+	}
+	fmt.Fprint(w, "\r\n")
+}
+
+// writeBase64 writes base64 encoded data wrapped at 76 chars per line
+func writeBase64(w io.Writer, data []byte) error {
+	enc := base64.StdEncoding.EncodeToString(data)
+	for len(enc) > 76 {
+		if _, err := io.WriteString(w, enc[:76]+"\r\n"); err != nil {
+			return err
+		}
+		enc = enc[76:]
+	}
+	_, err := io.WriteString(w, enc+"\r\n")
+	return err
+}
+
+// writeQuotedPrintable writes quoted-printable encoded text
+func writeQuotedPrintable(w io.Writer, s string) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write([]byte(s)); err != nil {
+		return err
+	}
+	return qp.Close()
+}
@@ -0,0 +1,330 @@
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/shayanderson/go-project/internal/work"
+)
+
+// ErrClosed is returned when sending on a closed AsyncSender
+var ErrClosed = errors.New("mail: sender closed")
+
+// ErrQueueFull is returned when the AsyncSender queue is full
+var ErrQueueFull = errors.New("mail: queue full")
+
+// Sender sends email messages
+type Sender interface {
+	Send(ctx context.Context, m *Message) error
+}
+
+// TLSMode is the SMTP connection security mode
+type TLSMode int
+
+const (
+	// TLSStartTLS upgrades a plain connection with STARTTLS, the connection fails if the
+	// server does not support it
+	TLSStartTLS TLSMode = iota
+	// TLSImplicit connects with TLS from the start (usually port 465)
+	TLSImplicit
+	// TLSNone sends over a plain connection, only use for local development servers
+	TLSNone
+)
+
+// SMTPSender sends messages using an SMTP server
+type SMTPSender struct {
+	// Host is the SMTP server host
+	Host string
+
+	// Password is the auth password, auth is skipped when Username is empty
+	Password string
+
+	// Port is the SMTP server port
+	Port int
+
+	// TLS is the connection security mode
+	TLS TLSMode
+
+	// Timeout is the timeout for the whole send, defaults to 30 seconds
+	Timeout time.Duration
+
+	// Username is the auth username
+	Username string
+}
+
+// NewSMTPSender creates a new SMTPSender using STARTTLS
+func NewSMTPSender(host string, port int, username, password string) *SMTPSender {
+	return &SMTPSender{
+		Host:     host,
+		Password: password,
+		Port:     port,
+		Username: username,
+	}
+}
+
+// Send implements the Sender interface
+func (s *SMTPSender) Send(ctx context.Context, m *Message) error {
+	data, err := m.Bytes()
+	if err != nil {
+		return err
+	}
+
+	timeout := s.Timeout
+	if timeout == 0 {
+		timeout = 30 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	conn, err := s.dial(ctx)
+	if err != nil {
+		return fmt.Errorf("mail: dial: %w", err)
+	}
+	if d, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(d)
+	}
+
+	c, err := smtp.NewClient(conn, s.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("mail: smtp client: %w", err)
+	}
+	defer c.Close()
+
+	if s.TLS == TLSStartTLS {
+		if err := c.StartTLS(&tls.Config{ServerName: s.Host, MinVersion: tls.VersionTLS12}); err != nil {
+			return fmt.Errorf("mail: starttls: %w", err)
+		}
+	}
+	if s.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", s.Username, s.Password, s.Host)); err != nil {
+			return fmt.Errorf("mail: auth: %w", err)
+		}
+	}
+
+	if err := c.Mail(address(m.From)); err != nil {
+		return fmt.Errorf("mail: from: %w", err)
+	}
+	for _, r := range m.Recipients() {
+		if err := c.Rcpt(address(r)); err != nil {
+			return fmt.Errorf("mail: rcpt %q: %w", r, err)
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("mail: data: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("mail: write: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("mail: write: %w", err)
+	}
+	return c.Quit()
+}
+
+// dial connects to the SMTP server
+func (s *SMTPSender) dial(ctx context.Context) (net.Conn, error) {
+	addr := net.JoinHostPort(s.Host, strconv.Itoa(s.Port))
+	if s.TLS == TLSImplicit {
+		d := &tls.Dialer{
+			Config: &tls.Config{ServerName: s.Host, MinVersion: tls.VersionTLS12},
+		}
+		return d.DialContext(ctx, "tcp", addr)
+	}
+	d := &net.Dialer{}
+	return d.DialContext(ctx, "tcp", addr)
+}
+
+// address returns the bare address for an SMTP envelope, for example "Name <a@b.c>"
+// returns "a@b.c"
+func address(addr string) string {
+	a, err := mail.ParseAddress(addr)
+	if err != nil {
+		return addr
+	}
+	return a.Address
+}
+
+// AsyncSender sends messages in the background using a bounded queue, failed sends are
+// retried with exponential backoff
+type AsyncSender struct {
+	backoff time.Duration
+	cancel  context.CancelFunc
+	closed  bool
+	mu      sync.RWMutex
+	queue   chan *Message
+	retries int
+	sender  Sender
+	wg      sync.WaitGroup
+}
+
+// NewAsyncSender creates a new AsyncSender with the number of workers and queue size, a
+// message is attempted up to retries+1 times
+func NewAsyncSender(sender Sender, workers, size, retries int) *AsyncSender {
+	ctx, cancel := context.WithCancel(context.Background())
+	a := &AsyncSender{
+		backoff: time.Second,
+		cancel:  cancel,
+		queue:   make(chan *Message, size),
+		retries: retries,
+		sender:  sender,
+	}
+	for range workers {
+		a.wg.Add(1)
+		work.Go(ctx, func(ctx context.Context) {
+			a.work(ctx)
+		})
+	}
+	return a
+}
+
+// Close stops accepting messages and waits until queued messages are sent or the context
+// is done, when the context is done the workers stop, retries are cancelled and the messages
+// still queued are dropped, so no message is sent after Close returns except for sends
+// already in progress
+func (a *AsyncSender) Close(ctx context.Context) error {
+	a.mu.Lock()
+	if !a.closed {
+		a.closed = true
+		close(a.queue)
+	}
+	a.mu.Unlock()
+
+	done := make(chan struct{})
+	work.Go(ctx, func(context.Context) {
+		a.wg.Wait()
+		close(done)
+	})
+	select {
+	case <-done:
+		a.cancel()
+		return nil
+	case <-ctx.Done():
+		a.cancel()
+		return ctx.Err()
+	}
+}
+
+// Send implements the Sender interface, the message is validated and queued, it does not
+// block when the queue is full
+func (a *AsyncSender) Send(ctx context.Context, m *Message) error {
+	if err := m.Validate(); err != nil {
+		return err
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.closed {
+		return ErrClosed
+	}
+	select {
+	case a.queue <- m:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// work sends queued messages until the queue is closed, messages are dropped once ctx is done
+func (a *AsyncSender) work(ctx context.Context) {
+	defer a.wg.Done()
+	for m := range a.queue {
+		if ctx.Err() != nil {
+			slog.Error("mail send dropped, sender closed", "to", m.To, "subject", m.Subject)
+			continue
+		}
+
+		backoff := a.backoff
+		for i := 0; ; i++ {
+			err := work.Run(ctx, func(ctx context.Context) error {
+				return a.sender.Send(ctx, m)
+			})
+			if err == nil {
+				break
+			}
+			if i >= a.retries || ctx.Err() != nil {
+				slog.Error("mail send failed", "to", m.To, "subject", m.Subject, "err", err)
+				break
+			}
+			slog.Warn("mail send failed, retrying", "attempt", i+1, "err", err)
+			if !sleep(ctx, backoff) {
+				slog.Error("mail send dropped, sender closed", "to", m.To, "subject", m.Subject)
+				break
+			}
+			backoff *= 2
+		}
+	}
+}
+
+// sleep waits for the duration, returns false when ctx is done first
+func sleep(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// MockSender is a Sender that records messages, for use in tests
+type MockSender struct {
+	// Err is returned by Send when set, no message is recorded
+	Err error
+
+	mu   sync.Mutex
+	sent []*Message
+}
+
+// Reset clears the recorded messages
+func (s *MockSender) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sent = nil
+}
+
+// Send implements the Sender interface
+func (s *MockSender) Send(ctx context.Context, m *Message) error {
+	if s.Err != nil {
+		return s.Err
+	}
+	if err := m.Validate(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sent = append(s.sent, m)
+	return nil
+}
+
+// Sent returns the recorded messages
+func (s *MockSender) Sent() []*Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*Message(nil), s.sent...)
+}
+
+// SentTo returns the recorded messages with the address as a recipient
+func (s *MockSender) SentTo(addr string) []*Message {
+	var r []*Message
+	for _, m := range s.Sent() {
+		for _, a := range m.Recipients() {
+			if a == addr {
+				r = append(r, m)
+				break
+			}
+		}
+	}
+	return r
+}
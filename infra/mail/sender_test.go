@@ -0,0 +1,79 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// failSender is a Sender that always fails and counts the attempts
+type failSender struct {
+	attempts atomic.Int32
+	started  chan struct{}
+}
+
+// Send implements the Sender interface
+func (s *failSender) Send(ctx context.Context, m *Message) error {
+	if s.attempts.Add(1) == 1 {
+		close(s.started)
+	}
+	return errors.New("send failed")
+}
+
+// message returns a valid message
+func message() *Message {
+	m := NewMessage("a@example.com", "subject", "b@example.com")
+	m.Text = "text"
+	return m
+}
+
+func TestAsyncSenderClose(t *testing.T) {
+	tests := []struct {
+		name    string
+		backoff time.Duration
+		queued  int
+		want    error
+		sent    int32
+	}{
+		{"retries done", time.Millisecond, 1, nil, 3},
+		{"backoff cancelled", time.Hour, 3, context.DeadlineExceeded, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &failSender{started: make(chan struct{})}
+			a := NewAsyncSender(s, 1, 10, 2)
+			a.backoff = tt.backoff
+			for range tt.queued {
+				if err := a.Send(context.Background(), message()); err != nil {
+					t.Fatal(err)
+				}
+			}
+			<-s.started
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			if tt.want != nil {
+				ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+			}
+			defer cancel()
+			if err := a.Close(ctx); !errors.Is(err, tt.want) {
+				t.Fatalf("got error %v, want %v", err, tt.want)
+			}
+
+			// the workers stop after Close returns, so a second Close returns once they exit
+			ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			if err := a.Close(ctx); err != nil {
+				t.Fatalf("workers still running after Close: %v", err)
+			}
+			if got := s.attempts.Load(); got != tt.sent {
+				t.Errorf("got %d attempts, want %d", got, tt.sent)
+			}
+			if err := a.Send(context.Background(), message()); !errors.Is(err, ErrClosed) {
+				t.Errorf("got error %v, want %v", err, ErrClosed)
+			}
+		})
+	}
+}
@@ -0,0 +1,217 @@
+package netx
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shayanderson/go-project/internal/work"
+)
+
+// errLookup is the error of a lookup that panicked
+var errLookup = errors.New("netx: lookup failed")
+
+// ResolverOptions are the resolver options
+type ResolverOptions struct {
+	// MaxEntries is the max number of cached hosts, expired entries are removed first when
+	// the cache is full, then the entries closest to expiry, default 1024
+	MaxEntries int
+
+	// NegativeTTL is how long failed lookups are cached, default 5s
+	NegativeTTL time.Duration
+
+	// Pins are static host addresses that bypass DNS, for example to route a host to a
+	// local test server
+	Pins map[string][]string
+
+	// Resolver is the underlying resolver, default net.DefaultResolver
+	Resolver *net.Resolver
+
+	// TTL is how long successful lookups are cached, default 30s, the stdlib resolver does not
+	// expose record TTLs so set it at or below the TTL of the resolved records
+	TTL time.Duration
+}
+
+// entry is a cached lookup
+type entry struct {
+	addrs   []string
+	err     error
+	expires time.Time
+}
+
+// call is an in-flight lookup shared by concurrent callers
+type call struct {
+	done chan struct{}
+	e    entry
+}
+
+// Resolver is a caching DNS resolver with static host pinning, concurrent lookups of the
+// same host share one DNS query
+type Resolver struct {
+	cache    map[string]entry
+	inflight map[string]*call
+	mu       sync.Mutex
+	opts     ResolverOptions
+	pins     map[string][]string
+}
+
+// NewResolver creates a new Resolver
+func NewResolver(opts ResolverOptions) *Resolver {
+	if opts.MaxEntries <= 0 {
+		opts.MaxEntries = 1024
+	}
+	if opts.NegativeTTL <= 0 {
+		opts.NegativeTTL = 5 * time.Second
+	}
+	if opts.Resolver == nil {
+		opts.Resolver = net.DefaultResolver
+	}
+	if opts.TTL <= 0 {
+		opts.TTL = 30 * time.Second
+	}
+
+	r := &Resolver{
+		cache:    map[string]entry{},
+		inflight: map[string]*call{},
+		opts:     opts,
+		pins:     map[string][]string{},
+	}
+	for host, addrs := range opts.Pins {
+		r.Pin(host, addrs...)
+	}
+	return r
+}
+
+// Pin pins a host to static addresses, no addresses removes the pin
+func (r *Resolver) Pin(host string, addrs ...string) {
+	host = strings.ToLower(host)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(addrs) == 0 {
+		delete(r.pins, host)
+		return
+	}
+	r.pins[host] = append([]string(nil), addrs...)
+}
+
+// LookupHost returns the addresses of a host, pinned hosts return their pinned addresses and
+// IP addresses are returned as is
+func (r *Resolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	if net.ParseIP(host) != nil {
+		return []string{host}, nil
+	}
+	host = strings.ToLower(host)
+
+	r.mu.Lock()
+	if addrs, ok := r.pins[host]; ok {
+		r.mu.Unlock()
+		return addrs, nil
+	}
+	if e, ok := r.cache[host]; ok && time.Now().Before(e.expires) {
+		r.mu.Unlock()
+		return e.addrs, e.err
+	}
+	c, ok := r.inflight[host]
+	if !ok {
+		c = &call{done: make(chan struct{})}
+		r.inflight[host] = c
+		// the lookup is shared, so it must not be cancelled by the first caller
+		work.Go(context.WithoutCancel(ctx), func(ctx context.Context) {
+			r.lookup(ctx, host, c)
+		})
+	}
+	r.mu.Unlock()
+
+	select {
+	case <-c.done:
+		return c.e.addrs, c.e.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// lookup resolves a host and caches the result, waiting callers are released even when the
+// lookup panics
+func (r *Resolver) lookup(ctx context.Context, host string, c *call) {
+	var addrs []string
+	err := errLookup
+	defer func() {
+		ttl := r.opts.TTL
+		if err != nil {
+			ttl = r.opts.NegativeTTL
+		}
+		c.e = entry{addrs: addrs, err: err, expires: time.Now().Add(ttl)}
+
+		r.mu.Lock()
+		r.store(host, c.e)
+		delete(r.inflight, host)
+		r.mu.Unlock()
+		close(c.done)
+	}()
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	addrs, err = r.opts.Resolver.LookupHost(ctx, host)
+}
+
+// store caches an entry, expired entries are removed when the cache is full, then the entry
+// closest to expiry, r.mu must be held
+func (r *Resolver) store(host string, e entry) {
+	if _, ok := r.cache[host]; !ok && len(r.cache) >= r.opts.MaxEntries {
+		now := time.Now()
+		for h, ce := range r.cache {
+			if !now.Before(ce.expires) {
+				delete(r.cache, h)
+			}
+		}
+		if len(r.cache) >= r.opts.MaxEntries {
+			var oldest string
+			for h, ce := range r.cache {
+				if oldest == "" || ce.expires.Before(r.cache[oldest].expires) {
+					oldest = h
+				}
+			}
+			delete(r.cache, oldest)
+		}
+	}
+	r.cache[host] = e
+}
+
+// DialContext resolves the address host with the resolver and dials the addresses in order
+// until a connection succeeds, it can be used as the DialContext of an http.Transport
+func (r *Resolver) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	addrs, err := r.LookupHost(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	d := net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	var errs []error
+	for _, a := range addrs {
+		conn, err := d.DialContext(ctx, network, net.JoinHostPort(a, port))
+		if err == nil {
+			return conn, nil
+		}
+		errs = append(errs, err)
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, errors.Join(errs...)
+}
+
+// Transport returns a clone of http.DefaultTransport that dials with the resolver, TLS
+// server names still use the request host
+func (r *Resolver) Transport() *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.DialContext = r.DialContext
+	return t
+}
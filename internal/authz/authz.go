@@ -0,0 +1,166 @@
+package authz
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/shayanderson/go-project/server"
+)
+
+// Permission is a permission in the form "resource:action", for example "items:write", the
+// action "*" grants all actions on the resource and "*" grants everything
+type Permission string
+
+// Role is a named set of permissions
+type Role struct {
+	// Name is the role name
+	Name string
+
+	// Permissions are the permissions granted by the role
+	Permissions []Permission
+}
+
+// PolicyStore stores role permissions
+type PolicyStore interface {
+	// Permissions returns the permissions of a role, an unknown role has no permissions
+	Permissions(ctx context.Context, role string) ([]Permission, error)
+}
+
+// MemoryPolicyStore is an in-memory PolicyStore
+type MemoryPolicyStore struct {
+	mu    sync.RWMutex
+	roles map[string][]Permission
+}
+
+// NewMemoryPolicyStore creates a new MemoryPolicyStore with roles
+func NewMemoryPolicyStore(roles ...Role) *MemoryPolicyStore {
+	s := &MemoryPolicyStore{
+		roles: map[string][]Permission{},
+	}
+	for _, r := range roles {
+		s.Set(r)
+	}
+	return s
+}
+
+// Delete deletes a role
+func (s *MemoryPolicyStore) Delete(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.roles, name)
+}
+
+// Permissions implements the PolicyStore interface
+func (s *MemoryPolicyStore) Permissions(ctx context.Context, role string) ([]Permission, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.roles[role], nil
+}
+
+// Set adds or replaces a role
+func (s *MemoryPolicyStore) Set(r Role) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.roles[r.Name] = append([]Permission(nil), r.Permissions...)
+}
+
+// Authorizer checks role permissions using a PolicyStore
+type Authorizer struct {
+	store PolicyStore
+}
+
+// New creates a new Authorizer
+func New(store PolicyStore) *Authorizer {
+	return &Authorizer{
+		store: store,
+	}
+}
+
+// Can returns true if any of the roles grants the permission
+func (a *Authorizer) Can(ctx context.Context, roles []string, perm Permission) (bool, error) {
+	for _, role := range roles {
+		perms, err := a.store.Permissions(ctx, role)
+		if err != nil {
+			return false, err
+		}
+		for _, p := range perms {
+			if p.Grants(perm) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// RequirePermission returns middleware that allows requests whose context roles grant the
+// permission, requests without roles receive a 401 response and requests without the
+// permission receive a 403 response
+func (a *Authorizer) RequirePermission(perm Permission) server.Middleware {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			roles := Roles(r.Context())
+			if len(roles) == 0 {
+				_ = server.WriteJSON(
+					w,
+					http.StatusUnauthorized,
+					map[string]string{"error": "unauthorized"},
+				)
+				return
+			}
+
+			ok, err := a.Can(r.Context(), roles, perm)
+			if err != nil {
+				slog.Error("authz policy lookup failed", "err", err)
+				_ = server.WriteJSON(
+					w,
+					http.StatusInternalServerError,
+					map[string]string{"error": "internal server error"},
+				)
+				return
+			}
+			if !ok {
+				_ = server.WriteJSON(
+					w,
+					http.StatusForbidden,
+					map[string]string{"error": "forbidden"},
+				)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// Grants returns true if the permission grants the other permission
+func (p Permission) Grants(other Permission) bool {
+	if p == "*" || p == other {
+		return true
+	}
+	res, act, ok := strings.Cut(string(p), ":")
+	if !ok || act != "*" {
+		return false
+	}
+	ores, _, _ := strings.Cut(string(other), ":")
+	return res == ores
+}
+
+// rolesKey is the context key for the roles
+type rolesKey struct{}
+
+// Roles returns the roles from the context
+func Roles(ctx context.Context) []string {
+	roles, _ := ctx.Value(rolesKey{}).([]string)
+	return roles
+}
+
+// WithRoles returns a copy of the context with the roles, authentication middleware sets the
+// roles from the verified claims before RequirePermission runs
+func WithRoles(ctx context.Context, roles ...string) context.Context {
+	return context.WithValue(ctx, rolesKey{}, roles)
+}
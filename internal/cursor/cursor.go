@@ -0,0 +1,105 @@
+package cursor
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"maps"
+	"strings"
+	"time"
+
+	"github.com/shayanderson/go-project/infra/crypto"
+)
+
+// ErrExpired is returned when a cursor is expired
+var ErrExpired = errors.New("cursor: expired")
+
+// ErrFilters is returned when a cursor was issued for different filters
+var ErrFilters = errors.New("cursor: filters changed")
+
+// ErrInvalid is returned when a cursor is malformed or was tampered with
+var ErrInvalid = errors.New("cursor: invalid")
+
+// Cursor is a pagination position with the filters it was issued for
+type Cursor struct {
+	// After is the sort key of the last item of the previous page
+	After string `json:"a,omitempty"`
+
+	// Filters are the list filters the cursor is valid for
+	Filters map[string]string `json:"f,omitempty"`
+}
+
+// payload is the signed cursor payload
+type payload struct {
+	Cursor
+	Expires int64 `json:"e,omitempty"`
+}
+
+// Codec encodes and decodes signed opaque cursors
+type Codec struct {
+	key []byte
+	ttl time.Duration
+}
+
+// New creates a new Codec that signs cursors with the key, cursors expire after ttl, 0 is no
+// expiry
+func New(key []byte, ttl time.Duration) *Codec {
+	return &Codec{
+		key: key,
+		ttl: ttl,
+	}
+}
+
+// Decode decodes and verifies a cursor string, the filters must match the filters the cursor
+// was issued for, an empty string returns a zero Cursor for the first page
+func (c *Codec) Decode(s string, filters map[string]string) (Cursor, error) {
+	if s == "" {
+		return Cursor{Filters: filters}, nil
+	}
+	data, sig, ok := strings.Cut(s, ".")
+	if !ok || !crypto.VerifyString(c.key, []byte(data), sig) {
+		return Cursor{}, ErrInvalid
+	}
+	b, err := base64.RawURLEncoding.DecodeString(data)
+	if err != nil {
+		return Cursor{}, ErrInvalid
+	}
+	var p payload
+	if err := json.Unmarshal(b, &p); err != nil {
+		return Cursor{}, ErrInvalid
+	}
+	if p.Expires > 0 && time.Now().Unix() > p.Expires {
+		return Cursor{}, ErrExpired
+	}
+	if !equalFilters(p.Filters, filters) {
+		return Cursor{}, ErrFilters
+	}
+	return p.Cursor, nil
+}
+
+// Encode encodes a cursor as a URL safe signed string
+func (c *Codec) Encode(cur Cursor) (string, error) {
+	p := payload{Cursor: cur}
+	if c.ttl > 0 {
+		p.Expires = time.Now().Add(c.ttl).Unix()
+	}
+	b, err := json.Marshal(p)
+	if err != nil {
+		return "", err
+	}
+	data := base64.RawURLEncoding.EncodeToString(b)
+	return data + "." + crypto.SignString(c.key, []byte(data)), nil
+}
+
+// Next returns the encoded cursor for the page after the sort key with the filters
+func (c *Codec) Next(after string, filters map[string]string) (string, error) {
+	return c.Encode(Cursor{After: after, Filters: filters})
+}
+
+// equalFilters returns true if the filters are equal, nil and empty are equal
+func equalFilters(a, b map[string]string) bool {
+	if len(a) == 0 && len(b) == 0 {
+		return true
+	}
+	return maps.Equal(a, b)
+}
@@ -0,0 +1,141 @@
+package errs
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Kind is an error kind
+type Kind int
+
+const (
+	// Internal is an unexpected error, its message is never exposed to clients
+	Internal Kind = iota
+	// Invalid is an invalid input error
+	Invalid
+	// NotFound is a missing resource error
+	NotFound
+	// Conflict is a resource state conflict error, for example a duplicate
+	Conflict
+	// Unauthorized is a missing or invalid authentication error
+	Unauthorized
+	// Forbidden is a permission error
+	Forbidden
+	// Unsupported is an unsupported media type error
+	Unsupported
+)
+
+// String implements the fmt.Stringer interface
+func (k Kind) String() string {
+	switch k {
+	case Internal:
+		return "internal"
+	case Invalid:
+		return "invalid"
+	case NotFound:
+		return "not_found"
+	case Conflict:
+		return "conflict"
+	case Unauthorized:
+		return "unauthorized"
+	case Forbidden:
+		return "forbidden"
+	case Unsupported:
+		return "unsupported"
+	}
+	return fmt.Sprintf("kind(%d)", int(k))
+}
+
+// Error is a typed error
+type Error struct {
+	// Code is a machine readable error code, for example "item_not_found"
+	Code string
+
+	// Err is the wrapped cause
+	Err error
+
+	// Kind is the error kind
+	Kind Kind
+
+	// Message is a message safe to return to clients
+	Message string
+
+	// Meta is additional error data returned to clients, for example invalid field names
+	Meta map[string]any
+}
+
+// New creates a new Error
+func New(kind Kind, code, message string) *Error {
+	return &Error{
+		Code:    code,
+		Kind:    kind,
+		Message: message,
+	}
+}
+
+// Wrap creates a new Error wrapping the cause
+func Wrap(err error, kind Kind, code, message string) *Error {
+	return &Error{
+		Code:    code,
+		Err:     err,
+		Kind:    kind,
+		Message: message,
+	}
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	s := e.Kind.String()
+	if e.Code != "" {
+		s += " " + e.Code
+	}
+	if e.Message != "" {
+		s += ": " + e.Message
+	}
+	if e.Err != nil {
+		s += ": " + e.Err.Error()
+	}
+	return s
+}
+
+// Unwrap returns the wrapped cause
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// With sets a metadata value and returns the error
+func (e *Error) With(key string, v any) *Error {
+	if e.Meta == nil {
+		e.Meta = map[string]any{}
+	}
+	e.Meta[key] = v
+	return e
+}
+
+// As returns the first Error in the error chain
+func As(err error) (*Error, bool) {
+	var e *Error
+	if errors.As(err, &e) {
+		return e, true
+	}
+	return nil, false
+}
+
+// Is returns true if the error chain contains an Error of the kind
+func Is(err error, kind Kind) bool {
+	e, ok := As(err)
+	return ok && e.Kind == kind
+}
+
+// KindOf returns the kind of the first Error in the error chain, Internal if there is none
+func KindOf(err error) Kind {
+	if e, ok := As(err); ok {
+		return e.Kind
+	}
+	return Internal
+}
+
+// E creates a new Error of the kind with a formatted message
+func E(kind Kind, code, format string, args ...any) *Error {
+	return New(kind, code, fmt.Sprintf(format, args...))
+}
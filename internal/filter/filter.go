@@ -0,0 +1,417 @@
+package filter
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ErrSyntax is returned when a filter expression is invalid
+var ErrSyntax = errors.New("filter: syntax error")
+
+// ErrField is returned when a filter expression uses a field that is not allowed
+var ErrField = errors.New("filter: field not allowed")
+
+// maxDepth is the max nesting depth of an expression, it guards against deeply nested
+// untrusted input
+const maxDepth = 32
+
+// Op is a comparison operator
+type Op string
+
+// comparison operators
+const (
+	Eq       Op = "="
+	NotEq    Op = "!="
+	Gt       Op = ">"
+	Gte      Op = ">="
+	Lt       Op = "<"
+	Lte      Op = "<="
+	Contains Op = "~"
+)
+
+// FieldFunc returns the value of a record field, returns false when the record has no field
+type FieldFunc func(field string) (any, bool)
+
+// Map returns a FieldFunc for a map record
+func Map(m map[string]any) FieldFunc {
+	return func(field string) (any, bool) {
+		v, ok := m[field]
+		return v, ok
+	}
+}
+
+// Expr is a parsed filter expression
+type Expr interface {
+	// Fields returns the fields used by the expression
+	Fields() []string
+
+	// Match evaluates the expression for a record, comparisons of missing fields and of
+	// values with different types are false
+	Match(get FieldFunc) bool
+
+	// String returns the expression in the filter syntax
+	String() string
+}
+
+// and is a logical AND expression
+type and struct {
+	left, right Expr
+}
+
+// Fields implements the Expr interface
+func (e and) Fields() []string {
+	return append(e.left.Fields(), e.right.Fields()...)
+}
+
+// Match implements the Expr interface
+func (e and) Match(get FieldFunc) bool {
+	return e.left.Match(get) && e.right.Match(get)
+}
+
+// String implements the Expr interface
+func (e and) String() string {
+	return "(" + e.left.String() + " AND " + e.right.String() + ")"
+}
+
+// or is a logical OR expression
+type or struct {
+	left, right Expr
+}
+
+// Fields implements the Expr interface
+func (e or) Fields() []string {
+	return append(e.left.Fields(), e.right.Fields()...)
+}
+
+// Match implements the Expr interface
+func (e or) Match(get FieldFunc) bool {
+	return e.left.Match(get) || e.right.Match(get)
+}
+
+// String implements the Expr interface
+func (e or) String() string {
+	return "(" + e.left.String() + " OR " + e.right.String() + ")"
+}
+
+// not is a logical NOT expression
+type not struct {
+	expr Expr
+}
+
+// Fields implements the Expr interface
+func (e not) Fields() []string {
+	return e.expr.Fields()
+}
+
+// Match implements the Expr interface
+func (e not) Match(get FieldFunc) bool {
+	return !e.expr.Match(get)
+}
+
+// String implements the Expr interface
+func (e not) String() string {
+	return "NOT " + e.expr.String()
+}
+
+// cmp is a comparison of a field with a value, the value is a string, float64, bool or nil
+type cmp struct {
+	field string
+	op    Op
+	value any
+}
+
+// Fields implements the Expr interface
+func (e cmp) Fields() []string {
+	return []string{e.field}
+}
+
+// Match implements the Expr interface
+func (e cmp) Match(get FieldFunc) bool {
+	v, ok := get(e.field)
+	if !ok {
+		return false
+	}
+	v = normalize(v)
+
+	if e.op == Contains {
+		s, ok := v.(string)
+		return ok && strings.Contains(strings.ToLower(s), strings.ToLower(e.value.(string)))
+	}
+
+	switch want := e.value.(type) {
+	case nil:
+		switch e.op {
+		case Eq:
+			return v == nil
+		case NotEq:
+			return v != nil
+		}
+		return false
+	case bool:
+		got, ok := v.(bool)
+		if !ok {
+			return false
+		}
+		switch e.op {
+		case Eq:
+			return got == want
+		case NotEq:
+			return got != want
+		}
+		return false
+	case float64:
+		got, ok := v.(float64)
+		return ok && compare(e.op, cmpFloat(got, want))
+	case string:
+		got, ok := v.(string)
+		return ok && compare(e.op, strings.Compare(got, want))
+	}
+	return false
+}
+
+// String implements the Expr interface
+func (e cmp) String() string {
+	switch v := e.value.(type) {
+	case nil:
+		return e.field + string(e.op) + "null"
+	case string:
+		return e.field + string(e.op) + strconv.Quote(v)
+	default:
+		return e.field + string(e.op) + fmt.Sprint(v)
+	}
+}
+
+// normalize converts record values to the filter value types, numbers to float64 and named
+// string and bool types to string and bool, pointers are dereferenced
+func normalize(v any) any {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		return rv.Float()
+	case reflect.String:
+		return rv.String()
+	case reflect.Bool:
+		return rv.Bool()
+	case reflect.Invalid:
+		return nil
+	}
+	return v
+}
+
+// cmpFloat compares two floats
+func cmpFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// compare checks if a comparison result satisfies the operator
+func compare(op Op, c int) bool {
+	switch op {
+	case Eq:
+		return c == 0
+	case NotEq:
+		return c != 0
+	case Gt:
+		return c > 0
+	case Gte:
+		return c >= 0
+	case Lt:
+		return c < 0
+	case Lte:
+		return c <= 0
+	}
+	return false
+}
+
+// Parse parses a filter expression like `name~"test" AND (id>10 OR active=true)`, fields are
+// compared with =, !=, >, >=, <, <= and ~ (case-insensitive contains) to quoted strings,
+// numbers, true, false or null, and combined with AND, OR, NOT and parentheses, when allowed
+// fields are given expressions using other fields return ErrField
+func Parse(s string, allowed ...string) (Expr, error) {
+	toks, err := lex(s)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{toks: toks}
+	e, err := p.or(0)
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().kind != tokEOF {
+		return nil, p.errorf("unexpected %q", p.peek().text)
+	}
+
+	if len(allowed) > 0 {
+		for _, f := range e.Fields() {
+			if !contains(allowed, f) {
+				return nil, fmt.Errorf("%w: %s", ErrField, f)
+			}
+		}
+	}
+	return e, nil
+}
+
+// contains checks if a list contains a string
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// parser is a recursive descent filter expression parser
+type parser struct {
+	pos  int
+	toks []token
+}
+
+// errorf returns a syntax error at the current token
+func (p *parser) errorf(format string, args ...any) error {
+	return syntaxError(p.peek().pos, fmt.Sprintf(format, args...))
+}
+
+// next returns the current token and advances
+func (p *parser) next() token {
+	t := p.toks[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+// peek returns the current token
+func (p *parser) peek() token {
+	return p.toks[p.pos]
+}
+
+// or parses: and ("OR" and)*
+func (p *parser) or(depth int) (Expr, error) {
+	left, err := p.and(depth)
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().keyword("OR") {
+		p.next()
+		right, err := p.and(depth)
+		if err != nil {
+			return nil, err
+		}
+		left = or{left: left, right: right}
+	}
+	return left, nil
+}
+
+// and parses: unary ("AND" unary)*
+func (p *parser) and(depth int) (Expr, error) {
+	left, err := p.unary(depth)
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().keyword("AND") {
+		p.next()
+		right, err := p.unary(depth)
+		if err != nil {
+			return nil, err
+		}
+		left = and{left: left, right: right}
+	}
+	return left, nil
+}
+
+// unary parses: "NOT" unary | "(" or ")" | comparison
+func (p *parser) unary(depth int) (Expr, error) {
+	if depth > maxDepth {
+		return nil, p.errorf("expression too deep")
+	}
+
+	t := p.peek()
+	switch {
+	case t.keyword("NOT"):
+		p.next()
+		e, err := p.unary(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return not{expr: e}, nil
+	case t.kind == tokLParen:
+		p.next()
+		e, err := p.or(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if p.next().kind != tokRParen {
+			return nil, p.errorf("missing )")
+		}
+		return e, nil
+	}
+	return p.comparison()
+}
+
+// comparison parses: field op value
+func (p *parser) comparison() (Expr, error) {
+	f := p.next()
+	if f.kind != tokIdent || f.keyword("AND") || f.keyword("OR") || f.keyword("NOT") {
+		return nil, p.errorf("expected field, got %q", f.text)
+	}
+	o := p.next()
+	if o.kind != tokOp {
+		return nil, p.errorf("expected operator after %s", f.text)
+	}
+
+	e := cmp{field: f.text, op: Op(o.text)}
+	v := p.next()
+	switch v.kind {
+	case tokString:
+		e.value = v.text
+	case tokNumber:
+		n, err := strconv.ParseFloat(v.text, 64)
+		if err != nil {
+			return nil, p.errorf("invalid number %q", v.text)
+		}
+		e.value = n
+	case tokIdent:
+		switch v.text {
+		case "true":
+			e.value = true
+		case "false":
+			e.value = false
+		case "null":
+			e.value = nil
+		default:
+			return nil, p.errorf("expected value, got %q", v.text)
+		}
+	default:
+		return nil, p.errorf("expected value after %s%s", f.text, o.text)
+	}
+
+	if e.op == Contains {
+		if _, ok := e.value.(string); !ok {
+			return nil, p.errorf("~ requires a string value")
+		}
+	}
+	if _, ok := e.value.(bool); (ok || e.value == nil) && e.op != Eq && e.op != NotEq {
+		return nil, p.errorf("%s only supports = and !=", v.text)
+	}
+	return e, nil
+}
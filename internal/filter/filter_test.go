@@ -0,0 +1,243 @@
+package filter
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"string", `name="a b"`, `name="a b"`},
+		{"number", `id>=10`, `id>=10`},
+		{"negative float", `price<-1.5`, `price<-1.5`},
+		{
+			"exponent",
+			`size>1e21 AND size<2E+21 AND rate>1e-3`,
+			`((size>1e+21 AND size<2e+21) AND rate>0.001)`,
+		},
+		{"bool", `active=true`, `active=true`},
+		{"null", `deleted!=null`, `deleted!=null`},
+		{"contains", `name~"x"`, `name~"x"`},
+		{"nested field", `owner.name="a"`, `owner.name="a"`},
+		{"and binds tighter than or", `a=1 OR b=2 AND c=3`, `(a=1 OR (b=2 AND c=3))`},
+		{"parentheses", `(a=1 OR b=2) AND c=3`, `((a=1 OR b=2) AND c=3)`},
+		{"not", `NOT a=1`, `NOT a=1`},
+		{"lowercase keywords", `a=1 and not b=2`, `(a=1 AND NOT b=2)`},
+		{"escaped string", `name="a\"b"`, `name="a\"b"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := Parse(tt.in)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := e.String(); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		allowed []string
+		want    error
+	}{
+		{"empty", ``, nil, ErrSyntax},
+		{"missing value", `a=`, nil, ErrSyntax},
+		{"missing operator", `a 1`, nil, ErrSyntax},
+		{"unterminated string", `a="x`, nil, ErrSyntax},
+		{"invalid operator", `a!1`, nil, ErrSyntax},
+		{"unexpected character", `a=1 & b=2`, nil, ErrSyntax},
+		{"missing paren", `(a=1`, nil, ErrSyntax},
+		{"trailing token", `a=1 b`, nil, ErrSyntax},
+		{"invalid number", `a=1.2.3`, nil, ErrSyntax},
+		{"invalid exponent", `a=1e+`, nil, ErrSyntax},
+		{"keyword as field", `AND=1`, nil, ErrSyntax},
+		{"contains number", `a~1`, nil, ErrSyntax},
+		{"ordered bool", `a>true`, nil, ErrSyntax},
+		{"ordered null", `a<null`, nil, ErrSyntax},
+		{
+			"too deep",
+			strings.Repeat("(", maxDepth+2) + "a=1" + strings.Repeat(")", maxDepth+2),
+			nil,
+			ErrSyntax,
+		},
+		{"field not allowed", `a=1 OR b=2`, []string{"a"}, ErrField},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(tt.in, tt.allowed...); !errors.Is(err, tt.want) {
+				t.Errorf("got error %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	type status string
+	n := 5
+	rec := Map(map[string]any{
+		"id":     int64(10),
+		"name":   "Hello World",
+		"active": true,
+		"price":  float32(1.5),
+		"status": status("open"),
+		"count":  &n,
+		"owner":  nil,
+	})
+
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{`id=10`, true},
+		{`id!=10`, false},
+		{`id>9 AND id<11`, true},
+		{`id>=10 AND id<=10`, true},
+		{`name~"world"`, true},
+		{`name~"mars"`, false},
+		{`name>"A"`, true},
+		{`active=true`, true},
+		{`active!=true`, false},
+		{`price=1.5`, true},
+		{`status="open"`, true},
+		{`count=5`, true},
+		{`owner=null`, true},
+		{`owner!=null`, false},
+		{`missing=null`, false},
+		{`missing!=1`, false},
+		{`id="10"`, false},
+		{`name=1`, false},
+		{`NOT id=10 OR active=true`, true},
+		{`NOT (id=10 OR active=false)`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			e, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := e.Match(rec); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSQL(t *testing.T) {
+	columns := map[string]string{"id": "id", "name": "user_name", "deleted": "deleted_at"}
+	dollar := func(n int) string { return "$" + strconv.Itoa(n) }
+
+	tests := []struct {
+		name        string
+		expr        string
+		placeholder func(int) string
+		want        string
+		args        []any
+	}{
+		{"compare", `id>1`, nil, "id > ?", []any{1.0}},
+		{"not equal", `id!=1`, nil, "id <> ?", []any{1.0}},
+		{
+			"and or",
+			`id=1 AND (name="a" OR name="b")`,
+			dollar,
+			"(id = $1 AND (user_name = $2 OR user_name = $3))",
+			[]any{1.0, "a", "b"},
+		},
+		{"not", `NOT id=1`, nil, "NOT id = ?", []any{1.0}},
+		{"is null", `deleted=null`, nil, "deleted_at IS NULL", nil},
+		{"is not null", `deleted!=null`, nil, "deleted_at IS NOT NULL", nil},
+		{
+			"contains escapes pattern",
+			`name~"50%_A"`,
+			nil,
+			`LOWER(user_name) LIKE ? ESCAPE '\'`,
+			[]any{`%50\%\_a%`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got, args, err := SQL(e, columns, tt.placeholder)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+			if !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("got args %#v, want %#v", args, tt.args)
+			}
+		})
+	}
+}
+
+func TestSQLUnknownField(t *testing.T) {
+	e, err := Parse(`a=1 AND b=2`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, _, err := SQL(e, map[string]string{"a": "a"}, nil); !errors.Is(err, ErrField) {
+		t.Errorf("got error %v, want %v", err, ErrField)
+	}
+}
+
+func FuzzParse(f *testing.F) {
+	for _, s := range []string{
+		`name="a b"`,
+		`id>=10 AND (active=true OR deleted=null)`,
+		`NOT price<-1.5e3`,
+		`size>1e21`,
+		`owner.name~"x\"y"`,
+		`a=1 or b!=2 and not c=3`,
+		`((a=1)`,
+		`a~1`,
+	} {
+		f.Add(s)
+	}
+	columns := map[string]string{}
+	rec := Map(map[string]any{"a": 1, "b": "x", "c": true, "d": nil})
+	f.Fuzz(func(t *testing.T, s string) {
+		e, err := Parse(s)
+		if err != nil {
+			if !errors.Is(err, ErrSyntax) {
+				t.Fatalf("got error %v, want %v", err, ErrSyntax)
+			}
+			return
+		}
+
+		// the string form must parse to the same expression
+		e2, err := Parse(e.String())
+		if err != nil {
+			t.Fatalf("%q from %q does not parse: %v", e.String(), s, err)
+		}
+		if e2.String() != e.String() {
+			t.Fatalf("got %q, want %q", e2.String(), e.String())
+		}
+
+		e.Match(rec)
+		for _, field := range e.Fields() {
+			columns[field] = field
+		}
+		if _, _, err := SQL(e, columns, nil); err != nil {
+			t.Fatalf("unexpected SQL error: %v", err)
+		}
+	})
+}
@@ -0,0 +1,109 @@
+package filter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// tokenKind is a token kind
+type tokenKind int
+
+// token kinds
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokLParen
+	tokNumber
+	tokOp
+	tokRParen
+	tokString
+)
+
+// token is a lexed token
+type token struct {
+	kind tokenKind
+	pos  int
+	text string
+}
+
+// keyword checks if the token is the keyword, keywords are case-insensitive
+func (t token) keyword(k string) bool {
+	return t.kind == tokIdent && strings.EqualFold(t.text, k)
+}
+
+// lex splits a filter expression into tokens
+func lex(s string) ([]token, error) {
+	var toks []token
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			toks = append(toks, token{kind: tokLParen, pos: i, text: "("})
+			i++
+		case c == ')':
+			toks = append(toks, token{kind: tokRParen, pos: i, text: ")"})
+			i++
+		case c == '"':
+			j := i + 1
+			for j < len(s) && s[j] != '"' {
+				if s[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(s) {
+				return nil, syntaxError(i, "unterminated string")
+			}
+			v, err := strconv.Unquote(s[i : j+1])
+			if err != nil {
+				return nil, syntaxError(i, "invalid string")
+			}
+			toks = append(toks, token{kind: tokString, pos: i, text: v})
+			i = j + 1
+		case strings.ContainsRune("=!<>~", rune(c)):
+			op := string(c)
+			if i+1 < len(s) && s[i+1] == '=' && c != '=' && c != '~' {
+				op += "="
+			}
+			if op == "!" {
+				return nil, syntaxError(i, "invalid operator !")
+			}
+			toks = append(toks, token{kind: tokOp, pos: i, text: op})
+			i += len(op)
+		case c == '-' || c == '.' || (c >= '0' && c <= '9'):
+			j := i + 1
+			for j < len(s) && (s[j] == '.' || s[j] == 'e' || s[j] == 'E' ||
+				(s[j] >= '0' && s[j] <= '9') ||
+				((s[j] == '+' || s[j] == '-') && (s[j-1] == 'e' || s[j-1] == 'E'))) {
+				j++
+			}
+			toks = append(toks, token{kind: tokNumber, pos: i, text: s[i:j]})
+			i = j
+		case isIdent(c, true):
+			j := i + 1
+			for j < len(s) && isIdent(s[j], false) {
+				j++
+			}
+			toks = append(toks, token{kind: tokIdent, pos: i, text: s[i:j]})
+			i = j
+		default:
+			return nil, syntaxError(i, "unexpected character "+strconv.QuoteRune(rune(c)))
+		}
+	}
+	return append(toks, token{kind: tokEOF, pos: len(s)}), nil
+}
+
+// isIdent checks if a character is valid in an identifier, dots are allowed after the first
+// character for nested fields like "owner.name"
+func isIdent(c byte, first bool) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') ||
+		(!first && (c == '.' || (c >= '0' && c <= '9')))
+}
+
+// syntaxError returns a syntax error at a position
+func syntaxError(pos int, msg string) error {
+	return fmt.Errorf("%w at %d: %s", ErrSyntax, pos, msg)
+}
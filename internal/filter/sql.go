@@ -0,0 +1,86 @@
+package filter
+
+import (
+	"fmt"
+	"strings"
+)
+
+// likeEscaper escapes LIKE pattern characters
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// SQL translates an expression to a SQL WHERE clause condition with placeholder args, fields
+// are mapped to columns by the columns map and fields without a column return ErrField, the
+// placeholder function returns the placeholder of the nth arg starting at 1, for example
+// "$1", nil uses "?", ~ is translated to a case-insensitive LIKE
+func SQL(
+	e Expr,
+	columns map[string]string,
+	placeholder func(n int) string,
+) (string, []any, error) {
+	if placeholder == nil {
+		placeholder = func(int) string { return "?" }
+	}
+	var args []any
+	var b strings.Builder
+	if err := writeSQL(&b, e, columns, placeholder, &args); err != nil {
+		return "", nil, err
+	}
+	return b.String(), args, nil
+}
+
+// writeSQL writes the SQL of an expression
+func writeSQL(
+	b *strings.Builder,
+	e Expr,
+	columns map[string]string,
+	placeholder func(n int) string,
+	args *[]any,
+) error {
+	switch e := e.(type) {
+	case and, or:
+		var left, right Expr
+		op := " AND "
+		if a, ok := e.(and); ok {
+			left, right = a.left, a.right
+		} else {
+			o := e.(or)
+			left, right, op = o.left, o.right, " OR "
+		}
+		b.WriteString("(")
+		if err := writeSQL(b, left, columns, placeholder, args); err != nil {
+			return err
+		}
+		b.WriteString(op)
+		if err := writeSQL(b, right, columns, placeholder, args); err != nil {
+			return err
+		}
+		b.WriteString(")")
+	case not:
+		b.WriteString("NOT ")
+		return writeSQL(b, e.expr, columns, placeholder, args)
+	case cmp:
+		col, ok := columns[e.field]
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrField, e.field)
+		}
+		switch {
+		case e.value == nil && e.op == Eq:
+			b.WriteString(col + " IS NULL")
+		case e.value == nil:
+			b.WriteString(col + " IS NOT NULL")
+		case e.op == Contains:
+			*args = append(*args, "%"+likeEscaper.Replace(strings.ToLower(e.value.(string)))+"%")
+			b.WriteString("LOWER(" + col + ") LIKE " + placeholder(len(*args)) + ` ESCAPE '\'`)
+		default:
+			op := string(e.op)
+			if e.op == NotEq {
+				op = "<>"
+			}
+			*args = append(*args, e.value)
+			b.WriteString(col + " " + op + " " + placeholder(len(*args)))
+		}
+	default:
+		return fmt.Errorf("filter: unsupported expression %T", e)
+	}
+	return nil
+}
@@ -0,0 +1,219 @@
+package i18n
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/shayanderson/go-project/server"
+)
+
+// Bundle is a set of message catalogs by language
+type Bundle struct {
+	catalogs map[string]map[string]string
+	fallback string
+}
+
+// Load loads message catalogs from the JSON files in a directory of the file system, for
+// example an embed.FS, files are named by language like "en.json" or "pt-BR.json" and
+// nested objects are flattened to dotted keys like "items.not_found", the fallback
+// language is used when a request language or message is not available
+func Load(fsys fs.FS, dir, fallback string) (*Bundle, error) {
+	files, err := fs.Glob(fsys, path.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	b := &Bundle{
+		catalogs: map[string]map[string]string{},
+		fallback: normalize(fallback),
+	}
+	for _, f := range files {
+		data, err := fs.ReadFile(fsys, f)
+		if err != nil {
+			return nil, err
+		}
+		var v map[string]any
+		if err := json.Unmarshal(data, &v); err != nil {
+			return nil, fmt.Errorf("i18n: invalid catalog %s: %w", f, err)
+		}
+		lang := normalize(strings.TrimSuffix(path.Base(f), ".json"))
+		msgs := map[string]string{}
+		if err := flatten(msgs, "", v); err != nil {
+			return nil, fmt.Errorf("i18n: invalid catalog %s: %w", f, err)
+		}
+		b.catalogs[lang] = msgs
+	}
+
+	if _, ok := b.catalogs[b.fallback]; !ok {
+		return nil, fmt.Errorf("i18n: missing fallback catalog %q", fallback)
+	}
+	return b, nil
+}
+
+// flatten flattens nested catalog objects into dotted keys
+func flatten(msgs map[string]string, prefix string, v map[string]any) error {
+	for k, val := range v {
+		if prefix != "" {
+			k = prefix + "." + k
+		}
+		switch val := val.(type) {
+		case string:
+			msgs[k] = val
+		case map[string]any:
+			if err := flatten(msgs, k, val); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("key %q is not a string or object", k)
+		}
+	}
+	return nil
+}
+
+// normalize normalizes a language tag, for example "pt_br" to "pt-br"
+func normalize(lang string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(lang), "_", "-"))
+}
+
+// Languages returns the catalog languages sorted
+func (b *Bundle) Languages() []string {
+	langs := make([]string, 0, len(b.catalogs))
+	for lang := range b.catalogs {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// Localizer returns a localizer for the first available language, languages are matched
+// exactly and then by base language, for example "en-GB" matches "en" and "pt" matches
+// "pt-br", the fallback language is used when no language is available
+func (b *Bundle) Localizer(langs ...string) *Localizer {
+	for _, lang := range langs {
+		lang = normalize(lang)
+		if _, ok := b.catalogs[lang]; ok {
+			return &Localizer{bundle: b, lang: lang}
+		}
+		base, _, _ := strings.Cut(lang, "-")
+		if _, ok := b.catalogs[base]; ok {
+			return &Localizer{bundle: b, lang: base}
+		}
+		for _, l := range b.Languages() {
+			if strings.HasPrefix(l, base+"-") {
+				return &Localizer{bundle: b, lang: l}
+			}
+		}
+	}
+	return &Localizer{bundle: b, lang: b.fallback}
+}
+
+// Localizer translates messages for a language
+type Localizer struct {
+	bundle *Bundle
+	lang   string
+}
+
+// Language returns the localizer language
+func (l *Localizer) Language() string {
+	return l.lang
+}
+
+// T returns the message for the key formatted with the args using fmt.Sprintf, the fallback
+// language message is used when the message is missing and the key is returned when the
+// message is missing in both
+func (l *Localizer) T(key string, args ...any) string {
+	msg, ok := l.bundle.catalogs[l.lang][key]
+	if !ok {
+		if msg, ok = l.bundle.catalogs[l.bundle.fallback][key]; !ok {
+			return key
+		}
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(msg, args...)
+	}
+	return msg
+}
+
+// ParseAcceptLanguage parses an Accept-Language header value and returns the languages
+// sorted by quality, languages with quality 0 and the "*" wildcard are excluded
+func ParseAcceptLanguage(v string) []string {
+	type lq struct {
+		lang string
+		q    float64
+	}
+	var list []lq
+	for _, part := range strings.Split(v, ",") {
+		lang, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		lang = strings.TrimSpace(lang)
+		if lang == "" || lang == "*" {
+			continue
+		}
+		q := 1.0
+		if qv, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			f, err := strconv.ParseFloat(qv, 64)
+			if err != nil {
+				continue
+			}
+			q = f
+		}
+		if q <= 0 {
+			continue
+		}
+		list = append(list, lq{lang: lang, q: q})
+	}
+
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].q > list[j].q
+	})
+	langs := make([]string, len(list))
+	for i, l := range list {
+		langs[i] = l.lang
+	}
+	return langs
+}
+
+// Middleware returns middleware that negotiates the request language from the
+// Accept-Language header and stores the localizer in the request context
+func (b *Bundle) Middleware() server.Middleware {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			l := b.Localizer(ParseAcceptLanguage(r.Header.Get("Accept-Language"))...)
+			w.Header().Add("Vary", "Accept-Language")
+			w.Header().Set("Content-Language", l.lang)
+			next.ServeHTTP(w, r.WithContext(WithLocalizer(r.Context(), l)))
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// localizerKey is the context key for the localizer
+type localizerKey struct{}
+
+// FromContext returns the localizer from the context, returns nil when the context has no
+// localizer
+func FromContext(ctx context.Context) *Localizer {
+	l, _ := ctx.Value(localizerKey{}).(*Localizer)
+	return l
+}
+
+// T returns the message for the key using the context localizer, the key is returned when
+// the context has no localizer
+func T(ctx context.Context, key string, args ...any) string {
+	if l := FromContext(ctx); l != nil {
+		return l.T(key, args...)
+	}
+	return key
+}
+
+// WithLocalizer returns a copy of the context with the localizer
+func WithLocalizer(ctx context.Context, l *Localizer) context.Context {
+	return context.WithValue(ctx, localizerKey{}, l)
+}
@@ -0,0 +1,189 @@
+package id
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+)
+
+// ErrInvalid is returned when an ID cannot be parsed
+var ErrInvalid = errors.New("id: invalid id")
+
+// crockford is the Crockford base32 alphabet used by ULIDs
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// shortAlphabet is the URL safe alphabet used by Short
+const shortAlphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// UUID is an RFC 9562 UUID
+type UUID [16]byte
+
+// ULID is a universally unique lexicographically sortable identifier
+type ULID [16]byte
+
+// NewULID returns a new ULID with the current time and random entropy
+func NewULID() ULID {
+	var u ULID
+	ms := uint64(time.Now().UnixMilli())
+	u[0] = byte(ms >> 40)
+	u[1] = byte(ms >> 32)
+	binary.BigEndian.PutUint32(u[2:6], uint32(ms))
+	random(u[6:])
+	return u
+}
+
+// NewV4 returns a new random (version 4) UUID
+func NewV4() UUID {
+	var u UUID
+	random(u[:])
+	u[6] = (u[6] & 0x0f) | 0x40
+	u[8] = (u[8] & 0x3f) | 0x80
+	return u
+}
+
+// NewV7 returns a new time ordered (version 7) UUID
+func NewV7() UUID {
+	var u UUID
+	random(u[6:])
+	ms := uint64(time.Now().UnixMilli())
+	u[0] = byte(ms >> 40)
+	u[1] = byte(ms >> 32)
+	binary.BigEndian.PutUint32(u[2:6], uint32(ms))
+	u[6] = (u[6] & 0x0f) | 0x70
+	u[8] = (u[8] & 0x3f) | 0x80
+	return u
+}
+
+// ParsePrefixed parses a prefixed ID returned by Prefixed, the prefix must match
+func ParsePrefixed(s, prefix string) (ULID, error) {
+	rest, ok := strings.CutPrefix(s, prefix+"_")
+	if !ok {
+		return ULID{}, ErrInvalid
+	}
+	return ParseULID(rest)
+}
+
+// ParseULID parses a ULID string, case insensitive
+func ParseULID(s string) (ULID, error) {
+	var u ULID
+	if len(s) != 26 {
+		return u, ErrInvalid
+	}
+	var hi, lo uint64
+	for i := 0; i < len(s); i++ {
+		v := strings.IndexByte(crockford, upper(s[i]))
+		if v < 0 || (i == 0 && v > 7) {
+			return u, ErrInvalid
+		}
+		hi = hi<<5 | lo>>59
+		lo = lo<<5 | uint64(v)
+	}
+	binary.BigEndian.PutUint64(u[:8], hi)
+	binary.BigEndian.PutUint64(u[8:], lo)
+	return u, nil
+}
+
+// ParseUUID parses a UUID in the canonical 8-4-4-4-12 hex format
+func ParseUUID(s string) (UUID, error) {
+	var u UUID
+	if len(s) != 36 || s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+		return u, ErrInvalid
+	}
+	h := s[0:8] + s[9:13] + s[14:18] + s[19:23] + s[24:]
+	if _, err := hex.Decode(u[:], []byte(h)); err != nil {
+		return u, ErrInvalid
+	}
+	return u, nil
+}
+
+// Prefixed returns a new type prefixed ID in the format "<prefix>_<ulid>" with a lowercase
+// ULID, for example "itm_01j9z3k6m8c0q4t5v7w8x9y0za", IDs with the same prefix sort by
+// creation time
+func Prefixed(prefix string) string {
+	return prefix + "_" + strings.ToLower(NewULID().String())
+}
+
+// Short returns a random URL safe ID of n alphanumeric chars, 22 chars give about 131 bits
+// of entropy
+func Short(n int) string {
+	b := make([]byte, n)
+	buf := make([]byte, n+n/4+1)
+	for i := 0; i < n; {
+		random(buf)
+		for _, c := range buf {
+			// rejection sampling keeps the distribution uniform: 62*4 = 248
+			if c >= 248 {
+				continue
+			}
+			b[i] = shortAlphabet[c%62]
+			if i++; i == n {
+				break
+			}
+		}
+	}
+	return string(b)
+}
+
+// ValidPrefixed returns true if the string is a valid prefixed ID with the prefix
+func ValidPrefixed(s, prefix string) bool {
+	_, err := ParsePrefixed(s, prefix)
+	return err == nil
+}
+
+// String returns the canonical 26 char Crockford base32 encoding
+func (u ULID) String() string {
+	hi := binary.BigEndian.Uint64(u[:8])
+	lo := binary.BigEndian.Uint64(u[8:])
+	b := make([]byte, 26)
+	for i := 25; i >= 0; i-- {
+		b[i] = crockford[lo&31]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(b)
+}
+
+// Time returns the ULID timestamp
+func (u ULID) Time() time.Time {
+	ms := uint64(u[0])<<40 | uint64(u[1])<<32 | uint64(binary.BigEndian.Uint32(u[2:6]))
+	return time.UnixMilli(int64(ms))
+}
+
+// String returns the canonical 8-4-4-4-12 hex encoding
+func (u UUID) String() string {
+	b := make([]byte, 36)
+	hex.Encode(b[0:8], u[0:4])
+	b[8] = '-'
+	hex.Encode(b[9:13], u[4:6])
+	b[13] = '-'
+	hex.Encode(b[14:18], u[6:8])
+	b[18] = '-'
+	hex.Encode(b[19:23], u[8:10])
+	b[23] = '-'
+	hex.Encode(b[24:], u[10:])
+	return string(b)
+}
+
+// Version returns the UUID version
+func (u UUID) Version() int {
+	return int(u[6] >> 4)
+}
+
+// random fills b with cryptographically secure random bytes, panics if the system random
+// source fails since IDs cannot be generated safely without it
+func random(b []byte) {
+	if _, err := rand.Read(b); err != nil {
+		panic("id: random source failed: " + err.Error())
+	}
+}
+
+// upper returns the uppercase ASCII letter
+func upper(c byte) byte {
+	if c >= 'a' && c <= 'z' {
+		return c - 'a' + 'A'
+	}
+	return c
+}
@@ -0,0 +1,362 @@
+package metrics
+
+import (
+	"expvar"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Default is the default registry
+var Default = NewRegistry()
+
+// DefaultBuckets are the default histogram buckets, in seconds for latency histograms
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Counter is a metric that only increases
+type Counter interface {
+	// Add adds a non-negative value
+	Add(v float64)
+
+	// Inc adds 1
+	Inc()
+}
+
+// Gauge is a metric that can increase and decrease
+type Gauge interface {
+	// Add adds a value, which can be negative
+	Add(v float64)
+
+	// Set sets the value
+	Set(v float64)
+}
+
+// Histogram is a metric that samples observations into buckets
+type Histogram interface {
+	// Observe adds an observation
+	Observe(v float64)
+}
+
+// Labels are metric labels
+type Labels map[string]string
+
+// kind is a metric kind
+type kind string
+
+const (
+	kindCounter   kind = "counter"
+	kindGauge     kind = "gauge"
+	kindHistogram kind = "histogram"
+)
+
+// family is a named metric with series per label set
+type family struct {
+	buckets []float64
+	help    string
+	kind    kind
+	name    string
+	series  map[string]any
+}
+
+// Registry is a metrics registry, safe for concurrent use
+type Registry struct {
+	families map[string]*family
+	mu       sync.RWMutex
+}
+
+// NewRegistry creates a new Registry
+func NewRegistry() *Registry {
+	return &Registry{
+		families: map[string]*family{},
+	}
+}
+
+// Counter returns the counter with the name and labels, creating it if needed, panics if the
+// name is registered as a different kind
+func (r *Registry) Counter(name, help string, labels Labels) Counter {
+	return r.series(name, help, kindCounter, nil, labels, func() any { return &counter{} }).(*counter)
+}
+
+// Gauge returns the gauge with the name and labels, creating it if needed, panics if the name
+// is registered as a different kind
+func (r *Registry) Gauge(name, help string, labels Labels) Gauge {
+	return r.series(name, help, kindGauge, nil, labels, func() any { return &gauge{} }).(*gauge)
+}
+
+// Histogram returns the histogram with the name and labels, creating it if needed, buckets
+// are the upper bounds used when the name is first registered, DefaultBuckets when nil,
+// panics if the name is registered as a different kind
+func (r *Registry) Histogram(name, help string, buckets []float64, labels Labels) Histogram {
+	if buckets == nil {
+		buckets = DefaultBuckets
+	}
+	return r.series(name, help, kindHistogram, buckets, labels, nil).(*histogram)
+}
+
+// Publish publishes a snapshot of the registry as an expvar variable, panics if the name is
+// already published
+func (r *Registry) Publish(name string) {
+	expvar.Publish(name, expvar.Func(func() any {
+		return r.Snapshot()
+	}))
+}
+
+// Snapshot returns the current values keyed by series name, for example
+// `http_requests_total{method="GET"}`, histograms return their count and sum
+func (r *Registry) Snapshot() map[string]any {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	m := map[string]any{}
+	for _, f := range r.families {
+		for key, s := range f.series {
+			switch s := s.(type) {
+			case *counter:
+				m[f.name+key] = s.value()
+			case *gauge:
+				m[f.name+key] = s.value()
+			case *histogram:
+				count, sum, _ := s.snapshot()
+				m[f.name+key] = map[string]any{"count": count, "sum": sum}
+			}
+		}
+	}
+	return m
+}
+
+// WritePrometheus writes the metrics in the Prometheus text exposition format
+func (r *Registry) WritePrometheus(w io.Writer) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.families))
+	for name := range r.families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	b := &strings.Builder{}
+	for _, name := range names {
+		f := r.families[name]
+		if f.help != "" {
+			fmt.Fprintf(b, "# HELP %s %s\n", f.name, escape(f.help, false))
+		}
+		fmt.Fprintf(b, "# TYPE %s %s\n", f.name, f.kind)
+
+		keys := make([]string, 0, len(f.series))
+		for k := range f.series {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			switch s := f.series[key].(type) {
+			case *counter:
+				fmt.Fprintf(b, "%s%s %s\n", f.name, key, formatFloat(s.value()))
+			case *gauge:
+				fmt.Fprintf(b, "%s%s %s\n", f.name, key, formatFloat(s.value()))
+			case *histogram:
+				count, sum, counts := s.snapshot()
+				var cum uint64
+				for i, le := range s.buckets {
+					cum += counts[i]
+					fmt.Fprintf(b, "%s_bucket%s %d\n", f.name, withLabel(key, "le", formatFloat(le)), cum)
+				}
+				fmt.Fprintf(b, "%s_bucket%s %d\n", f.name, withLabel(key, "le", "+Inf"), count)
+				fmt.Fprintf(b, "%s_sum%s %s\n", f.name, key, formatFloat(sum))
+				fmt.Fprintf(b, "%s_count%s %d\n", f.name, key, count)
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// series returns the series for the name and labels, creating the family and series if needed
+func (r *Registry) series(
+	name, help string,
+	k kind,
+	buckets []float64,
+	labels Labels,
+	create func() any,
+) any {
+	key := labelKey(labels)
+
+	r.mu.RLock()
+	f, ok := r.families[name]
+	if ok && f.kind == k {
+		if s, ok := f.series[key]; ok {
+			r.mu.RUnlock()
+			return s
+		}
+	}
+	r.mu.RUnlock()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	f, ok = r.families[name]
+	if !ok {
+		f = &family{
+			buckets: append([]float64(nil), buckets...),
+			help:    help,
+			kind:    k,
+			name:    name,
+			series:  map[string]any{},
+		}
+		sort.Float64s(f.buckets)
+		r.families[name] = f
+	}
+	if f.kind != k {
+		panic(fmt.Sprintf("metrics: %s registered as %s, not %s", name, f.kind, k))
+	}
+	if s, ok := f.series[key]; ok {
+		return s
+	}
+	var s any
+	if k == kindHistogram {
+		s = &histogram{buckets: f.buckets, counts: make([]uint64, len(f.buckets))}
+	} else {
+		s = create()
+	}
+	f.series[key] = s
+	return s
+}
+
+// counter implements the Counter interface
+type counter struct {
+	bits atomic.Uint64
+}
+
+// Add implements the Counter interface, negative values are ignored
+func (c *counter) Add(v float64) {
+	if v < 0 {
+		return
+	}
+	addFloat(&c.bits, v)
+}
+
+// Inc implements the Counter interface
+func (c *counter) Inc() {
+	c.Add(1)
+}
+
+// value returns the counter value
+func (c *counter) value() float64 {
+	return math.Float64frombits(c.bits.Load())
+}
+
+// gauge implements the Gauge interface
+type gauge struct {
+	bits atomic.Uint64
+}
+
+// Add implements the Gauge interface
+func (g *gauge) Add(v float64) {
+	addFloat(&g.bits, v)
+}
+
+// Set implements the Gauge interface
+func (g *gauge) Set(v float64) {
+	g.bits.Store(math.Float64bits(v))
+}
+
+// value returns the gauge value
+func (g *gauge) value() float64 {
+	return math.Float64frombits(g.bits.Load())
+}
+
+// histogram implements the Histogram interface
+type histogram struct {
+	buckets []float64
+	count   uint64
+	counts  []uint64
+	mu      sync.Mutex
+	sum     float64
+}
+
+// Observe implements the Histogram interface
+func (h *histogram) Observe(v float64) {
+	i := sort.SearchFloat64s(h.buckets, v)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if i < len(h.counts) {
+		h.counts[i]++
+	}
+	h.count++
+	h.sum += v
+}
+
+// snapshot returns the count, sum and per bucket (non-cumulative) counts
+func (h *histogram) snapshot() (uint64, float64, []uint64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.count, h.sum, append([]uint64(nil), h.counts...)
+}
+
+// addFloat atomically adds a float64 stored as bits
+func addFloat(bits *atomic.Uint64, v float64) {
+	for {
+		old := bits.Load()
+		if bits.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+v)) {
+			return
+		}
+	}
+}
+
+// escape escapes a help text or label value for the text exposition format
+func escape(s string, quote bool) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	if quote {
+		s = strings.ReplaceAll(s, `"`, `\"`)
+	}
+	return s
+}
+
+// formatFloat formats a float for the text exposition format
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// labelKey returns the labels formatted as `{k="v",...}` sorted by name, or an empty string
+func labelKey(labels Labels) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(labels))
+	for k := range labels {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	b := &strings.Builder{}
+	b.WriteByte('{')
+	for i, k := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(b, `%s="%s"`, k, escape(labels[k], true))
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// withLabel returns the label key with an extra label appended
+func withLabel(key, name, value string) string {
+	l := fmt.Sprintf(`%s="%s"`, name, value)
+	if key == "" {
+		return "{" + l + "}"
+	}
+	return key[:len(key)-1] + "," + l + "}"
+}
@@ -0,0 +1,120 @@
+package metrics
+
+import (
+	"context"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// RuntimeStats are sampled Go runtime stats
+type RuntimeStats struct {
+	// GCPauseLast is the duration of the last GC pause
+	GCPauseLast time.Duration `json:"gc_pause_last"`
+
+	// GCPauseTotal is the cumulative GC pause duration
+	GCPauseTotal time.Duration `json:"gc_pause_total"`
+
+	// Goroutines is the number of goroutines
+	Goroutines int `json:"goroutines"`
+
+	// HeapAlloc is the bytes of allocated heap objects
+	HeapAlloc uint64 `json:"heap_alloc"`
+
+	// HeapInuse is the bytes in in-use heap spans
+	HeapInuse uint64 `json:"heap_inuse"`
+
+	// HeapObjects is the number of allocated heap objects
+	HeapObjects uint64 `json:"heap_objects"`
+
+	// NumGC is the number of completed GC cycles
+	NumGC uint32 `json:"num_gc"`
+
+	// OpenFDs is the number of open file descriptors, -1 when not available on the platform
+	OpenFDs int `json:"open_fds"`
+
+	// SampledAt is the time the stats were sampled
+	SampledAt time.Time `json:"sampled_at"`
+}
+
+// RuntimeCollector periodically samples runtime stats into a registry
+type RuntimeCollector struct {
+	interval time.Duration
+	last     RuntimeStats
+	mu       sync.RWMutex
+	reg      *Registry
+}
+
+// NewRuntimeCollector creates a new RuntimeCollector that samples every interval
+func NewRuntimeCollector(reg *Registry, interval time.Duration) *RuntimeCollector {
+	return &RuntimeCollector{
+		interval: interval,
+		reg:      reg,
+	}
+}
+
+// Run samples the runtime stats until the context is done
+func (c *RuntimeCollector) Run(ctx context.Context) error {
+	c.Sample()
+
+	t := time.NewTicker(c.interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-t.C:
+			c.Sample()
+		}
+	}
+}
+
+// Sample samples the runtime stats, updates the registry gauges and returns the stats
+func (c *RuntimeCollector) Sample() RuntimeStats {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+
+	s := RuntimeStats{
+		GCPauseLast:  time.Duration(m.PauseNs[(m.NumGC+255)%256]),
+		GCPauseTotal: time.Duration(m.PauseTotalNs),
+		Goroutines:   runtime.NumGoroutine(),
+		HeapAlloc:    m.HeapAlloc,
+		HeapInuse:    m.HeapInuse,
+		HeapObjects:  m.HeapObjects,
+		NumGC:        m.NumGC,
+		OpenFDs:      openFDs(),
+		SampledAt:    time.Now(),
+	}
+
+	c.reg.Gauge("go_goroutines", "Number of goroutines", nil).Set(float64(s.Goroutines))
+	c.reg.Gauge("go_memstats_heap_alloc_bytes", "Heap bytes allocated", nil).Set(float64(s.HeapAlloc))
+	c.reg.Gauge("go_memstats_heap_inuse_bytes", "Heap bytes in use", nil).Set(float64(s.HeapInuse))
+	c.reg.Gauge("go_memstats_heap_objects", "Number of allocated heap objects", nil).
+		Set(float64(s.HeapObjects))
+	c.reg.Gauge("go_gc_cycles_total", "Number of completed GC cycles", nil).Set(float64(s.NumGC))
+	c.reg.Gauge("go_gc_pause_seconds_total", "Cumulative GC pause duration", nil).
+		Set(s.GCPauseTotal.Seconds())
+	c.reg.Gauge("process_open_fds", "Number of open file descriptors", nil).Set(float64(s.OpenFDs))
+
+	c.mu.Lock()
+	c.last = s
+	c.mu.Unlock()
+	return s
+}
+
+// Stats returns the last sampled stats
+func (c *RuntimeCollector) Stats() RuntimeStats {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.last
+}
+
+// openFDs returns the number of open file descriptors, -1 when /proc is not available
+func openFDs() int {
+	entries, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		return -1
+	}
+	return len(entries)
+}
@@ -0,0 +1,210 @@
+package metrics
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// SLO is a route service level objective
+type SLO struct {
+	// Latency is the max duration of a good request, 0 only counts failed requests as bad
+	Latency time.Duration
+
+	// Objective is the target ratio of good requests, for example 0.999
+	Objective float64
+
+	// Route is the route pattern, for example "GET /example"
+	Route string
+}
+
+// BurnAlert is raised when an SLO error budget burns faster than the burn rate threshold in
+// both the short and long window
+type BurnAlert struct {
+	// LongBurn is the burn rate over the long window
+	LongBurn float64
+
+	// ShortBurn is the burn rate over the short window
+	ShortBurn float64
+
+	// SLO is the objective burning
+	SLO SLO
+}
+
+// SLOOptions are the SLOEvaluator options
+type SLOOptions struct {
+	// BurnRate is the burn rate alert threshold, defaults to 14.4 (2% of a 30 day budget in
+	// one hour)
+	BurnRate float64
+
+	// Cooldown is the min time between alerts for the same route, defaults to 15 minutes
+	Cooldown time.Duration
+
+	// LongWindow is the long evaluation window, defaults to 1 hour
+	LongWindow time.Duration
+
+	// OnBurn is called for each alert, defaults to logging a warning
+	OnBurn func(BurnAlert)
+
+	// Registry receives slo_burn_rate gauges when set
+	Registry *Registry
+
+	// ShortWindow is the short evaluation window, defaults to 5 minutes
+	ShortWindow time.Duration
+}
+
+// sloBucket counts requests in a time slot
+type sloBucket struct {
+	bad  uint64
+	good uint64
+	slot int64
+}
+
+// sloRoute tracks the buckets of a route SLO
+type sloRoute struct {
+	alerted time.Time
+	buckets []sloBucket
+	slo     SLO
+}
+
+// SLOEvaluator tracks route request outcomes and raises alerts when error budgets burn too
+// fast, the burn rate is the bad request ratio divided by the error budget (1 - objective)
+type SLOEvaluator struct {
+	mu         sync.Mutex
+	opts       SLOOptions
+	resolution time.Duration
+	routes     map[string]*sloRoute
+}
+
+// NewSLOEvaluator creates a new SLOEvaluator for the SLOs
+func NewSLOEvaluator(opts SLOOptions, slos ...SLO) *SLOEvaluator {
+	if opts.BurnRate <= 0 {
+		opts.BurnRate = 14.4
+	}
+	if opts.Cooldown <= 0 {
+		opts.Cooldown = 15 * time.Minute
+	}
+	if opts.ShortWindow <= 0 {
+		opts.ShortWindow = 5 * time.Minute
+	}
+	if opts.LongWindow < opts.ShortWindow {
+		opts.LongWindow = max(time.Hour, opts.ShortWindow)
+	}
+	if opts.OnBurn == nil {
+		opts.OnBurn = func(a BurnAlert) {
+			slog.Warn(
+				"slo error budget burning",
+				"route", a.SLO.Route,
+				"objective", a.SLO.Objective,
+				"short_burn", a.ShortBurn,
+				"long_burn", a.LongBurn,
+			)
+		}
+	}
+
+	e := &SLOEvaluator{
+		opts:       opts,
+		resolution: max(opts.ShortWindow/5, time.Second),
+		routes:     map[string]*sloRoute{},
+	}
+	n := int(opts.LongWindow/e.resolution) + 1
+	for _, s := range slos {
+		e.routes[s.Route] = &sloRoute{
+			buckets: make([]sloBucket, n),
+			slo:     s,
+		}
+	}
+	return e
+}
+
+// Evaluate computes the burn rates of all SLOs, calls OnBurn for routes burning in both
+// windows that are not in cooldown and returns the alerts raised
+func (e *SLOEvaluator) Evaluate() []BurnAlert {
+	e.mu.Lock()
+	now := time.Now()
+	slot := now.UnixNano() / int64(e.resolution)
+	short := int64(e.opts.ShortWindow / e.resolution)
+	long := int64(e.opts.LongWindow / e.resolution)
+
+	var alerts []BurnAlert
+	for _, rt := range e.routes {
+		sb := rt.burn(slot, short)
+		lb := rt.burn(slot, long)
+		if e.opts.Registry != nil {
+			e.opts.Registry.Gauge(
+				"slo_burn_rate",
+				"SLO error budget burn rate",
+				Labels{"route": rt.slo.Route, "window": "short"},
+			).Set(sb)
+			e.opts.Registry.Gauge(
+				"slo_burn_rate",
+				"SLO error budget burn rate",
+				Labels{"route": rt.slo.Route, "window": "long"},
+			).Set(lb)
+		}
+		if sb < e.opts.BurnRate || lb < e.opts.BurnRate || now.Sub(rt.alerted) < e.opts.Cooldown {
+			continue
+		}
+		rt.alerted = now
+		alerts = append(alerts, BurnAlert{LongBurn: lb, ShortBurn: sb, SLO: rt.slo})
+	}
+	e.mu.Unlock()
+
+	for _, a := range alerts {
+		e.opts.OnBurn(a)
+	}
+	return alerts
+}
+
+// Record records a request outcome for a route, routes without an SLO are ignored
+func (e *SLOEvaluator) Record(route string, ok bool, d time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	rt, found := e.routes[route]
+	if !found {
+		return
+	}
+
+	slot := time.Now().UnixNano() / int64(e.resolution)
+	b := &rt.buckets[slot%int64(len(rt.buckets))]
+	if b.slot != slot {
+		*b = sloBucket{slot: slot}
+	}
+	if ok && (rt.slo.Latency == 0 || d <= rt.slo.Latency) {
+		b.good++
+	} else {
+		b.bad++
+	}
+}
+
+// Run evaluates the SLOs every interval until the context is done
+func (e *SLOEvaluator) Run(ctx context.Context, interval time.Duration) error {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-t.C:
+			e.Evaluate()
+		}
+	}
+}
+
+// burn returns the burn rate over the last n slots
+func (r *sloRoute) burn(slot, n int64) float64 {
+	var good, bad uint64
+	for _, b := range r.buckets {
+		if b.slot > slot-n && b.slot <= slot {
+			good += b.good
+			bad += b.bad
+		}
+	}
+	total := good + bad
+	budget := 1 - r.slo.Objective
+	if total == 0 || budget <= 0 {
+		return 0
+	}
+	return (float64(bad) / float64(total)) / budget
+}
@@ -0,0 +1,340 @@
+package patch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalid is returned when a patch is invalid
+var ErrInvalid = errors.New("patch: invalid patch")
+
+// ErrTest is returned when a JSON Patch test operation fails
+var ErrTest = errors.New("patch: test failed")
+
+// Merge applies an RFC 7386 JSON merge patch to a JSON document, object members set to null
+// in the patch are removed and other values replace the document values
+func Merge(doc, patch []byte) ([]byte, error) {
+	var d any
+	if len(bytes.TrimSpace(doc)) > 0 {
+		if err := decode(doc, &d); err != nil {
+			return nil, err
+		}
+	}
+	var p any
+	if err := decode(patch, &p); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalid, err)
+	}
+	return json.Marshal(merge(d, p))
+}
+
+// merge merges a patch value into a target value
+func merge(target, patch any) any {
+	p, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]any)
+	if !ok {
+		t = map[string]any{}
+	}
+	for k, v := range p {
+		if v == nil {
+			delete(t, k)
+			continue
+		}
+		t[k] = merge(t[k], v)
+	}
+	return t
+}
+
+// Operation is an RFC 6902 JSON Patch operation
+type Operation struct {
+	// From is the source JSON pointer of move and copy operations
+	From string `json:"from,omitempty"`
+
+	// Op is the operation, one of add, remove, replace, move, copy or test
+	Op string `json:"op"`
+
+	// Path is the target JSON pointer
+	Path string `json:"path"`
+
+	// Value is the value of add, replace and test operations
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// Apply applies an RFC 6902 JSON Patch, a JSON array of operations, to a JSON document, the
+// operations are applied in order and no change is returned when any operation fails
+func Apply(doc, patch []byte) ([]byte, error) {
+	var ops []Operation
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalid, err)
+	}
+	var d any
+	if err := decode(doc, &d); err != nil {
+		return nil, err
+	}
+
+	for i, op := range ops {
+		var err error
+		if d, err = apply(d, op); err != nil {
+			return nil, fmt.Errorf("operation %d: %w", i, err)
+		}
+	}
+	return json.Marshal(d)
+}
+
+// apply applies an operation to a document
+func apply(doc any, op Operation) (any, error) {
+	path, err := pointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	var value any
+	switch op.Op {
+	case "add", "replace", "test":
+		if len(op.Value) == 0 {
+			return nil, fmt.Errorf("%w: missing value", ErrInvalid)
+		}
+		if err := decode(op.Value, &value); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalid, err)
+		}
+	case "move", "copy":
+		from, err := pointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		if value, err = get(doc, from); err != nil {
+			return nil, err
+		}
+		if op.Op == "move" {
+			if op.Path == op.From {
+				return doc, nil
+			}
+			if strings.HasPrefix(op.Path, op.From+"/") {
+				return nil, fmt.Errorf("%w: cannot move a value into itself", ErrInvalid)
+			}
+			if doc, err = update(doc, from, remove); err != nil {
+				return nil, err
+			}
+		} else {
+			// copy so later operations do not change both values
+			b, _ := json.Marshal(value)
+			_ = decode(b, &value)
+		}
+	}
+
+	switch op.Op {
+	case "add", "move", "copy":
+		return update(doc, path, func(c any, key string) (any, error) {
+			return add(c, key, value)
+		})
+	case "remove":
+		return update(doc, path, remove)
+	case "replace":
+		return update(doc, path, func(c any, key string) (any, error) {
+			return replace(c, key, value)
+		})
+	case "test":
+		v, err := get(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !equal(v, value) {
+			return nil, ErrTest
+		}
+		return doc, nil
+	}
+	return nil, fmt.Errorf("%w: unknown operation %q", ErrInvalid, op.Op)
+}
+
+// pointer parses an RFC 6901 JSON pointer into reference tokens
+func pointer(p string) ([]string, error) {
+	if p == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(p, "/") {
+		return nil, fmt.Errorf("%w: invalid pointer %q", ErrInvalid, p)
+	}
+	tokens := strings.Split(p[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(t, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// get returns the value at the pointer tokens
+func get(doc any, tokens []string) (any, error) {
+	for _, t := range tokens {
+		switch c := doc.(type) {
+		case map[string]any:
+			v, ok := c[t]
+			if !ok {
+				return nil, fmt.Errorf("%w: path not found", ErrInvalid)
+			}
+			doc = v
+		case []any:
+			i, err := index(t, len(c)-1)
+			if err != nil {
+				return nil, err
+			}
+			doc = c[i]
+		default:
+			return nil, fmt.Errorf("%w: path not found", ErrInvalid)
+		}
+	}
+	return doc, nil
+}
+
+// update calls fn with the container of the last pointer token and the token, and returns the
+// document with the container returned by fn, the root is replaced when tokens is empty
+func update(doc any, tokens []string, fn func(c any, key string) (any, error)) (any, error) {
+	if len(tokens) == 0 {
+		// the root is a single value container
+		return fn(nil, "")
+	}
+	if len(tokens) == 1 {
+		return fn(doc, tokens[0])
+	}
+
+	child, err := get(doc, tokens[:1])
+	if err != nil {
+		return nil, err
+	}
+	child, err = update(child, tokens[1:], fn)
+	if err != nil {
+		return nil, err
+	}
+	return replace(doc, tokens[0], child)
+}
+
+// add adds a value to a container, "-" appends to arrays
+func add(c any, key string, v any) (any, error) {
+	switch c := c.(type) {
+	case nil:
+		return v, nil
+	case map[string]any:
+		c[key] = v
+		return c, nil
+	case []any:
+		i := len(c)
+		if key != "-" {
+			var err error
+			if i, err = index(key, len(c)); err != nil {
+				return nil, err
+			}
+		}
+		c = append(c, nil)
+		copy(c[i+1:], c[i:])
+		c[i] = v
+		return c, nil
+	}
+	return nil, fmt.Errorf("%w: path not found", ErrInvalid)
+}
+
+// remove removes a value from a container
+func remove(c any, key string) (any, error) {
+	switch c := c.(type) {
+	case nil:
+		return nil, nil
+	case map[string]any:
+		if _, ok := c[key]; !ok {
+			return nil, fmt.Errorf("%w: path not found", ErrInvalid)
+		}
+		delete(c, key)
+		return c, nil
+	case []any:
+		i, err := index(key, len(c)-1)
+		if err != nil {
+			return nil, err
+		}
+		return append(c[:i], c[i+1:]...), nil
+	}
+	return nil, fmt.Errorf("%w: path not found", ErrInvalid)
+}
+
+// replace replaces an existing value in a container
+func replace(c any, key string, v any) (any, error) {
+	switch c := c.(type) {
+	case nil:
+		return v, nil
+	case map[string]any:
+		if _, ok := c[key]; !ok {
+			return nil, fmt.Errorf("%w: path not found", ErrInvalid)
+		}
+		c[key] = v
+		return c, nil
+	case []any:
+		i, err := index(key, len(c)-1)
+		if err != nil {
+			return nil, err
+		}
+		c[i] = v
+		return c, nil
+	}
+	return nil, fmt.Errorf("%w: path not found", ErrInvalid)
+}
+
+// index parses an array index token, the index must be between 0 and max
+func index(t string, max int) (int, error) {
+	i, err := strconv.Atoi(t)
+	if err != nil || i < 0 || i > max || (len(t) > 1 && t[0] == '0') {
+		return 0, fmt.Errorf("%w: invalid array index %q", ErrInvalid, t)
+	}
+	return i, nil
+}
+
+// equal checks if two JSON values are equal, numbers are compared by value
+func equal(a, b any) bool {
+	switch a := a.(type) {
+	case json.Number:
+		bn, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		af, err1 := a.Float64()
+		bf, err2 := bn.Float64()
+		return err1 == nil && err2 == nil && af == bf
+	case map[string]any:
+		bm, ok := b.(map[string]any)
+		if !ok || len(a) != len(bm) {
+			return false
+		}
+		for k, v := range a {
+			bv, ok := bm[k]
+			if !ok || !equal(v, bv) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		bs, ok := b.([]any)
+		if !ok || len(a) != len(bs) {
+			return false
+		}
+		for i := range a {
+			if !equal(a[i], bs[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return a == b
+}
+
+// decode decodes JSON keeping numbers as json.Number so they are not rounded
+func decode(b []byte, v *any) error {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if dec.More() {
+		return errors.New("unexpected data after JSON value")
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package patch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+// jsonEqual checks if two JSON documents are equal ignoring formatting and member order,
+// numbers are compared as written
+func jsonEqual(t *testing.T, got []byte, want string) bool {
+	t.Helper()
+	var g, w any
+	if err := decode(got, &g); err != nil {
+		t.Fatalf("invalid result %s: %v", got, err)
+	}
+	if err := decode([]byte(want), &w); err != nil {
+		t.Fatalf("invalid want %s: %v", want, err)
+	}
+	gb, _ := json.Marshal(g)
+	wb, _ := json.Marshal(w)
+	return bytes.Equal(gb, wb)
+}
+
+func TestMerge(t *testing.T) {
+	// cases from RFC 7386 appendix A
+	tests := []struct {
+		doc   string
+		patch string
+		want  string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+		{``, `{"a":1}`, `{"a":1}`},
+		{`{"n":12345678901234567890}`, `{"a":1}`, `{"n":12345678901234567890,"a":1}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.doc+" "+tt.patch, func(t *testing.T) {
+			got, err := Merge([]byte(tt.doc), []byte(tt.patch))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !jsonEqual(t, got, tt.want) {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		doc   string
+		patch string
+		want  error
+	}{
+		{"invalid patch", `{}`, `{`, ErrInvalid},
+		{"trailing patch data", `{}`, `{} {}`, ErrInvalid},
+		{"empty patch", `{}`, ``, ErrInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Merge([]byte(tt.doc), []byte(tt.patch)); !errors.Is(err, tt.want) {
+				t.Errorf("got error %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	if _, err := Merge([]byte(`{`), []byte(`{}`)); err == nil {
+		t.Error("expected error for invalid document")
+	}
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name  string
+		doc   string
+		patch string
+		want  string
+	}{
+		{"add member", `{"a":1}`, `[{"op":"add","path":"/b","value":2}]`, `{"a":1,"b":2}`},
+		{"add replaces member", `{"a":1}`, `[{"op":"add","path":"/a","value":2}]`, `{"a":2}`},
+		{
+			"add array element",
+			`{"a":[1,3]}`,
+			`[{"op":"add","path":"/a/1","value":2}]`,
+			`{"a":[1,2,3]}`,
+		},
+		{"append array", `{"a":[1]}`, `[{"op":"add","path":"/a/-","value":2}]`, `{"a":[1,2]}`},
+		{"replace root", `{"a":1}`, `[{"op":"add","path":"","value":[1]}]`, `[1]`},
+		{"remove member", `{"a":1,"b":2}`, `[{"op":"remove","path":"/a"}]`, `{"b":2}`},
+		{"remove array element", `[1,2,3]`, `[{"op":"remove","path":"/1"}]`, `[1,3]`},
+		{
+			"replace nested",
+			`{"a":{"b":1}}`,
+			`[{"op":"replace","path":"/a/b","value":"x"}]`,
+			`{"a":{"b":"x"}}`,
+		},
+		{
+			"move",
+			`{"a":{"b":1},"c":{}}`,
+			`[{"op":"move","from":"/a/b","path":"/c/d"}]`,
+			`{"a":{},"c":{"d":1}}`,
+		},
+		{"move to itself", `{"a":1}`, `[{"op":"move","from":"/a","path":"/a"}]`, `{"a":1}`},
+		{
+			"copy is independent",
+			`{"a":{"b":1}}`,
+			`[{"op":"copy","from":"/a","path":"/c"},{"op":"replace","path":"/c/b","value":2}]`,
+			`{"a":{"b":1},"c":{"b":2}}`,
+		},
+		{"test number", `{"a":1.0}`, `[{"op":"test","path":"/a","value":1}]`, `{"a":1.0}`},
+		{
+			"test object",
+			`{"a":{"b":[1,"x"]}}`,
+			`[{"op":"test","path":"/a","value":{"b":[1,"x"]}}]`,
+			`{"a":{"b":[1,"x"]}}`,
+		},
+		{
+			"escaped pointer",
+			`{"a/b":1,"c~d":2}`,
+			`[{"op":"remove","path":"/a~1b"},{"op":"remove","path":"/c~0d"}]`,
+			`{}`,
+		},
+		{"no operations", `{"a":1}`, `[]`, `{"a":1}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Apply([]byte(tt.doc), []byte(tt.patch))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !jsonEqual(t, got, tt.want) {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		doc   string
+		patch string
+		want  error
+	}{
+		{"not an array", `{}`, `{"op":"add"}`, ErrInvalid},
+		{"unknown op", `{}`, `[{"op":"x","path":"/a"}]`, ErrInvalid},
+		{"missing value", `{}`, `[{"op":"add","path":"/a"}]`, ErrInvalid},
+		{"invalid pointer", `{}`, `[{"op":"add","path":"a","value":1}]`, ErrInvalid},
+		{"missing parent", `{}`, `[{"op":"add","path":"/a/b","value":1}]`, ErrInvalid},
+		{"remove missing", `{}`, `[{"op":"remove","path":"/a"}]`, ErrInvalid},
+		{"replace missing", `{}`, `[{"op":"replace","path":"/a","value":1}]`, ErrInvalid},
+		{"index out of range", `[1]`, `[{"op":"add","path":"/2","value":1}]`, ErrInvalid},
+		{"leading zero index", `[1,2]`, `[{"op":"remove","path":"/01"}]`, ErrInvalid},
+		{"move into itself", `{"a":{}}`, `[{"op":"move","from":"/a","path":"/a/b"}]`, ErrInvalid},
+		{"move missing", `{}`, `[{"op":"move","from":"/a","path":"/b"}]`, ErrInvalid},
+		{"test failed", `{"a":1}`, `[{"op":"test","path":"/a","value":2}]`, ErrTest},
+		{"test type", `{"a":1}`, `[{"op":"test","path":"/a","value":"1"}]`, ErrTest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Apply([]byte(tt.doc), []byte(tt.patch)); !errors.Is(err, tt.want) {
+				t.Errorf("got error %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package probe
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+)
+
+// DefaultTimeout is the default hard deadline for a dependency to become available
+const DefaultTimeout = 30 * time.Second
+
+// Func probes a dependency, returns nil when the dependency is available
+type Func func(ctx context.Context) error
+
+// TCP returns a probe that connects to a TCP address, for example "db:5432"
+func TCP(addr string) Func {
+	return func(ctx context.Context) error {
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", addr)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+}
+
+// HTTP returns a probe that sends a GET request to a URL, for example a health endpoint,
+// responses with a status under 400 are available
+func HTTP(url string) Func {
+	return func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		res.Body.Close()
+		if res.StatusCode >= http.StatusBadRequest {
+			return fmt.Errorf("unexpected status %d", res.StatusCode)
+		}
+		return nil
+	}
+}
+
+// Options are the probe retry options
+type Options struct {
+	// AttemptTimeout is the timeout of a single attempt, default 5s
+	AttemptTimeout time.Duration
+
+	// Backoff is the delay after the first failed attempt, it doubles after each following
+	// failed attempt, default 250ms
+	Backoff time.Duration
+
+	// MaxBackoff is the max delay between attempts, default 5s
+	MaxBackoff time.Duration
+
+	// Timeout is the hard deadline for the dependency to become available, default
+	// DefaultTimeout
+	Timeout time.Duration
+}
+
+// Wait runs a probe until it succeeds, retrying with backoff, each attempt is logged, returns
+// the last probe error when the timeout is reached or ctx is done
+func Wait(ctx context.Context, name string, fn Func, opts Options) error {
+	if opts.AttemptTimeout <= 0 {
+		opts.AttemptTimeout = 5 * time.Second
+	}
+	if opts.Backoff <= 0 {
+		opts.Backoff = 250 * time.Millisecond
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = 5 * time.Second
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	delay := opts.Backoff
+	for attempt := 1; ; attempt++ {
+		actx, acancel := context.WithTimeout(ctx, opts.AttemptTimeout)
+		err := fn(actx)
+		acancel()
+		if err == nil {
+			slog.Info("dependency available", "name", name, "attempt", attempt)
+			return nil
+		}
+		slog.Warn("dependency unavailable", "name", name, "attempt", attempt, "err", err)
+
+		t := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return fmt.Errorf("dependency %s unavailable after %d attempts: %w", name, attempt, err)
+		case <-t.C:
+		}
+		delay = min(delay*2, opts.MaxBackoff)
+	}
+}
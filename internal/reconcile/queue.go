@@ -0,0 +1,99 @@
+package reconcile
+
+import (
+	"sync"
+	"time"
+)
+
+// queue is a work queue of resource keys, a key is queued at most once and a key added while
+// it is processed is queued again when processing is done, so a key is never reconciled
+// concurrently
+type queue struct {
+	cond       *sync.Cond
+	dirty      map[string]bool
+	items      []string
+	mu         sync.Mutex
+	processing map[string]bool
+	shutdown   bool
+}
+
+// newQueue creates a new queue
+func newQueue() *queue {
+	q := &queue{
+		dirty:      map[string]bool{},
+		processing: map[string]bool{},
+	}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+// add adds a key to the queue
+func (q *queue) add(key string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.shutdown || q.dirty[key] {
+		return
+	}
+	q.dirty[key] = true
+	if q.processing[key] {
+		return
+	}
+	q.items = append(q.items, key)
+	q.cond.Signal()
+}
+
+// addAfter adds a key to the queue after a delay
+func (q *queue) addAfter(key string, d time.Duration) {
+	if d <= 0 {
+		q.add(key)
+		return
+	}
+	time.AfterFunc(d, func() {
+		q.add(key)
+	})
+}
+
+// get blocks until a key is available and marks it as processing, returns false when the
+// queue is shut down
+func (q *queue) get() (string, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for len(q.items) == 0 && !q.shutdown {
+		q.cond.Wait()
+	}
+	if q.shutdown {
+		return "", false
+	}
+
+	key := q.items[0]
+	q.items = q.items[1:]
+	q.processing[key] = true
+	delete(q.dirty, key)
+	return key, true
+}
+
+// done marks a key as processed, the key is queued again if it was added while processed
+func (q *queue) done(key string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.processing, key)
+	if q.dirty[key] && !q.shutdown {
+		q.items = append(q.items, key)
+		q.cond.Signal()
+	}
+}
+
+// len returns the number of queued keys
+func (q *queue) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.items)
+}
+
+// stop shuts down the queue, waiting get calls return false
+func (q *queue) stop() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.shutdown = true
+	q.cond.Broadcast()
+}
@@ -0,0 +1,194 @@
+package reconcile
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/shayanderson/go-project/internal/work"
+)
+
+// Result is the result of a reconcile
+type Result struct {
+	// RequeueAfter reconciles the resource again after the duration, for example to poll an
+	// external resource until it is ready, 0 does not requeue
+	RequeueAfter time.Duration
+}
+
+// Reconciler reconciles the actual state of a resource with its desired state, it must be
+// idempotent, a returned error or panic requeues the resource with backoff
+type Reconciler interface {
+	Reconcile(ctx context.Context, key string) (Result, error)
+}
+
+// Func is a Reconciler function
+type Func func(ctx context.Context, key string) (Result, error)
+
+// Reconcile implements the Reconciler interface
+func (f Func) Reconcile(ctx context.Context, key string) (Result, error) {
+	return f(ctx, key)
+}
+
+// Options are the controller options
+type Options struct {
+	// Backoff is the requeue delay after the first failure of a resource, it doubles after
+	// each following failure, default 500ms
+	Backoff time.Duration
+
+	// List returns the keys of all resources, used for resync
+	List func(ctx context.Context) ([]string, error)
+
+	// MaxBackoff is the max requeue delay after failures, default 5m
+	MaxBackoff time.Duration
+
+	// Resync is the interval all resources from List are queued, so drift in the actual state
+	// is corrected without events, 0 is no resync
+	Resync time.Duration
+
+	// Workers is the number of resources reconciled concurrently, default 1
+	Workers int
+}
+
+// Controller queues resource keys and reconciles them with a Reconciler, a resource key is
+// never reconciled concurrently and failed resources are requeued with exponential backoff
+type Controller struct {
+	failures map[string]int
+	mu       sync.Mutex
+	name     string
+	opts     Options
+	queue    *queue
+	r        Reconciler
+}
+
+// New creates a new Controller
+func New(name string, r Reconciler, opts Options) *Controller {
+	if opts.Backoff <= 0 {
+		opts.Backoff = 500 * time.Millisecond
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = 5 * time.Minute
+	}
+	opts.Workers = max(opts.Workers, 1)
+
+	return &Controller{
+		failures: map[string]int{},
+		name:     name,
+		opts:     opts,
+		queue:    newQueue(),
+		r:        r,
+	}
+}
+
+// Enqueue queues a resource for reconciliation, for example when its desired state changes
+func (c *Controller) Enqueue(key string) {
+	c.queue.add(key)
+}
+
+// EnqueueAfter queues a resource for reconciliation after a delay
+func (c *Controller) EnqueueAfter(key string, d time.Duration) {
+	c.queue.addAfter(key, d)
+}
+
+// Len returns the number of queued resources
+func (c *Controller) Len() int {
+	return c.queue.len()
+}
+
+// Run runs the workers until ctx is done, resources being reconciled when ctx is done finish
+// before Run returns
+func (c *Controller) Run(ctx context.Context) error {
+	var wg sync.WaitGroup
+	for range c.opts.Workers {
+		wg.Add(1)
+		work.Go(ctx, func(ctx context.Context) {
+			defer wg.Done()
+			for {
+				key, ok := c.queue.get()
+				if !ok {
+					return
+				}
+				c.process(ctx, key)
+			}
+		})
+	}
+
+	if c.opts.List != nil && c.opts.Resync > 0 {
+		c.resync(ctx)
+		t := time.NewTicker(c.opts.Resync)
+		defer t.Stop()
+	loop:
+		for {
+			select {
+			case <-ctx.Done():
+				break loop
+			case <-t.C:
+				c.resync(ctx)
+			}
+		}
+	} else {
+		<-ctx.Done()
+	}
+
+	c.queue.stop()
+	wg.Wait()
+	return nil
+}
+
+// process reconciles a resource and requeues it on failure or when requested
+func (c *Controller) process(ctx context.Context, key string) {
+	defer c.queue.done(key)
+
+	var res Result
+	err := work.Run(ctx, func(ctx context.Context) error {
+		var err error
+		res, err = c.r.Reconcile(ctx, key)
+		return err
+	})
+
+	c.mu.Lock()
+	if err != nil {
+		c.failures[key]++
+		n := c.failures[key]
+		c.mu.Unlock()
+
+		d := c.backoff(n)
+		slog.Warn(
+			"[reconcile] reconcile failed",
+			"controller", c.name,
+			"key", key,
+			"err", err,
+			"failures", n,
+			"retry", d.String(),
+		)
+		c.queue.addAfter(key, d)
+		return
+	}
+	delete(c.failures, key)
+	c.mu.Unlock()
+
+	if res.RequeueAfter > 0 {
+		c.queue.addAfter(key, res.RequeueAfter)
+	}
+}
+
+// backoff returns the requeue delay after n failures
+func (c *Controller) backoff(n int) time.Duration {
+	d := c.opts.Backoff
+	for i := 1; i < n && d < c.opts.MaxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, c.opts.MaxBackoff)
+}
+
+// resync queues all resources from List
+func (c *Controller) resync(ctx context.Context) {
+	keys, err := c.opts.List(ctx)
+	if err != nil {
+		slog.Warn("[reconcile] resync failed", "controller", c.name, "err", err)
+		return
+	}
+	for _, k := range keys {
+		c.queue.add(k)
+	}
+}
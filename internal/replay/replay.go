@@ -0,0 +1,356 @@
+package replay
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// redacted replaces redacted header and query values
+const redacted = "[REDACTED]"
+
+// DefaultRedactHeaders are the headers redacted by default
+var DefaultRedactHeaders = []string{
+	"Authorization",
+	"Cookie",
+	"Proxy-Authorization",
+	"Set-Cookie",
+	"X-Api-Key",
+}
+
+// DefaultRedactFields are the JSON body fields redacted by default
+var DefaultRedactFields = []string{
+	"access_token",
+	"api_key",
+	"client_secret",
+	"password",
+	"refresh_token",
+	"secret",
+	"token",
+}
+
+// DefaultRedactQuery are the query parameters redacted by default
+var DefaultRedactQuery = []string{
+	"access_token",
+	"api_key",
+	"apikey",
+	"code",
+	"key",
+	"password",
+	"secret",
+	"signature",
+	"token",
+}
+
+// Record is a captured request
+type Record struct {
+	// Body is the request body with sensitive JSON and form fields redacted, truncated to the
+	// capture max body size, only set when body capture is enabled
+	Body []byte `json:"body,omitempty"`
+
+	// Header is the request header with sensitive values redacted
+	Header http.Header `json:"header"`
+
+	// Method is the request method
+	Method string `json:"method"`
+
+	// Time is the time the request was received
+	Time time.Time `json:"time"`
+
+	// Truncated is true when the body was truncated
+	Truncated bool `json:"truncated,omitempty"`
+
+	// URI is the request URI with sensitive query values redacted
+	URI string `json:"uri"`
+}
+
+// CaptureOptions are the Recorder options
+type CaptureOptions struct {
+	// Body enables request body capture, disabled by default since bodies carry credentials,
+	// JSON and form bodies are captured with the redacted fields replaced, JSON bodies that
+	// cannot be redacted because they are truncated or invalid are not captured, other bodies
+	// are captured as is
+	Body bool
+
+	// MaxBody is the max number of body bytes captured, defaults to 64 KiB
+	MaxBody int64
+
+	// RedactFields are the JSON body fields, at any depth, and form body fields redacted,
+	// matched ignoring case, defaults to DefaultRedactFields
+	RedactFields []string
+
+	// RedactHeaders are the headers redacted, defaults to DefaultRedactHeaders
+	RedactHeaders []string
+
+	// RedactQuery are the query parameters redacted, defaults to DefaultRedactQuery
+	RedactQuery []string
+}
+
+// Recorder captures requests as NDJSON records
+type Recorder struct {
+	enc    *json.Encoder
+	fields map[string]bool
+	mu     sync.Mutex
+	opts   CaptureOptions
+}
+
+// NewRecorder creates a new Recorder that writes records to w
+func NewRecorder(w io.Writer, opts CaptureOptions) *Recorder {
+	if opts.MaxBody <= 0 {
+		opts.MaxBody = 64 << 10
+	}
+	if opts.RedactFields == nil {
+		opts.RedactFields = DefaultRedactFields
+	}
+	if opts.RedactHeaders == nil {
+		opts.RedactHeaders = DefaultRedactHeaders
+	}
+	if opts.RedactQuery == nil {
+		opts.RedactQuery = DefaultRedactQuery
+	}
+	fields := make(map[string]bool, len(opts.RedactFields))
+	for _, f := range opts.RedactFields {
+		fields[strings.ToLower(f)] = true
+	}
+	return &Recorder{
+		enc:    json.NewEncoder(w),
+		fields: fields,
+		opts:   opts,
+	}
+}
+
+// Middleware returns middleware that captures requests, the request body is restored so
+// handlers read it unchanged
+func (rc *Recorder) Middleware(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		rec := Record{
+			Header: r.Header.Clone(),
+			Method: r.Method,
+			Time:   time.Now(),
+			URI:    rc.redactURI(r.URL),
+		}
+		for _, h := range rc.opts.RedactHeaders {
+			if rec.Header.Get(h) != "" {
+				rec.Header.Set(h, redacted)
+			}
+		}
+
+		if rc.opts.Body && r.Body != nil && r.Body != http.NoBody {
+			buf := &bytes.Buffer{}
+			n, err := io.CopyN(buf, r.Body, rc.opts.MaxBody+1)
+			if err != nil && err != io.EOF {
+				slog.Error("replay capture body read failed", "err", err)
+			}
+			body := buf.Bytes()
+			if n > rc.opts.MaxBody {
+				body = body[:rc.opts.MaxBody]
+				rec.Truncated = true
+			}
+			rec.Body = rc.redactBody(r.Header.Get("Content-Type"), body, rec.Truncated)
+			r.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.MultiReader(bytes.NewReader(buf.Bytes()), r.Body), r.Body}
+		}
+
+		rc.mu.Lock()
+		err := rc.enc.Encode(rec)
+		rc.mu.Unlock()
+		if err != nil {
+			slog.Error("replay capture write failed", "err", err)
+		}
+
+		next.ServeHTTP(w, r)
+	}
+
+	return http.HandlerFunc(fn)
+}
+
+// redactBody returns a copy of the body with the redacted fields replaced, JSON bodies that
+// cannot be redacted return nil
+func (rc *Recorder) redactBody(contentType string, body []byte, truncated bool) []byte {
+	ct, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case ct == "application/json" || strings.HasSuffix(ct, "+json"):
+		if truncated {
+			return nil
+		}
+		dec := json.NewDecoder(bytes.NewReader(body))
+		dec.UseNumber()
+		var v any
+		if err := dec.Decode(&v); err != nil {
+			return nil
+		}
+		b, err := json.Marshal(rc.redactJSON(v))
+		if err != nil {
+			return nil
+		}
+		return b
+	case ct == "application/x-www-form-urlencoded":
+		q, err := url.ParseQuery(string(body))
+		if err != nil {
+			return nil
+		}
+		for k := range q {
+			if rc.fields[strings.ToLower(k)] {
+				q[k] = []string{redacted}
+			}
+		}
+		return []byte(q.Encode())
+	}
+	return bytes.Clone(body)
+}
+
+// redactJSON replaces the values of redacted fields in a decoded JSON value
+func (rc *Recorder) redactJSON(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, e := range v {
+			if rc.fields[strings.ToLower(k)] {
+				v[k] = redacted
+				continue
+			}
+			v[k] = rc.redactJSON(e)
+		}
+	case []any:
+		for i, e := range v {
+			v[i] = rc.redactJSON(e)
+		}
+	}
+	return v
+}
+
+// redactURI returns the request URI with the redacted query parameters replaced
+func (rc *Recorder) redactURI(u *url.URL) string {
+	if len(rc.opts.RedactQuery) == 0 || u.RawQuery == "" {
+		return u.RequestURI()
+	}
+	q := u.Query()
+	for k := range q {
+		for _, r := range rc.opts.RedactQuery {
+			if strings.EqualFold(k, r) {
+				q[k] = []string{redacted}
+			}
+		}
+	}
+	c := *u
+	c.RawQuery = q.Encode()
+	return c.RequestURI()
+}
+
+// Result is the summary of a replay
+type Result struct {
+	// Failed is the number of requests that failed to send
+	Failed int `json:"failed"`
+
+	// Sent is the number of requests sent
+	Sent int `json:"sent"`
+
+	// Skipped is the number of records skipped because the body was truncated
+	Skipped int `json:"skipped"`
+
+	// Statuses are the response counts by status code
+	Statuses map[int]int `json:"statuses"`
+}
+
+// Replayer sends captured requests to a target
+type Replayer struct {
+	// Client is the HTTP client, defaults to a client with a 30 second timeout
+	Client *http.Client
+
+	// Rate is the max number of requests sent per second, 0 is no limit
+	Rate float64
+
+	// Target is the base URL requests are sent to, for example "http://localhost:8080"
+	Target string
+}
+
+// Replay sends the NDJSON records read from r sequentially until the reader is exhausted or
+// the context is done, redacted headers are not sent
+func (p *Replayer) Replay(ctx context.Context, r io.Reader) (Result, error) {
+	client := p.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	var tick <-chan time.Time
+	if p.Rate > 0 {
+		t := time.NewTicker(time.Duration(float64(time.Second) / p.Rate))
+		defer t.Stop()
+		tick = t.C
+	}
+
+	res := Result{Statuses: map[int]int{}}
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64<<10), 16<<20)
+	for sc.Scan() {
+		var rec Record
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			return res, fmt.Errorf("replay: decode record: %w", err)
+		}
+		if rec.Truncated {
+			res.Skipped++
+			continue
+		}
+
+		if tick != nil {
+			select {
+			case <-ctx.Done():
+				return res, ctx.Err()
+			case <-tick:
+			}
+		}
+
+		status, err := p.send(ctx, client, rec)
+		if err != nil {
+			if ctx.Err() != nil {
+				return res, ctx.Err()
+			}
+			slog.Warn("replay request failed", "method", rec.Method, "uri", rec.URI, "err", err)
+			res.Failed++
+			continue
+		}
+		res.Sent++
+		res.Statuses[status]++
+	}
+	if err := sc.Err(); err != nil {
+		return res, fmt.Errorf("replay: read records: %w", err)
+	}
+	return res, nil
+}
+
+// send sends a record and returns the response status
+func (p *Replayer) send(ctx context.Context, client *http.Client, rec Record) (int, error) {
+	req, err := http.NewRequestWithContext(
+		ctx,
+		rec.Method,
+		strings.TrimSuffix(p.Target, "/")+rec.URI,
+		bytes.NewReader(rec.Body),
+	)
+	if err != nil {
+		return 0, err
+	}
+	for k, v := range rec.Header {
+		if len(v) == 1 && v[0] == redacted {
+			continue
+		}
+		req.Header[k] = v
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,136 @@
+package replay
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRecorder(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   CaptureOptions
+		uri    string
+		ct     string
+		body   string
+		want   Record
+		header string
+	}{
+		{
+			name: "body not captured by default",
+			uri:  "/login?token=abc&page=2",
+			ct:   "application/json",
+			body: `{"password":"p"}`,
+			want: Record{URI: "/login?page=2&token=%5BREDACTED%5D"},
+		},
+		{
+			name: "json fields redacted",
+			opts: CaptureOptions{Body: true},
+			uri:  "/login",
+			ct:   "application/json; charset=utf-8",
+			body: `{"user":"u","Password":"p","nested":[{"api_key":"k","n":1.50}]}`,
+			want: Record{
+				Body: []byte(
+					`{"Password":"[REDACTED]","nested":[{"api_key":"[REDACTED]","n":1.50}],"user":"u"}`,
+				),
+				URI: "/login",
+			},
+		},
+		{
+			name: "form fields redacted",
+			opts: CaptureOptions{Body: true},
+			uri:  "/login",
+			ct:   "application/x-www-form-urlencoded",
+			body: "user=u&password=p",
+			want: Record{Body: []byte("password=%5BREDACTED%5D&user=u"), URI: "/login"},
+		},
+		{
+			name: "truncated json not captured",
+			opts: CaptureOptions{Body: true, MaxBody: 5},
+			uri:  "/login",
+			ct:   "application/json",
+			body: `{"password":"p"}`,
+			want: Record{Truncated: true, URI: "/login"},
+		},
+		{
+			name: "invalid json not captured",
+			opts: CaptureOptions{Body: true},
+			uri:  "/login",
+			ct:   "application/json",
+			body: `{"password":`,
+			want: Record{URI: "/login"},
+		},
+		{
+			name: "other body captured",
+			opts: CaptureOptions{Body: true},
+			uri:  "/upload",
+			ct:   "text/plain",
+			body: "hello",
+			want: Record{Body: []byte("hello"), URI: "/upload"},
+		},
+		{
+			name: "custom redaction",
+			opts: CaptureOptions{
+				Body:          true,
+				RedactFields:  []string{"pin"},
+				RedactHeaders: []string{"X-Pin"},
+				RedactQuery:   []string{"pin"},
+			},
+			uri:    "/login?PIN=1&token=abc",
+			ct:     "application/json",
+			body:   `{"pin":"1","password":"p"}`,
+			header: "X-Pin",
+			want: Record{
+				Body: []byte(`{"password":"p","pin":"[REDACTED]"}`),
+				URI:  "/login?PIN=%5BREDACTED%5D&token=abc",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			var got []byte
+			h := NewRecorder(&out, tt.opts).Middleware(http.HandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) {
+					got, _ = io.ReadAll(r.Body)
+				},
+			))
+			r := httptest.NewRequest(http.MethodPost, tt.uri, strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", tt.ct)
+			r.Header.Set("Authorization", "Bearer abc")
+			if tt.header != "" {
+				r.Header.Set(tt.header, "secret")
+			}
+			h.ServeHTTP(httptest.NewRecorder(), r)
+
+			if string(got) != tt.body {
+				t.Errorf("got handler body %q, want %q", got, tt.body)
+			}
+			var rec Record
+			if err := json.Unmarshal(out.Bytes(), &rec); err != nil {
+				t.Fatal(err)
+			}
+			if string(rec.Body) != string(tt.want.Body) {
+				t.Errorf("got body %s, want %s", rec.Body, tt.want.Body)
+			}
+			if rec.URI != tt.want.URI {
+				t.Errorf("got uri %q, want %q", rec.URI, tt.want.URI)
+			}
+			if rec.Truncated != tt.want.Truncated {
+				t.Errorf("got truncated %v, want %v", rec.Truncated, tt.want.Truncated)
+			}
+			header := "Authorization"
+			if tt.header != "" {
+				header = tt.header
+			}
+			if v := rec.Header.Get(header); v != redacted {
+				t.Errorf("got header %s %q, want %q", header, v, redacted)
+			}
+		})
+	}
+}
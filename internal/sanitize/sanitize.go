@@ -0,0 +1,161 @@
+package sanitize
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/shayanderson/go-project/server"
+)
+
+// tagName is the struct tag used by Struct
+const tagName = "sanitize"
+
+// ops are the sanitize operations available in struct tags, unicode normalization is not
+// built in since the standard library has no normalization tables, add it with Register
+var ops = map[string]func(string) string{
+	"html":  EscapeHTML,
+	"lower": strings.ToLower,
+	"space": CollapseSpace,
+	"strip": StripControl,
+	"trim":  strings.TrimSpace,
+	"upper": strings.ToUpper,
+}
+
+// Register adds a sanitize op available in struct tags, for example a "nfc" op using
+// norm.NFC.String of golang.org/x/text for unicode normalization, it must be called before
+// Struct or ReadJSON are used
+func Register(name string, fn func(string) string) {
+	ops[name] = fn
+}
+
+// CollapseSpace replaces runs of whitespace with a single space and trims the string
+func CollapseSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// EscapeHTML escapes the chars <, >, &, ' and "
+func EscapeHTML(s string) string {
+	return html.EscapeString(s)
+}
+
+// String applies the default sanitizing to a string: strips control chars, collapses
+// whitespace and trims
+func String(s string) string {
+	return CollapseSpace(StripControl(s))
+}
+
+// StripControl removes control and invalid UTF-8 chars, tabs and newlines are kept
+func StripControl(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == unicode.ReplacementChar || (unicode.IsControl(r) && r != '\t' && r != '\n') {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// Struct sanitizes the string fields of the struct pointer using the comma separated ops in
+// the field "sanitize" tag, ops are applied in order, for example:
+//
+//	Name string `json:"name" sanitize:"strip,trim,html"`
+//
+// available ops are html, lower, space, strip, trim, upper and the ops added with Register,
+// nested structs, string pointers and string slices are supported
+func Struct(v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return errors.New("sanitize: value must be a non-nil struct pointer")
+	}
+	return sanitizeStruct(rv.Elem())
+}
+
+// ReadJSON decodes a JSON request body into the struct pointer with server.JSONCodec and
+// sanitizes it
+func ReadJSON(r *http.Request, v any) error {
+	if err := server.JSONCodec.Decode(r.Body, v); err != nil {
+		return err
+	}
+	return Struct(v)
+}
+
+// sanitizeStruct sanitizes the fields of a struct value
+func sanitizeStruct(v reflect.Value) error {
+	t := v.Type()
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		fv := v.Field(i)
+		tag := f.Tag.Get(tagName)
+
+		if tag == "" {
+			if err := sanitizeNested(fv); err != nil {
+				return err
+			}
+			continue
+		}
+
+		var fns []func(string) string
+		for _, name := range strings.Split(tag, ",") {
+			fn, ok := ops[strings.TrimSpace(name)]
+			if !ok {
+				return fmt.Errorf("sanitize: unknown op %q on field %s", name, f.Name)
+			}
+			fns = append(fns, fn)
+		}
+		if err := apply(fv, fns); err != nil {
+			return fmt.Errorf("sanitize: field %s: %w", f.Name, err)
+		}
+	}
+	return nil
+}
+
+// sanitizeNested sanitizes a nested struct, struct pointer or slice of structs
+func sanitizeNested(v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Struct:
+		return sanitizeStruct(v)
+	case reflect.Pointer:
+		if !v.IsNil() && v.Elem().Kind() == reflect.Struct {
+			return sanitizeStruct(v.Elem())
+		}
+	case reflect.Slice:
+		for i := range v.Len() {
+			if err := sanitizeNested(v.Index(i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// apply applies the ops to a string, string pointer or string slice value
+func apply(v reflect.Value, fns []func(string) string) error {
+	switch {
+	case v.Kind() == reflect.String:
+		s := v.String()
+		for _, fn := range fns {
+			s = fn(s)
+		}
+		v.SetString(s)
+	case v.Kind() == reflect.Pointer && v.Type().Elem().Kind() == reflect.String:
+		if !v.IsNil() {
+			return apply(v.Elem(), fns)
+		}
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.String:
+		for i := range v.Len() {
+			if err := apply(v.Index(i), fns); err != nil {
+				return err
+			}
+		}
+	default:
+		return errors.New("tag only supported on string, *string and []string")
+	}
+	return nil
+}
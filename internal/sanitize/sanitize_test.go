@@ -0,0 +1,99 @@
+package sanitize
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type testAddress struct {
+	City string `json:"city" sanitize:"trim,upper"`
+}
+
+type testInput struct {
+	Address  testAddress    `json:"address"`
+	Bio      string         `json:"bio" sanitize:"strip,html"`
+	Emails   []string       `json:"emails" sanitize:"trim,lower"`
+	Homes    []*testAddress `json:"homes"`
+	Name     string         `json:"name" sanitize:"strip,space"`
+	Nickname *string        `json:"nickname" sanitize:"trim"`
+	Raw      string         `json:"raw"`
+}
+
+func TestReadJSON(t *testing.T) {
+	body := `{
+		"address": {"city": " paris "},
+		"bio": "<b>hi</b>\u0000",
+		"emails": [" A@Example.com "],
+		"homes": [{"city": "rome "}, null],
+		"name": "  Jane \u0007 \t Doe ",
+		"nickname": " jd ",
+		"raw": " <raw> "
+	}`
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	var got testInput
+	if err := ReadJSON(r, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	nickname := "jd"
+	want := testInput{
+		Address:  testAddress{City: "PARIS"},
+		Bio:      "&lt;b&gt;hi&lt;/b&gt;",
+		Emails:   []string{"a@example.com"},
+		Homes:    []*testAddress{{City: "ROME"}, nil},
+		Name:     "Jane Doe",
+		Nickname: &nickname,
+		Raw:      " <raw> ",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestStructErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		v    any
+	}{
+		{"not a pointer", testInput{}},
+		{"nil pointer", (*testInput)(nil)},
+		{"unknown op", &struct {
+			A string `sanitize:"nope"`
+		}{}},
+		{"unsupported type", &struct {
+			A int `sanitize:"trim"`
+		}{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Struct(tt.v); err == nil {
+				t.Error("got no error")
+			}
+		})
+	}
+}
+
+func TestRegister(t *testing.T) {
+	Register("reverse", func(s string) string {
+		r := []rune(s)
+		for i, j := 0, len(r)-1; i < j; i, j = i+1, j-1 {
+			r[i], r[j] = r[j], r[i]
+		}
+		return string(r)
+	})
+	t.Cleanup(func() { delete(ops, "reverse") })
+
+	v := struct {
+		A string `sanitize:"trim,reverse"`
+	}{A: " abc "}
+	if err := Struct(&v); err != nil {
+		t.Fatal(err)
+	}
+	if v.A != "cba" {
+		t.Errorf("got %q, want %q", v.A, "cba")
+	}
+}
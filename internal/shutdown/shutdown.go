@@ -0,0 +1,175 @@
+package shutdown
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/shayanderson/go-project/internal/work"
+)
+
+// Phase is a shutdown phase, phases run in order and hooks in the same phase run concurrently
+type Phase int
+
+const (
+	// PhaseStopTraffic stops accepting new traffic, for example failing readiness checks
+	PhaseStopTraffic Phase = iota
+	// PhaseDrain drains in-flight work, for example http.Server.Shutdown
+	PhaseDrain
+	// PhaseStopWorkers stops background workers and queues
+	PhaseStopWorkers
+	// PhaseCloseInfra closes infrastructure, for example database connections
+	PhaseCloseInfra
+)
+
+// phases are the phases in run order
+var phases = []Phase{PhaseStopTraffic, PhaseDrain, PhaseStopWorkers, PhaseCloseInfra}
+
+// String implements the fmt.Stringer interface
+func (p Phase) String() string {
+	switch p {
+	case PhaseStopTraffic:
+		return "stop-traffic"
+	case PhaseDrain:
+		return "drain"
+	case PhaseStopWorkers:
+		return "stop-workers"
+	case PhaseCloseInfra:
+		return "close-infra"
+	}
+	return fmt.Sprintf("phase(%d)", int(p))
+}
+
+// Func is a shutdown hook, the context is done when the phase timeout is reached
+type Func func(ctx context.Context) error
+
+// hook is a registered shutdown hook
+type hook struct {
+	fn   Func
+	name string
+}
+
+// Coordinator runs shutdown hooks in phases
+type Coordinator struct {
+	done     bool
+	hooks    map[Phase][]hook
+	mu       sync.Mutex
+	timeouts map[Phase]time.Duration
+}
+
+// DefaultTimeout is the default timeout of a phase
+const DefaultTimeout = 5 * time.Second
+
+// New creates a new Coordinator
+func New() *Coordinator {
+	return &Coordinator{
+		hooks:    map[Phase][]hook{},
+		timeouts: map[Phase]time.Duration{},
+	}
+}
+
+// Register registers a named hook for a phase
+func (c *Coordinator) Register(p Phase, name string, fn Func) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.hooks[p] = append(c.hooks[p], hook{fn: fn, name: name})
+}
+
+// SetTimeout sets the timeout of a phase, the default is DefaultTimeout
+func (c *Coordinator) SetTimeout(p Phase, d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.timeouts[p] = d
+}
+
+// Shutdown runs the hooks phase by phase and logs a summary, a phase starts when all hooks of
+// the previous phase returned or its timeout was reached, returns the joined hook errors,
+// calling Shutdown more than once is a no-op
+func (c *Coordinator) Shutdown(ctx context.Context) error {
+	c.mu.Lock()
+	if c.done {
+		c.mu.Unlock()
+		return nil
+	}
+	c.done = true
+	c.mu.Unlock()
+
+	start := time.Now()
+	var errs []error
+	for _, p := range phases {
+		c.mu.Lock()
+		hooks := c.hooks[p]
+		timeout, ok := c.timeouts[p]
+		c.mu.Unlock()
+		if len(hooks) == 0 {
+			continue
+		}
+		if !ok {
+			timeout = DefaultTimeout
+		}
+		errs = append(errs, c.run(ctx, p, timeout, hooks)...)
+	}
+
+	slog.Info(
+		"shutdown complete",
+		"took", time.Since(start).String(),
+		"errors", len(errs),
+	)
+	return errors.Join(errs...)
+}
+
+// run runs the hooks of a phase concurrently and returns their errors
+func (c *Coordinator) run(
+	ctx context.Context,
+	p Phase,
+	timeout time.Duration,
+	hooks []hook,
+) []error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var (
+		errs []error
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+	)
+	for _, h := range hooks {
+		wg.Add(1)
+		work.Go(ctx, func(ctx context.Context) {
+			defer wg.Done()
+			start := time.Now()
+			err := work.Run(ctx, h.fn)
+			attrs := []any{"phase", p.String(), "hook", h.name, "took", time.Since(start).String()}
+			if err != nil {
+				slog.Error("shutdown hook failed", append(attrs, "err", err)...)
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("shutdown %s %s: %w", p, h.name, err))
+				mu.Unlock()
+				return
+			}
+			slog.Info("shutdown hook done", attrs...)
+		})
+	}
+
+	done := make(chan struct{})
+	work.Go(ctx, func(context.Context) {
+		wg.Wait()
+		close(done)
+	})
+	select {
+	case <-done:
+	case <-ctx.Done():
+		// hooks that ignore the context are left running
+		slog.Error("shutdown phase timed out", "phase", p.String(), "timeout", timeout.String())
+		mu.Lock()
+		errs = append(errs, fmt.Errorf("shutdown %s: %w", p, ctx.Err()))
+		mu.Unlock()
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	return append([]error(nil), errs...)
+}
@@ -0,0 +1,53 @@
+package test
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// number is a numeric type compared by InDelta and InEpsilon
+type number interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 |
+		~float32 | ~float64
+}
+
+// InDelta checks that got is within delta of want, NaN is never within delta
+func InDelta[T number](t testing.TB, want, got T, delta float64) {
+	t.Helper()
+	d := math.Abs(float64(want) - float64(got))
+	if math.IsNaN(d) || d > delta {
+		t.Fatalf("got %v, want %v within %v, difference %v", got, want, delta, d)
+	}
+}
+
+// InEpsilon checks that the relative error between got and want is at most epsilon, for
+// example an epsilon of 0.01 allows got to differ from want by 1%, want must not be zero
+func InEpsilon[T number](t testing.TB, want, got T, epsilon float64) {
+	t.Helper()
+	if want == 0 {
+		t.Fatalf("want is 0, the relative error is undefined, use InDelta")
+		return
+	}
+	e := math.Abs(float64(want)-float64(got)) / math.Abs(float64(want))
+	if math.IsNaN(e) || e > epsilon {
+		t.Fatalf(
+			"got %v, want %v within relative error %v, relative error %v",
+			got,
+			want,
+			epsilon,
+			e,
+		)
+	}
+}
+
+// WithinDuration checks that got is within delta of want, for example to check a timestamp
+// set to the current time by the code under test
+func WithinDuration(t testing.TB, want, got time.Time, delta time.Duration) {
+	t.Helper()
+	d := got.Sub(want).Abs()
+	if d > delta {
+		t.Fatalf("got %v, want %v within %v, difference %v", got, want, delta, d)
+	}
+}
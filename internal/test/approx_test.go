@@ -0,0 +1,31 @@
+package test
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestApproxAssertions(t *testing.T) {
+	now := time.Now()
+
+	runAssertions(t, []assertion{
+		{"InDelta", func(t testing.TB) { InDelta(t, 1.0, 1.05, 0.1) }, false},
+		{"InDelta ints", func(t testing.TB) { InDelta(t, 10, 12, 2) }, false},
+		{"InDelta fails", func(t testing.TB) { InDelta(t, 1.0, 1.2, 0.1) }, true},
+		{"InDelta NaN", func(t testing.TB) { InDelta(t, math.NaN(), math.NaN(), 1) }, true},
+		{"InEpsilon", func(t testing.TB) { InEpsilon(t, 200.0, 201.0, 0.01) }, false},
+		{"InEpsilon negative", func(t testing.TB) { InEpsilon(t, -100, -101, 0.01) }, false},
+		{"InEpsilon fails", func(t testing.TB) { InEpsilon(t, 100.0, 102.0, 0.01) }, true},
+		{"InEpsilon zero", func(t testing.TB) { InEpsilon(t, 0.0, 0.0, 0.01) }, true},
+		{"WithinDuration", func(t testing.TB) {
+			WithinDuration(t, now, now.Add(-time.Second), time.Second)
+		}, false},
+		{"WithinDuration fails", func(t testing.TB) {
+			WithinDuration(t, now, now.Add(2*time.Second), time.Second)
+		}, true},
+		{"WithinDuration zones", func(t testing.TB) {
+			WithinDuration(t, now.UTC(), now.In(time.FixedZone("X", 3600)), 0)
+		}, false},
+	})
+}
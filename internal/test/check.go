@@ -0,0 +1,226 @@
+package test
+
+import (
+	"testing"
+	"time"
+)
+
+// Check returns a testing.TB reporting fatal failures with Error so the test continues, use it
+// for non fatal assertions that report every mismatch in one run, for example:
+//
+//	test.Equal(test.Check(t), want.Name, got.Name)
+//	test.Equal(test.Check(t), want.Email, got.Email)
+func Check(t testing.TB) testing.TB {
+	if s, ok := t.(soft); ok {
+		return s
+	}
+	return soft{t}
+}
+
+// soft is a testing.TB reporting fatal failures without stopping the test
+type soft struct {
+	testing.TB
+}
+
+func (s soft) FailNow() {
+	s.TB.Helper()
+	s.TB.Fail()
+}
+
+func (s soft) Fatal(args ...any) {
+	s.TB.Helper()
+	s.TB.Error(args...)
+}
+
+func (s soft) Fatalf(format string, args ...any) {
+	s.TB.Helper()
+	s.TB.Errorf(format, args...)
+}
+
+// Assertions calls the assertions with T so tests do not pass t to every call, fatal and non
+// fatal checks are mixed using Check, for example:
+//
+//	a := test.Assertions{T: t}
+//	a.NoError(err)
+//	a.Check().Equal("a", got.Name)
+//	a.Check().Equal(2, got.Count)
+//
+// generic assertions such as ElementsMatch are only funcs since methods cannot have type
+// parameters, call them with a.T
+type Assertions struct {
+	// T is the test the assertions report to
+	T testing.TB
+}
+
+// Check returns assertions that report failures and continue the test, see Check
+func (a Assertions) Check() Assertions {
+	return Assertions{T: Check(a.T)}
+}
+
+// Equal checks that got is deeply equal to want, see Equal
+func (a Assertions) Equal(want, got any) {
+	a.T.Helper()
+	Equal(a.T, want, got)
+}
+
+// NotEqual checks that got is not deeply equal to want
+func (a Assertions) NotEqual(want, got any) {
+	a.T.Helper()
+	NotEqual(a.T, want, got)
+}
+
+// True checks that v is true
+func (a Assertions) True(v bool) {
+	a.T.Helper()
+	True(a.T, v)
+}
+
+// False checks that v is false
+func (a Assertions) False(v bool) {
+	a.T.Helper()
+	False(a.T, v)
+}
+
+// Nil checks that v is nil, see Nil
+func (a Assertions) Nil(v any) {
+	a.T.Helper()
+	Nil(a.T, v)
+}
+
+// NotNil checks that v is not nil
+func (a Assertions) NotNil(v any) {
+	a.T.Helper()
+	NotNil(a.T, v)
+}
+
+// NoError checks that err is nil
+func (a Assertions) NoError(err error) {
+	a.T.Helper()
+	NoError(a.T, err)
+}
+
+// Error checks that err is not nil
+func (a Assertions) Error(err error) {
+	a.T.Helper()
+	Error(a.T, err)
+}
+
+// ErrorIs checks that err matches target using errors.Is
+func (a Assertions) ErrorIs(err, target error) {
+	a.T.Helper()
+	ErrorIs(a.T, err, target)
+}
+
+// Empty checks that v is empty, see Empty
+func (a Assertions) Empty(v any) {
+	a.T.Helper()
+	Empty(a.T, v)
+}
+
+// NotEmpty checks that v is not empty, see Empty
+func (a Assertions) NotEmpty(v any) {
+	a.T.Helper()
+	NotEmpty(a.T, v)
+}
+
+// Zero checks that v is the zero value of its type
+func (a Assertions) Zero(v any) {
+	a.T.Helper()
+	Zero(a.T, v)
+}
+
+// NotZero checks that v is not the zero value of its type
+func (a Assertions) NotZero(v any) {
+	a.T.Helper()
+	NotZero(a.T, v)
+}
+
+// InDelta checks that got is within delta of want
+func (a Assertions) InDelta(want, got, delta float64) {
+	a.T.Helper()
+	InDelta(a.T, want, got, delta)
+}
+
+// InEpsilon checks that the relative error between got and want is at most epsilon
+func (a Assertions) InEpsilon(want, got, epsilon float64) {
+	a.T.Helper()
+	InEpsilon(a.T, want, got, epsilon)
+}
+
+// WithinDuration checks that got is within delta of want
+func (a Assertions) WithinDuration(want, got time.Time, delta time.Duration) {
+	a.T.Helper()
+	WithinDuration(a.T, want, got, delta)
+}
+
+// Panics checks that fn panics and returns the recovered value
+func (a Assertions) Panics(fn func()) any {
+	a.T.Helper()
+	return Panics(a.T, fn)
+}
+
+// PanicsWithValue checks that fn panics with a value deeply equal to want
+func (a Assertions) PanicsWithValue(want any, fn func()) {
+	a.T.Helper()
+	PanicsWithValue(a.T, want, fn)
+}
+
+// PanicsWithError checks that fn panics with an error with the message msg
+func (a Assertions) PanicsWithError(msg string, fn func()) {
+	a.T.Helper()
+	PanicsWithError(a.T, msg, fn)
+}
+
+// PanicsWithMessage checks that fn panics with the message msg, see PanicsWithMessage
+func (a Assertions) PanicsWithMessage(msg string, fn func()) {
+	a.T.Helper()
+	PanicsWithMessage(a.T, msg, fn)
+}
+
+// NotPanics checks that fn does not panic
+func (a Assertions) NotPanics(fn func()) {
+	a.T.Helper()
+	NotPanics(a.T, fn)
+}
+
+// Contains checks that s contains substr
+func (a Assertions) Contains(s, substr string) {
+	a.T.Helper()
+	Contains(a.T, s, substr)
+}
+
+// Regexp checks that s matches the regular expression pattern
+func (a Assertions) Regexp(pattern, s string) {
+	a.T.Helper()
+	Regexp(a.T, pattern, s)
+}
+
+// HasPrefix checks that s starts with prefix
+func (a Assertions) HasPrefix(s, prefix string) {
+	a.T.Helper()
+	HasPrefix(a.T, s, prefix)
+}
+
+// HasSuffix checks that s ends with suffix
+func (a Assertions) HasSuffix(s, suffix string) {
+	a.T.Helper()
+	HasSuffix(a.T, s, suffix)
+}
+
+// EqualFold checks that got is equal to want ignoring case
+func (a Assertions) EqualFold(want, got string) {
+	a.T.Helper()
+	EqualFold(a.T, want, got)
+}
+
+// FileExists checks that a regular file exists at the path
+func (a Assertions) FileExists(path string) {
+	a.T.Helper()
+	FileExists(a.T, path)
+}
+
+// FileContains checks that the file at the path contains substr
+func (a Assertions) FileContains(path, substr string) {
+	a.T.Helper()
+	FileContains(a.T, path, substr)
+}
@@ -0,0 +1,94 @@
+package test
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name  string
+		fn    func(t testing.TB)
+		fatal bool
+	}{
+		{"fatal", func(t testing.TB) { Equal(t, 1, 2) }, true},
+		{"check", func(t testing.TB) { Equal(Check(t), 1, 2) }, false},
+		{"check twice", func(t testing.TB) { Equal(Check(Check(t)), 1, 2) }, false},
+		{"check FailNow", func(t testing.TB) { Check(t).FailNow() }, false},
+		{"check Fatal", func(t testing.TB) { Check(t).Fatal("x") }, false},
+		{"assertions", func(t testing.TB) { Assertions{T: t}.True(false) }, true},
+		{"assertions check", func(t testing.TB) { Assertions{T: t}.Check().True(false) }, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &recorder{TB: t}
+			tt.fn(r)
+			if !r.failed {
+				t.Error("got no failure, want failure")
+			}
+			if r.fatal != tt.fatal {
+				t.Errorf("got fatal %v, want %v", r.fatal, tt.fatal)
+			}
+		})
+	}
+}
+
+func TestCheckContinues(t *testing.T) {
+	r := &recorder{TB: t}
+	a := Assertions{T: r}.Check()
+	a.Equal("a", "b")
+	a.Equal(1, 1)
+	if !r.failed || r.msg != `got "b", want "a"` {
+		t.Errorf("got failed %v with %q, want first failure kept", r.failed, r.msg)
+	}
+	a.True(false)
+	if r.msg != "got false, want true" {
+		t.Errorf("got %q, want second failure reported", r.msg)
+	}
+}
+
+func TestAssertionsMethods(t *testing.T) {
+	type point struct{ X int }
+	errClosed := errors.New("closed")
+	now := time.Now()
+	path := TempFile(t, "hello world")
+
+	a := Assertions{T: t}
+	a.Equal(point{1}, point{1})
+	a.NotEqual(point{1}, point{2})
+	a.True(true)
+	a.False(false)
+	a.Nil(nil)
+	a.NotNil(&point{})
+	a.NoError(nil)
+	a.Error(errClosed)
+	a.ErrorIs(errClosed, errClosed)
+	a.Empty([]int{})
+	a.NotEmpty(map[string]int{"a": 1})
+	a.Zero(point{})
+	a.NotZero(point{1})
+	a.InDelta(1, 1.1, 0.2)
+	a.InEpsilon(100, 101, 0.01)
+	a.WithinDuration(now, now.Add(time.Millisecond), time.Second)
+	a.Equal("x", a.Panics(func() { panic("x") }))
+	a.PanicsWithValue(1, func() { panic(1) })
+	a.PanicsWithError("closed", func() { panic(errClosed) })
+	a.PanicsWithMessage("closed", func() { panic(errClosed) })
+	a.NotPanics(func() {})
+	a.Contains("hello", "ell")
+	a.Regexp(`^h`, "hello")
+	a.HasPrefix("hello", "he")
+	a.HasSuffix("hello", "lo")
+	a.EqualFold("Hello", "hELLO")
+	a.FileExists(path)
+	a.FileContains(path, "world")
+	ElementsMatch(a.T, []int{1, 2}, []int{2, 1})
+
+	r := &recorder{TB: t}
+	Assertions{T: r}.Zero(point{1})
+	if !r.failed {
+		t.Error("got no failure for a non zero struct, want failure")
+	}
+}
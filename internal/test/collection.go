@@ -0,0 +1,89 @@
+package test
+
+import (
+	"cmp"
+	"testing"
+)
+
+// ElementsMatch checks that got has the same elements as want in any order, duplicates are
+// counted so []int{1, 1, 2} does not match []int{1, 2, 2}
+func ElementsMatch[T comparable](t testing.TB, want, got []T) {
+	t.Helper()
+	missing, extra := difference(want, got)
+	if len(missing) > 0 || len(extra) > 0 {
+		t.Fatalf("got %v, want %v in any order, missing %v, extra %v", got, want, missing, extra)
+	}
+}
+
+// Subset checks that every element of subset is in set, duplicates are counted
+func Subset[T comparable](t testing.TB, set, subset []T) {
+	t.Helper()
+	if missing, _ := difference(subset, set); len(missing) > 0 {
+		t.Fatalf("got %v, want a superset of %v, missing %v", set, subset, missing)
+	}
+}
+
+// Superset checks that superset has every element of set, duplicates are counted
+func Superset[T comparable](t testing.TB, set, superset []T) {
+	t.Helper()
+	if missing, _ := difference(set, superset); len(missing) > 0 {
+		t.Fatalf("got %v, want a superset of %v, missing %v", superset, set, missing)
+	}
+}
+
+// IsSorted checks that s is sorted in ascending order
+func IsSorted[T cmp.Ordered](t testing.TB, s []T) {
+	t.Helper()
+	IsSortedFunc(t, s, cmp.Compare[T])
+}
+
+// IsSortedFunc checks that s is sorted in ascending order using the compare func, for example
+// to check list results sorted by a field
+func IsSortedFunc[T any](t testing.TB, s []T, compare func(a, b T) int) {
+	t.Helper()
+	for i := 1; i < len(s); i++ {
+		if compare(s[i-1], s[i]) > 0 {
+			t.Fatalf("got %v, want sorted, index %d is out of order", s, i)
+			return
+		}
+	}
+}
+
+// Unique checks that s has no duplicate elements
+func Unique[T comparable](t testing.TB, s []T) {
+	t.Helper()
+	seen := make(map[T]bool, len(s))
+	var dups []T
+	for _, v := range s {
+		if seen[v] {
+			dups = append(dups, v)
+		}
+		seen[v] = true
+	}
+	if len(dups) > 0 {
+		t.Fatalf("got %v, want unique elements, duplicates %v", s, dups)
+	}
+}
+
+// difference returns the elements of want that are not in got and the elements of got that
+// are not in want, duplicates are counted
+func difference[T comparable](want, got []T) (missing, extra []T) {
+	counts := make(map[T]int, len(got))
+	for _, v := range got {
+		counts[v]++
+	}
+	for _, v := range want {
+		if counts[v] == 0 {
+			missing = append(missing, v)
+			continue
+		}
+		counts[v]--
+	}
+	for _, v := range got {
+		if counts[v] > 0 {
+			extra = append(extra, v)
+			counts[v]--
+		}
+	}
+	return missing, extra
+}
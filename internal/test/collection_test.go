@@ -0,0 +1,40 @@
+package test
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCollectionAssertions(t *testing.T) {
+	byLen := func(a, b string) int { return len(a) - len(b) }
+
+	runAssertions(t, []assertion{
+		{"ElementsMatch", func(t testing.TB) { ElementsMatch(t, []int{1, 2, 2}, []int{2, 1, 2}) }, false},
+		{"ElementsMatch empty", func(t testing.TB) { ElementsMatch(t, nil, []int{}) }, false},
+		{"ElementsMatch duplicates", func(t testing.TB) {
+			ElementsMatch(t, []int{1, 1, 2}, []int{1, 2, 2})
+		}, true},
+		{"ElementsMatch missing", func(t testing.TB) { ElementsMatch(t, []int{1, 2}, []int{1}) }, true},
+		{"ElementsMatch extra", func(t testing.TB) { ElementsMatch(t, []int{1}, []int{1, 2}) }, true},
+		{"Subset", func(t testing.TB) { Subset(t, []string{"a", "b", "c"}, []string{"c", "a"}) }, false},
+		{"Subset fails", func(t testing.TB) { Subset(t, []string{"a", "b"}, []string{"a", "d"}) }, true},
+		{"Subset duplicates", func(t testing.TB) { Subset(t, []string{"a"}, []string{"a", "a"}) }, true},
+		{"Superset", func(t testing.TB) { Superset(t, []int{1}, []int{1, 2}) }, false},
+		{"Superset fails", func(t testing.TB) { Superset(t, []int{1, 3}, []int{1, 2}) }, true},
+		{"IsSorted", func(t testing.TB) { IsSorted(t, []int{1, 2, 2, 3}) }, false},
+		{"IsSorted empty", func(t testing.TB) { IsSorted(t, []string{}) }, false},
+		{"IsSorted fails", func(t testing.TB) { IsSorted(t, []int{1, 3, 2}) }, true},
+		{"IsSortedFunc", func(t testing.TB) { IsSortedFunc(t, []string{"b", "aa"}, byLen) }, false},
+		{"IsSortedFunc fails", func(t testing.TB) { IsSortedFunc(t, []string{"aa", "b"}, byLen) }, true},
+		{"Unique", func(t testing.TB) { Unique(t, []int{1, 2, 3}) }, false},
+		{"Unique fails", func(t testing.TB) { Unique(t, []int{1, 2, 1}) }, true},
+	})
+}
+
+func TestElementsMatchMessage(t *testing.T) {
+	r := &recorder{TB: t}
+	ElementsMatch(r, []int{1, 2}, []int{2, 3})
+	if !strings.Contains(r.msg, "missing [1], extra [3]") {
+		t.Errorf("got %q, want missing and extra elements", r.msg)
+	}
+}
@@ -0,0 +1,81 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/shayanderson/go-project/internal/work"
+)
+
+// Concurrently runs fn in n goroutines started at the same time and waits for them, use it with
+// the race detector to stress code shared by goroutines, fn receives a non fatal testing.TB
+// since FailNow must not be called outside the test goroutine (see Check), the goroutine index
+// and a random generator seeded from the test name and the index, for example:
+//
+//	test.Concurrently(t, 8, func(t testing.TB, i int, r *rand.Rand) {
+//		k := strconv.Itoa(r.IntN(10))
+//		c.Set(k, i)
+//		c.Get(k)
+//	})
+//
+// a panic in fn fails the test
+func Concurrently(t testing.TB, n int, fn func(t testing.TB, i int, r *rand.Rand)) {
+	t.Helper()
+	parallel(t, n, func(t testing.TB, i int) {
+		fn(t, i, newRand(t, uint64(i)+1))
+	})
+}
+
+// RepeatParallel runs fn n times using workers goroutines started at the same time and waits
+// for them, fn receives a non fatal testing.TB (see Concurrently), the iteration index and a
+// random generator seeded from the test name and the iteration index so the values of an
+// iteration do not depend on the goroutine running it, a panic in fn fails the test and stops
+// the goroutine
+func RepeatParallel(
+	t testing.TB,
+	n int,
+	workers int,
+	fn func(t testing.TB, i int, r *rand.Rand),
+) {
+	t.Helper()
+	var next atomic.Int64
+	parallel(t, workers, func(t testing.TB, _ int) {
+		for {
+			i := int(next.Add(1)) - 1
+			if i >= n {
+				return
+			}
+			fn(t, i, newRand(t, uint64(i)+1))
+		}
+	})
+}
+
+// parallel runs fn in n goroutines started at the same time with a non fatal testing.TB and
+// waits for them, a panic is reported as a test failure
+func parallel(t testing.TB, n int, fn func(t testing.TB, i int)) {
+	t.Helper()
+	st := Check(t)
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := range n {
+		work.Go(context.Background(), func(ctx context.Context) {
+			defer wg.Done()
+			<-start
+			err := work.Run(ctx, func(context.Context) error {
+				fn(st, i)
+				return nil
+			})
+			var p *work.PanicError
+			if errors.As(err, &p) {
+				st.Errorf("goroutine %d panicked: %v\n%s", i, p.Value, p.Stack)
+			}
+		})
+	}
+	close(start)
+	wg.Wait()
+}
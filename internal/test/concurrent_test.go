@@ -0,0 +1,64 @@
+package test
+
+import (
+	"log/slog"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestConcurrently(t *testing.T) {
+	var mu sync.Mutex
+	seen := map[int]bool{}
+	Concurrently(t, 8, func(t testing.TB, i int, r *rand.Rand) {
+		mu.Lock()
+		defer mu.Unlock()
+		seen[i] = true
+	})
+	Equal(t, 8, len(seen))
+}
+
+func TestRepeatParallel(t *testing.T) {
+	var count atomic.Int64
+	values := make([]uint64, 100)
+	RepeatParallel(t, 100, 4, func(t testing.TB, i int, r *rand.Rand) {
+		count.Add(1)
+		values[i] = r.Uint64()
+	})
+	Equal(t, int64(100), count.Load())
+
+	again := make([]uint64, 100)
+	RepeatParallel(t, 100, 7, func(t testing.TB, i int, r *rand.Rand) {
+		again[i] = r.Uint64()
+	})
+	Equal(t, values, again)
+	Unique(t, values)
+}
+
+func TestConcurrentlyFailures(t *testing.T) {
+	logs := CaptureLogs(t)
+
+	tests := []struct {
+		name  string
+		fn    func(t testing.TB, i int, r *rand.Rand)
+		panic bool
+	}{
+		{"fatal assertion", func(t testing.TB, i int, r *rand.Rand) { Equal(t, 0, 1) }, false},
+		{"panic", func(t testing.TB, i int, r *rand.Rand) { panic("boom") }, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs.Reset()
+			r := &recorder{TB: t}
+			Concurrently(r, 1, tt.fn)
+			if !r.failed || r.fatal {
+				t.Errorf("got failed %v fatal %v, want non fatal failure", r.failed, r.fatal)
+			}
+			if got := logs.Contains(slog.LevelError, "panic"); got != tt.panic {
+				t.Errorf("got panic logged %v, want %v", got, tt.panic)
+			}
+		})
+	}
+}
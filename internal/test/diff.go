@@ -0,0 +1,199 @@
+package test
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// maxDiffs is the max number of differences reported by Equal
+const maxDiffs = 20
+
+// missing is the value shown for a missing slice element or map key
+const missing = "<missing>"
+
+// differ collects the differences between two values
+type differ struct {
+	lines   []string
+	more    bool
+	visited map[[2]uintptr]bool
+}
+
+// diff returns the differences between want and got, one line per difference with the path
+// of the field, element or key, for example `.Items[1].Name: got "a", want "b"`
+func diff(want, got any) []string {
+	d := &differ{visited: map[[2]uintptr]bool{}}
+	d.diff("", reflect.ValueOf(want), reflect.ValueOf(got))
+	if d.more {
+		d.lines = append(d.lines, "...")
+	}
+	return d.lines
+}
+
+// add adds a difference
+func (d *differ) add(path, got, want string) {
+	if len(d.lines) >= maxDiffs {
+		d.more = true
+		return
+	}
+	if path == "" {
+		path = "value"
+	}
+	d.lines = append(d.lines, fmt.Sprintf("%s: got %s, want %s", path, got, want))
+}
+
+// diff adds the differences between want and got at the path
+func (d *differ) diff(path string, want, got reflect.Value) {
+	if !want.IsValid() || !got.IsValid() {
+		if want.IsValid() != got.IsValid() {
+			d.add(path, format(got), format(want))
+		}
+		return
+	}
+	if want.Type() != got.Type() {
+		d.add(path, "type "+got.Type().String(), "type "+want.Type().String())
+		return
+	}
+
+	switch want.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if want.IsNil() || got.IsNil() {
+			if want.IsNil() != got.IsNil() {
+				d.add(path, format(got), format(want))
+			}
+			return
+		}
+		if want.Kind() == reflect.Pointer {
+			k := [2]uintptr{want.Pointer(), got.Pointer()}
+			if k[0] == k[1] || d.visited[k] {
+				return
+			}
+			d.visited[k] = true
+		}
+		d.diff(path, want.Elem(), got.Elem())
+	case reflect.Struct:
+		if opaque(want) {
+			if !reflect.DeepEqual(want.Interface(), got.Interface()) {
+				d.add(path, format(got), format(want))
+			}
+			return
+		}
+		for i := range want.NumField() {
+			name := want.Type().Field(i).Name
+			d.diff(path+"."+name, want.Field(i), got.Field(i))
+		}
+	case reflect.Slice, reflect.Array:
+		if want.Kind() == reflect.Slice && want.IsNil() != got.IsNil() {
+			d.add(path, format(got), format(want))
+			return
+		}
+		for i := range max(want.Len(), got.Len()) {
+			p := fmt.Sprintf("%s[%d]", path, i)
+			switch {
+			case i >= got.Len():
+				d.add(p, missing, format(want.Index(i)))
+			case i >= want.Len():
+				d.add(p, format(got.Index(i)), missing)
+			default:
+				d.diff(p, want.Index(i), got.Index(i))
+			}
+		}
+	case reflect.Map:
+		if want.IsNil() != got.IsNil() {
+			d.add(path, format(got), format(want))
+			return
+		}
+		for _, k := range mapKeys(want, got) {
+			p := fmt.Sprintf("%s[%s]", path, format(k))
+			w, g := want.MapIndex(k), got.MapIndex(k)
+			switch {
+			case !g.IsValid():
+				d.add(p, missing, format(w))
+			case !w.IsValid():
+				d.add(p, format(g), missing)
+			default:
+				d.diff(p, w, g)
+			}
+		}
+	default:
+		if !leafEqual(want, got) {
+			d.add(path, format(got), format(want))
+		}
+	}
+}
+
+// opaque returns true for structs compared as a whole, structs without exported fields like
+// time.Time are shown with their String or fmt output instead of their internal fields
+func opaque(v reflect.Value) bool {
+	if !v.CanInterface() {
+		return false
+	}
+	for i := range v.NumField() {
+		if v.Type().Field(i).IsExported() {
+			return false
+		}
+	}
+	return true
+}
+
+// leafEqual returns true when the values of a non composite kind are equal, unexported
+// fields cannot be read with Interface so the values are compared by kind
+func leafEqual(want, got reflect.Value) bool {
+	switch want.Kind() {
+	case reflect.Bool:
+		return want.Bool() == got.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return want.Int() == got.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Uintptr:
+		return want.Uint() == got.Uint()
+	case reflect.Float32, reflect.Float64:
+		return want.Float() == got.Float()
+	case reflect.Complex64, reflect.Complex128:
+		return want.Complex() == got.Complex()
+	case reflect.String:
+		return want.String() == got.String()
+	case reflect.Chan, reflect.UnsafePointer:
+		return want.Pointer() == got.Pointer()
+	case reflect.Func:
+		// funcs are only deeply equal when both are nil
+		return want.IsNil() && got.IsNil()
+	}
+	return false
+}
+
+// mapKeys returns the keys of both maps sorted by their formatted value
+func mapKeys(a, b reflect.Value) []reflect.Value {
+	seen := map[string]bool{}
+	var keys []reflect.Value
+	for _, m := range []reflect.Value{a, b} {
+		for _, k := range m.MapKeys() {
+			s := format(k)
+			if !seen[s] {
+				seen[s] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return format(keys[i]) < format(keys[j])
+	})
+	return keys
+}
+
+// format formats a value for a difference, strings are quoted
+func format(v reflect.Value) string {
+	if !v.IsValid() {
+		return "nil"
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return fmt.Sprintf("%q", v.String())
+	case reflect.Chan, reflect.Func, reflect.Interface, reflect.Map, reflect.Pointer,
+		reflect.Slice:
+		if v.IsNil() {
+			return "nil"
+		}
+	}
+	return fmt.Sprintf("%+v", v)
+}
@@ -0,0 +1,134 @@
+package test
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+type entity struct {
+	ID      string
+	Meta    map[string]int
+	Next    *entity
+	Tags    []string
+	Updated time.Time
+	version int
+}
+
+func TestDiff(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		want any
+		got  any
+		diff []string
+	}{
+		{
+			name: "equal",
+			want: entity{ID: "a", Tags: []string{"x"}},
+			got:  entity{ID: "a", Tags: []string{"x"}},
+			diff: nil,
+		},
+		{
+			name: "fields",
+			want: entity{ID: "a", version: 1},
+			got:  entity{ID: "b", version: 2},
+			diff: []string{`.ID: got "b", want "a"`, `.version: got 2, want 1`},
+		},
+		{
+			name: "slice elements",
+			want: entity{Tags: []string{"x", "y"}},
+			got:  entity{Tags: []string{"x", "z", "w"}},
+			diff: []string{`.Tags[1]: got "z", want "y"`, `.Tags[2]: got "w", want <missing>`},
+		},
+		{
+			name: "nil slice",
+			want: entity{Tags: []string{}},
+			got:  entity{},
+			diff: []string{`.Tags: got nil, want []`},
+		},
+		{
+			name: "map keys",
+			want: entity{Meta: map[string]int{"a": 1, "b": 2}},
+			got:  entity{Meta: map[string]int{"a": 3, "c": 4}},
+			diff: []string{
+				`.Meta["a"]: got 3, want 1`,
+				`.Meta["b"]: got <missing>, want 2`,
+				`.Meta["c"]: got 4, want <missing>`,
+			},
+		},
+		{
+			name: "pointers",
+			want: &entity{Next: &entity{ID: "a"}},
+			got:  &entity{Next: &entity{ID: "b"}},
+			diff: []string{`.Next.ID: got "b", want "a"`},
+		},
+		{
+			name: "nil pointer",
+			want: entity{Next: &entity{}},
+			got:  entity{},
+			diff: []string{`.Next: got nil, want &{ID: Meta:map[] Next:<nil> Tags:[] ` +
+				`Updated:0001-01-01 00:00:00 +0000 UTC version:0}`},
+		},
+		{
+			name: "opaque struct",
+			want: entity{Updated: now},
+			got:  entity{Updated: now.Add(time.Second)},
+			diff: []string{
+				".Updated: got 2024-01-02 03:04:06 +0000 UTC, want 2024-01-02 03:04:05 +0000 UTC",
+			},
+		},
+		{
+			name: "interface types",
+			want: []any{1},
+			got:  []any{"1"},
+			diff: []string{`[0]: got type string, want type int`},
+		},
+		{
+			name: "scalar",
+			want: 1,
+			got:  2,
+			diff: []string{`value: got 2, want 1`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := diff(tt.want, tt.got)
+			if strings.Join(got, "\n") != strings.Join(tt.diff, "\n") {
+				t.Errorf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.diff, "\n"))
+			}
+		})
+	}
+}
+
+func TestDiffCycle(t *testing.T) {
+	a, b := &entity{ID: "a"}, &entity{ID: "b"}
+	a.Next, b.Next = a, b
+	if got := diff(a, b); len(got) != 1 {
+		t.Errorf("got %v, want 1 difference", got)
+	}
+}
+
+func TestDiffLimit(t *testing.T) {
+	got := diff(make([]int, maxDiffs+5), make([]int, 0, 1))
+	if len(got) != maxDiffs+1 || got[maxDiffs] != "..." {
+		t.Errorf("got %d lines, want %d ending with ...", len(got), maxDiffs+1)
+	}
+}
+
+func TestEqualDiff(t *testing.T) {
+	r := &recorder{TB: t}
+	Equal(r, entity{ID: "a", Tags: []string{"x"}}, entity{ID: "b", Tags: []string{"x"}})
+	want := "got test.entity not equal to want:\n\t.ID: got \"b\", want \"a\""
+	if r.msg != want {
+		t.Errorf("got %q, want %q", r.msg, want)
+	}
+
+	r = &recorder{TB: t}
+	Equal(r, "a", "b")
+	if r.msg != `got "b", want "a"` {
+		t.Errorf("got %q, want %q", r.msg, `got "b", want "a"`)
+	}
+}
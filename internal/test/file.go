@@ -0,0 +1,81 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TempFile creates a file with the content in a temporary directory and returns its path, the
+// directory is removed when the test and its subtests complete
+func TempFile(t testing.TB, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write temp file: %v", err)
+	}
+	return path
+}
+
+// TempDir creates a temporary directory with the layout and returns its path, the layout keys
+// are slash separated paths relative to the directory and the values are the file contents,
+// a key ending with a slash creates an empty directory, for example:
+//
+//	dir := test.TempDir(t, map[string]string{
+//		"a.txt":     "a",
+//		"sub/b.txt": "b",
+//		"empty/":    "",
+//	})
+//
+// the directory is removed when the test and its subtests complete
+func TempDir(t testing.TB, layout map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range layout {
+		if !filepath.IsLocal(filepath.FromSlash(strings.TrimSuffix(name, "/"))) {
+			t.Fatalf("invalid layout path %q", name)
+			return dir
+		}
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if strings.HasSuffix(name, "/") {
+			if err := os.MkdirAll(path, 0o700); err != nil {
+				t.Fatalf("create temp dir: %v", err)
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			t.Fatalf("create temp dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("write temp file: %v", err)
+		}
+	}
+	return dir
+}
+
+// FileExists checks that a regular file exists at the path
+func FileExists(t testing.TB, path string) {
+	t.Helper()
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("got %v, want file %q", err, path)
+		return
+	}
+	if !fi.Mode().IsRegular() {
+		t.Fatalf("got %s, want regular file %q", fi.Mode().Type(), path)
+	}
+}
+
+// FileContains checks that the file at the path contains substr
+func FileContains(t testing.TB, path, substr string) {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("got %v, want file %q", err, path)
+		return
+	}
+	if !strings.Contains(string(b), substr) {
+		t.Fatalf("got file %q with %q, want it to contain %q", path, b, substr)
+	}
+}
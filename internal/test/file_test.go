@@ -0,0 +1,67 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTempFile(t *testing.T) {
+	path := TempFile(t, "hello")
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "hello" {
+		t.Errorf("got %q, want %q", b, "hello")
+	}
+}
+
+func TestTempDir(t *testing.T) {
+	dir := TempDir(t, map[string]string{
+		"a.txt":       "a",
+		"sub/b.txt":   "b",
+		"sub/c/d.txt": "d",
+		"empty/":      "",
+	})
+
+	for name, want := range map[string]string{"a.txt": "a", "sub/b.txt": "b", "sub/c/d.txt": "d"} {
+		b, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != want {
+			t.Errorf("got %q, want %q", b, want)
+		}
+	}
+	if fi, err := os.Stat(filepath.Join(dir, "empty")); err != nil || !fi.IsDir() {
+		t.Errorf("got %v, want empty dir", err)
+	}
+
+	for _, name := range []string{"../x", "/x", "a/../../x"} {
+		r := &recorder{TB: t}
+		TempDir(r, map[string]string{name: "x"})
+		if !r.failed {
+			t.Errorf("got no failure for %q, want failure", name)
+		}
+	}
+}
+
+func TestFileAssertions(t *testing.T) {
+	dir := TempDir(t, map[string]string{
+		"a.txt":  "line one\nline two",
+		"empty/": "",
+	})
+	a := filepath.Join(dir, "a.txt")
+
+	runAssertions(t, []assertion{
+		{"FileExists", func(t testing.TB) { FileExists(t, a) }, false},
+		{"FileExists missing", func(t testing.TB) { FileExists(t, filepath.Join(dir, "b")) }, true},
+		{"FileExists dir", func(t testing.TB) { FileExists(t, filepath.Join(dir, "empty")) }, true},
+		{"FileContains", func(t testing.TB) { FileContains(t, a, "line two") }, false},
+		{"FileContains fails", func(t testing.TB) { FileContains(t, a, "line three") }, true},
+		{"FileContains missing", func(t testing.TB) {
+			FileContains(t, filepath.Join(dir, "b"), "x")
+		}, true},
+	})
+}
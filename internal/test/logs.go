@@ -0,0 +1,188 @@
+package test
+
+import (
+	"context"
+	"log/slog"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Record is a log record captured by Logs
+type Record struct {
+	// Attrs are the record attributes including the logger attributes, grouped attribute keys
+	// are joined with dots, for example "req.method"
+	Attrs map[string]any
+
+	// Level is the record level
+	Level slog.Level
+
+	// Message is the record message
+	Message string
+}
+
+// Logs is a slog.Handler capturing log records in memory, all levels are captured and the
+// handlers returned by WithAttrs and WithGroup share the captured records
+type Logs struct {
+	attrs  []slog.Attr
+	group  string
+	shared *records
+}
+
+// records are the captured records shared by a Logs and its derived handlers
+type records struct {
+	mu      sync.Mutex
+	records []Record
+}
+
+// NewLogs creates a new Logs
+func NewLogs() *Logs {
+	return &Logs{shared: &records{}}
+}
+
+// CaptureLogs creates a new Logs and sets it as the default slog handler until the test
+// completes, tests using it must not run in parallel since the default logger is global
+func CaptureLogs(t testing.TB) *Logs {
+	t.Helper()
+	l := NewLogs()
+	def := slog.Default()
+	slog.SetDefault(l.Logger())
+	t.Cleanup(func() {
+		slog.SetDefault(def)
+	})
+	return l
+}
+
+// Logger returns a logger using the handler
+func (l *Logs) Logger() *slog.Logger {
+	return slog.New(l)
+}
+
+// Enabled implements the slog.Handler interface
+func (l *Logs) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+// Handle implements the slog.Handler interface
+func (l *Logs) Handle(_ context.Context, r slog.Record) error {
+	rec := Record{
+		Attrs:   map[string]any{},
+		Level:   r.Level,
+		Message: r.Message,
+	}
+	for _, a := range l.attrs {
+		addAttr(rec.Attrs, "", a)
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		addAttr(rec.Attrs, l.group, a)
+		return true
+	})
+
+	l.shared.mu.Lock()
+	defer l.shared.mu.Unlock()
+	l.shared.records = append(l.shared.records, rec)
+	return nil
+}
+
+// WithAttrs implements the slog.Handler interface
+func (l *Logs) WithAttrs(attrs []slog.Attr) slog.Handler {
+	c := *l
+	c.attrs = append([]slog.Attr(nil), l.attrs...)
+	for _, a := range attrs {
+		if l.group != "" {
+			a.Key = l.group + a.Key
+		}
+		c.attrs = append(c.attrs, a)
+	}
+	return &c
+}
+
+// WithGroup implements the slog.Handler interface
+func (l *Logs) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return l
+	}
+	c := *l
+	c.group = l.group + name + "."
+	return &c
+}
+
+// Records returns the captured records in log order
+func (l *Logs) Records() []Record {
+	l.shared.mu.Lock()
+	defer l.shared.mu.Unlock()
+	return append([]Record(nil), l.shared.records...)
+}
+
+// Reset clears the captured records
+func (l *Logs) Reset() {
+	l.shared.mu.Lock()
+	defer l.shared.mu.Unlock()
+	l.shared.records = nil
+}
+
+// Contains returns true when a record has the level and a message containing substr
+func (l *Logs) Contains(level slog.Level, substr string) bool {
+	return len(l.Find(level, substr)) > 0
+}
+
+// ContainsAttrs returns true when a record has the level, a message containing substr and
+// the attributes, args are key value pairs like slog.Logger.Log args, for example:
+//
+//	logs.ContainsAttrs(slog.LevelError, "request failed", "status", 500, "req.method", "GET")
+//
+// values are compared after slog conversion so an int matches an int64 attribute
+func (l *Logs) ContainsAttrs(level slog.Level, substr string, args ...any) bool {
+	want := map[string]any{}
+	for _, a := range slog.Group("", args...).Value.Group() {
+		addAttr(want, "", a)
+	}
+	for _, r := range l.Find(level, substr) {
+		if r.hasAttrs(want) {
+			return true
+		}
+	}
+	return false
+}
+
+// Find returns the records with the level and a message containing substr
+func (l *Logs) Find(level slog.Level, substr string) []Record {
+	var found []Record
+	for _, r := range l.Records() {
+		if r.Level == level && strings.Contains(r.Message, substr) {
+			found = append(found, r)
+		}
+	}
+	return found
+}
+
+// hasAttrs returns true when the record has all the attributes
+func (r Record) hasAttrs(attrs map[string]any) bool {
+	for k, v := range attrs {
+		got, ok := r.Attrs[k]
+		if !ok || !reflect.DeepEqual(got, v) {
+			return false
+		}
+	}
+	return true
+}
+
+// addAttr adds a resolved attribute to attrs with the key prefix, group attributes are
+// flattened
+func addAttr(attrs map[string]any, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			addAttr(attrs, prefix, ga)
+		}
+		return
+	}
+	if a.Key == "" {
+		return
+	}
+	attrs[prefix+a.Key] = a.Value.Any()
+}
@@ -0,0 +1,69 @@
+package test
+
+import (
+	"errors"
+	"log/slog"
+	"testing"
+)
+
+// secret is a slog.LogValuer redacting its value
+type secret string
+
+func (secret) LogValue() slog.Value {
+	return slog.StringValue("[REDACTED]")
+}
+
+func TestLogs(t *testing.T) {
+	logs := NewLogs()
+	log := logs.Logger().With("app", "api")
+	req := log.WithGroup("req")
+	req.Error("request failed", "method", "GET", "status", 500, slog.Group("user", "id", "u1"))
+	log.Info("login", "token", secret("abc"))
+	log.Debug("cache miss", "err", errors.New("not found"))
+
+	tests := []struct {
+		name string
+		got  bool
+		want bool
+	}{
+		{"contains", logs.Contains(slog.LevelError, "failed"), true},
+		{"contains other level", logs.Contains(slog.LevelWarn, "failed"), false},
+		{"contains other message", logs.Contains(slog.LevelError, "timeout"), false},
+		{"contains debug", logs.Contains(slog.LevelDebug, "cache"), true},
+		{
+			"attrs",
+			logs.ContainsAttrs(slog.LevelError, "failed", "app", "api", "req.status", 500),
+			true,
+		},
+		{"nested group attrs", logs.ContainsAttrs(slog.LevelError, "", "req.user.id", "u1"), true},
+		{"ungrouped key", logs.ContainsAttrs(slog.LevelError, "", "status", 500), false},
+		{"attr value", logs.ContainsAttrs(slog.LevelError, "", "req.status", 400), false},
+		{"redacted", logs.ContainsAttrs(slog.LevelInfo, "login", "token", "[REDACTED]"), true},
+		{"raw secret", logs.ContainsAttrs(slog.LevelInfo, "login", "token", "abc"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("got %v, want %v", tt.got, tt.want)
+			}
+		})
+	}
+
+	Equal(t, 3, len(logs.Records()))
+	Equal(t, "not found", logs.Find(slog.LevelDebug, "")[0].Attrs["err"].(error).Error())
+	logs.Reset()
+	Empty(t, logs.Records())
+}
+
+func TestCaptureLogs(t *testing.T) {
+	def := slog.Default()
+	t.Run("capture", func(t *testing.T) {
+		logs := CaptureLogs(t)
+		slog.Warn("disk almost full", "free", 0.05)
+		True(t, logs.ContainsAttrs(slog.LevelWarn, "disk", "free", 0.05))
+	})
+	if slog.Default() != def {
+		t.Error("got captured default logger, want restored logger")
+	}
+}
@@ -0,0 +1,94 @@
+package test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// Panics checks that fn panics and returns the recovered value
+func Panics(t testing.TB, fn func()) any {
+	t.Helper()
+	v, ok := recovered(fn)
+	if !ok {
+		t.Fatalf("got no panic, want panic")
+	}
+	return v
+}
+
+// PanicsWithValue checks that fn panics with a value deeply equal to want
+func PanicsWithValue(t testing.TB, want any, fn func()) {
+	t.Helper()
+	v, ok := recovered(fn)
+	if !ok {
+		t.Fatalf("got no panic, want panic with %s", show(want))
+		return
+	}
+	if !reflect.DeepEqual(v, want) {
+		t.Fatalf("got panic with %s, want panic with %s", show(v), show(want))
+	}
+}
+
+// PanicsWithError checks that fn panics with an error with the message msg
+func PanicsWithError(t testing.TB, msg string, fn func()) {
+	t.Helper()
+	v, ok := recovered(fn)
+	if !ok {
+		t.Fatalf("got no panic, want panic with error %q", msg)
+		return
+	}
+	err, ok := v.(error)
+	if !ok {
+		t.Fatalf("got panic with %T %s, want panic with error %q", v, show(v), msg)
+		return
+	}
+	if err.Error() != msg {
+		t.Fatalf("got panic with error %q, want panic with error %q", err, msg)
+	}
+}
+
+// PanicsWithMessage checks that fn panics with the message msg, the message is the value for
+// a string, the error message for an error and the fmt output otherwise, use it for code
+// that panics on broken invariants with a formatted message
+func PanicsWithMessage(t testing.TB, msg string, fn func()) {
+	t.Helper()
+	v, ok := recovered(fn)
+	if !ok {
+		t.Fatalf("got no panic, want panic with %q", msg)
+		return
+	}
+	if m := message(v); m != msg {
+		t.Fatalf("got panic with %q, want panic with %q", m, msg)
+	}
+}
+
+// NotPanics checks that fn does not panic
+func NotPanics(t testing.TB, fn func()) {
+	t.Helper()
+	if v, ok := recovered(fn); ok {
+		t.Fatalf("got panic with %s, want no panic", show(v))
+	}
+}
+
+// recovered calls fn and returns the recovered value, ok is false when fn does not panic, a
+// panic(nil) is recovered as a *runtime.PanicNilError so ok is true
+func recovered(fn func()) (v any, ok bool) {
+	defer func() {
+		if v = recover(); v != nil {
+			ok = true
+		}
+	}()
+	fn()
+	return nil, false
+}
+
+// message returns the message of a recovered value
+func message(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case error:
+		return v.Error()
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,55 @@
+package test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestPanicAssertions(t *testing.T) {
+	panicErr := func() { panic(errors.New("closed")) }
+	panicString := func() { panic("closed") }
+	noPanic := func() {}
+
+	runAssertions(t, []assertion{
+		{"Panics", func(t testing.TB) { Panics(t, panicString) }, false},
+		{"Panics fails", func(t testing.TB) { Panics(t, noPanic) }, true},
+		{"PanicsWithValue", func(t testing.TB) { PanicsWithValue(t, "closed", panicString) }, false},
+		{"PanicsWithValue int", func(t testing.TB) {
+			PanicsWithValue(t, 1, func() { panic(1) })
+		}, false},
+		{"PanicsWithValue other value", func(t testing.TB) {
+			PanicsWithValue(t, "open", panicString)
+		}, true},
+		{"PanicsWithValue no panic", func(t testing.TB) { PanicsWithValue(t, "x", noPanic) }, true},
+		{"PanicsWithError", func(t testing.TB) { PanicsWithError(t, "closed", panicErr) }, false},
+		{"PanicsWithError other message", func(t testing.TB) {
+			PanicsWithError(t, "open", panicErr)
+		}, true},
+		{"PanicsWithError string", func(t testing.TB) {
+			PanicsWithError(t, "closed", panicString)
+		}, true},
+		{"PanicsWithError no panic", func(t testing.TB) { PanicsWithError(t, "x", noPanic) }, true},
+		{"PanicsWithMessage string", func(t testing.TB) {
+			PanicsWithMessage(t, "closed", panicString)
+		}, false},
+		{"PanicsWithMessage error", func(t testing.TB) {
+			PanicsWithMessage(t, "closed", panicErr)
+		}, false},
+		{"PanicsWithMessage formatted", func(t testing.TB) {
+			PanicsWithMessage(t, "invalid size 3", func() { panic(fmt.Sprintf("invalid size %d", 3)) })
+		}, false},
+		{"PanicsWithMessage other message", func(t testing.TB) {
+			PanicsWithMessage(t, "open", panicErr)
+		}, true},
+		{"NotPanics", func(t testing.TB) { NotPanics(t, noPanic) }, false},
+		{"NotPanics fails", func(t testing.TB) { NotPanics(t, panicString) }, true},
+		{"NotPanics panic nil", func(t testing.TB) { NotPanics(t, func() { panic(nil) }) }, true},
+	})
+}
+
+func TestPanicsValue(t *testing.T) {
+	if v := Panics(t, func() { panic(42) }); v != 42 {
+		t.Errorf("got %v, want 42", v)
+	}
+}
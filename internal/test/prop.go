@@ -0,0 +1,199 @@
+package test
+
+import (
+	"hash/fnv"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+const (
+	// propRuns is the number of generated values checked by Prop
+	propRuns = 100
+
+	// propShrinks is the max number of property calls while shrinking a failing value
+	propShrinks = 1000
+)
+
+// Gen generates values for Prop
+type Gen[T any] struct {
+	// Generate returns a random value using r
+	Generate func(r *rand.Rand) T
+
+	// Shrink returns smaller candidates for a failing value, the smallest candidates first,
+	// nil means the value cannot be shrunk
+	Shrink func(v T) []T
+}
+
+// Prop checks that property holds for values generated by gen, a property that panics does not
+// hold, the first failing value is shrunk to a smaller value that still fails so the failure
+// shows a minimal case, for example:
+//
+//	test.Prop(t, test.Slices(test.Ints(-100, 100), 20), func(s []int) bool {
+//		q := NewQueue[int]()
+//		for _, v := range s {
+//			q.Push(v)
+//		}
+//		return q.Len() == len(s)
+//	})
+//
+// the values are generated from a seed derived from the test name so a failure is reproduced
+// by running the test again
+func Prop[T any](t testing.TB, gen Gen[T], property func(v T) bool) {
+	t.Helper()
+	r := newRand(t, 0)
+	for i := range propRuns {
+		v := gen.Generate(r)
+		if holds(property, v) {
+			continue
+		}
+		small, steps := shrink(gen, v, property)
+		t.Fatalf(
+			"property failed for %s, shrunk in %d steps from %s in run %d",
+			show(small),
+			steps,
+			show(v),
+			i+1,
+		)
+		return
+	}
+}
+
+// Ints returns a Gen for ints from lo to hi inclusive, values shrink toward 0 or toward the
+// bound closest to 0
+func Ints(lo, hi int) Gen[int] {
+	target := 0
+	if lo > 0 {
+		target = lo
+	} else if hi < 0 {
+		target = hi
+	}
+	return Gen[int]{
+		Generate: func(r *rand.Rand) int {
+			return lo + int(r.Uint64N(uint64(hi-lo)+1))
+		},
+		Shrink: func(v int) []int {
+			if v == target {
+				return nil
+			}
+			c := []int{target}
+			for d := (v - target) / 2; d != 0; d /= 2 {
+				c = append(c, v-d)
+			}
+			return c
+		},
+	}
+}
+
+// alphabet is the runes of the strings generated by Strings, it has multibyte runes to
+// exercise code indexing strings by byte
+const alphabet = "abcxyzABCXYZ0189 -_./:@é世😀"
+
+// Strings returns a Gen for strings of up to maxLen runes, values shrink by removing runes
+func Strings(maxLen int) Gen[string] {
+	runes := []rune(alphabet)
+	return Gen[string]{
+		Generate: func(r *rand.Rand) string {
+			s := make([]rune, r.IntN(maxLen+1))
+			for i := range s {
+				s[i] = runes[r.IntN(len(runes))]
+			}
+			return string(s)
+		},
+		Shrink: func(v string) []string {
+			var c []string
+			for _, s := range shrinkSlice([]rune(v), nil) {
+				c = append(c, string(s))
+			}
+			return c
+		},
+	}
+}
+
+// Slices returns a Gen for slices of up to maxLen elements generated by elem, values shrink by
+// removing elements and then by shrinking elements
+func Slices[T any](elem Gen[T], maxLen int) Gen[[]T] {
+	return Gen[[]T]{
+		Generate: func(r *rand.Rand) []T {
+			s := make([]T, r.IntN(maxLen+1))
+			for i := range s {
+				s[i] = elem.Generate(r)
+			}
+			return s
+		},
+		Shrink: func(v []T) [][]T {
+			return shrinkSlice(v, elem.Shrink)
+		},
+	}
+}
+
+// shrinkSlice returns the shrink candidates of s, s without chunks of halving size at the end
+// and start, s without each element and s with each element shrunk
+func shrinkSlice[T any](s []T, elem func(v T) []T) [][]T {
+	if len(s) == 0 {
+		return nil
+	}
+	c := [][]T{{}}
+	for n := len(s) / 2; n > 0; n /= 2 {
+		c = append(c, slices.Clone(s[:len(s)-n]), slices.Clone(s[n:]))
+	}
+	for i := range s {
+		c = append(c, slices.Delete(slices.Clone(s), i, i+1))
+	}
+	if elem == nil {
+		return c
+	}
+	for i, v := range s {
+		for _, sv := range elem(v) {
+			cs := slices.Clone(s)
+			cs[i] = sv
+			c = append(c, cs)
+		}
+	}
+	return c
+}
+
+// shrink returns the smallest failing value found from the failing value v and the number of
+// shrink steps
+func shrink[T any](gen Gen[T], v T, property func(v T) bool) (T, int) {
+	if gen.Shrink == nil {
+		return v, 0
+	}
+	steps, calls := 0, 0
+	for {
+		shrunk := false
+		for _, c := range gen.Shrink(v) {
+			if calls >= propShrinks {
+				return v, steps
+			}
+			calls++
+			if !holds(property, c) {
+				v = c
+				steps++
+				shrunk = true
+				break
+			}
+		}
+		if !shrunk {
+			return v, steps
+		}
+	}
+}
+
+// holds returns true when property holds for v, a panic means the property does not hold
+func holds[T any](property func(v T) bool, v T) (ok bool) {
+	defer func() {
+		if recover() != nil {
+			ok = false
+		}
+	}()
+	return property(v)
+}
+
+// newRand returns a random generator seeded from the test name, the stream separates
+// generators of the same test
+func newRand(t testing.TB, stream uint64) *rand.Rand {
+	h := fnv.New64a()
+	h.Write([]byte(t.Name()))
+	return rand.New(rand.NewPCG(h.Sum64(), stream))
+}
@@ -0,0 +1,95 @@
+package test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestProp(t *testing.T) {
+	Prop(t, Slices(Ints(-100, 100), 20), func(s []int) bool {
+		sorted := slices.Clone(s)
+		slices.Sort(sorted)
+		return len(sorted) == len(s) && slices.IsSorted(sorted)
+	})
+	Prop(t, Strings(10), func(s string) bool {
+		return utf8.ValidString(s) && utf8.RuneCountInString(s) <= 10
+	})
+}
+
+func TestPropShrinks(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func(t testing.TB)
+		want string
+	}{
+		{
+			name: "ints",
+			fn: func(t testing.TB) {
+				Prop(t, Ints(0, 1000), func(v int) bool { return v < 37 })
+			},
+			want: "property failed for 37,",
+		},
+		{
+			name: "negative ints",
+			fn: func(t testing.TB) {
+				Prop(t, Ints(-1000, -10), func(v int) bool { return v > -500 })
+			},
+			want: "property failed for -500,",
+		},
+		{
+			name: "strings",
+			fn: func(t testing.TB) {
+				Prop(t, Strings(20), func(s string) bool { return !strings.Contains(s, "a") })
+			},
+			want: `property failed for "a",`,
+		},
+		{
+			name: "slices",
+			fn: func(t testing.TB) {
+				Prop(t, Slices(Ints(0, 100), 20), func(s []int) bool {
+					for _, v := range s {
+						if v >= 10 {
+							return false
+						}
+					}
+					return true
+				})
+			},
+			want: "property failed for [10],",
+		},
+		{
+			name: "panic",
+			fn: func(t testing.TB) {
+				Prop(t, Slices(Ints(0, 9), 20), func(s []int) bool {
+					return s[4] >= 0
+				})
+			},
+			want: "property failed for [],",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &recorder{TB: t}
+			tt.fn(r)
+			if !strings.HasPrefix(r.msg, tt.want) {
+				t.Errorf("got %q, want prefix %q", r.msg, tt.want)
+			}
+		})
+	}
+}
+
+func TestPropDeterministic(t *testing.T) {
+	var a, b []string
+	Prop(t, Strings(10), func(s string) bool {
+		a = append(a, s)
+		return true
+	})
+	Prop(t, Strings(10), func(s string) bool {
+		b = append(b, s)
+		return true
+	})
+	Equal(t, a, b)
+}
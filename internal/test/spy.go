@@ -0,0 +1,131 @@
+package test
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// Call is a call recorded by a Spy
+type Call struct {
+	// Args are the call arguments, variadic arguments are a single slice
+	Args []any
+
+	// Returns are the returned values, nil when the call panicked
+	Returns []any
+}
+
+// Spy records the calls of a func typed dependency, T must be a func type, the spy is safe for
+// concurrent use, small interfaces are stubbed without generated mocks using a struct with a
+// func field per method set to a spy func, for example:
+//
+//	type stubStore struct {
+//		get func(ctx context.Context, id string) (*Item, error)
+//	}
+//
+//	func (s stubStore) Get(ctx context.Context, id string) (*Item, error) {
+//		return s.get(ctx, id)
+//	}
+//
+//	get := test.NewSpy(func(ctx context.Context, id string) (*Item, error) {
+//		return &Item{ID: id}, nil
+//	})
+//	h := NewItemHandler(stubStore{get: get.Func()})
+//	// ...
+//	test.Equal(t, 1, get.Count())
+//	test.Equal(t, "a", get.Calls()[0].Args[1])
+type Spy[T any] struct {
+	calls []*Call
+	fn    T
+	mu    sync.Mutex
+	spy   T
+	typ   reflect.Type
+}
+
+// NewSpy creates a new Spy calling fn, a nil fn returns the zero values, panics when T is not a
+// func type
+func NewSpy[T any](fn T) *Spy[T] {
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+	if typ.Kind() != reflect.Func {
+		panic(fmt.Sprintf("test: spy type %s is not a func", typ))
+	}
+	s := &Spy[T]{
+		fn:  fn,
+		typ: typ,
+	}
+	s.spy = reflect.MakeFunc(typ, s.call).Interface().(T)
+	return s
+}
+
+// Func returns the func recording its calls, use it as the dependency
+func (s *Spy[T]) Func() T {
+	return s.spy
+}
+
+// Set replaces the func called by the spy, for example to return an error in a subtest
+func (s *Spy[T]) Set(fn T) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fn = fn
+}
+
+// Calls returns the recorded calls in call order
+func (s *Spy[T]) Calls() []Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	calls := make([]Call, len(s.calls))
+	for i, c := range s.calls {
+		calls[i] = *c
+	}
+	return calls
+}
+
+// Count returns the number of recorded calls
+func (s *Spy[T]) Count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.calls)
+}
+
+// Reset clears the recorded calls
+func (s *Spy[T]) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = nil
+}
+
+// call records a call and calls the func
+func (s *Spy[T]) call(args []reflect.Value) []reflect.Value {
+	c := &Call{Args: make([]any, len(args))}
+	for i, a := range args {
+		c.Args[i] = a.Interface()
+	}
+
+	s.mu.Lock()
+	s.calls = append(s.calls, c)
+	f := s.fn
+	s.mu.Unlock()
+	fn := reflect.ValueOf(&f).Elem()
+
+	var out []reflect.Value
+	switch {
+	case fn.IsNil():
+		out = make([]reflect.Value, s.typ.NumOut())
+		for j := range out {
+			out[j] = reflect.Zero(s.typ.Out(j))
+		}
+	case s.typ.IsVariadic():
+		out = fn.CallSlice(args)
+	default:
+		out = fn.Call(args)
+	}
+
+	returns := make([]any, len(out))
+	for j, v := range out {
+		returns[j] = v.Interface()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c.Returns = returns
+	return out
+}
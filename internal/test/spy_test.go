@@ -0,0 +1,97 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/shayanderson/go-project/internal/work"
+)
+
+// item is an entity for the stub example
+type item struct {
+	ID string
+}
+
+// store is a small interface stubbed with spies
+type store interface {
+	Get(ctx context.Context, id string) (*item, error)
+	Delete(ctx context.Context, id string) error
+}
+
+// stubStore implements store with a func field per method
+type stubStore struct {
+	delete func(ctx context.Context, id string) error
+	get    func(ctx context.Context, id string) (*item, error)
+}
+
+func (s stubStore) Delete(ctx context.Context, id string) error {
+	return s.delete(ctx, id)
+}
+
+func (s stubStore) Get(ctx context.Context, id string) (*item, error) {
+	return s.get(ctx, id)
+}
+
+// move gets an item and deletes it
+func move(ctx context.Context, s store, id string) (*item, error) {
+	it, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return it, s.Delete(ctx, id)
+}
+
+func TestSpyStub(t *testing.T) {
+	errDelete := errors.New("delete failed")
+	get := NewSpy(func(ctx context.Context, id string) (*item, error) {
+		return &item{ID: id}, nil
+	})
+	del := NewSpy[func(ctx context.Context, id string) error](nil)
+	s := stubStore{delete: del.Func(), get: get.Func()}
+
+	it, err := move(context.Background(), s, "a")
+	NoError(t, err)
+	Equal(t, &item{ID: "a"}, it)
+	Equal(t, 1, get.Count())
+	Equal(t, "a", get.Calls()[0].Args[1])
+	Equal(t, []any{&item{ID: "a"}, error(nil)}, get.Calls()[0].Returns)
+	Equal(t, []any{error(nil)}, del.Calls()[0].Returns)
+
+	del.Set(func(ctx context.Context, id string) error { return errDelete })
+	_, err = move(context.Background(), s, "b")
+	ErrorIs(t, err, errDelete)
+	Equal(t, 2, del.Count())
+
+	del.Reset()
+	Equal(t, 0, del.Count())
+}
+
+func TestSpyVariadic(t *testing.T) {
+	spy := NewSpy(func(format string, args ...any) string {
+		return fmt.Sprintf(format, args...)
+	})
+	Equal(t, "a=1 b=2", spy.Func()("a=%d b=%d", 1, 2))
+	Equal(t, []any{"a=%d b=%d", []any{1, 2}}, spy.Calls()[0].Args)
+}
+
+func TestSpyConcurrent(t *testing.T) {
+	spy := NewSpy(strings.ToUpper)
+	var wg sync.WaitGroup
+	wg.Add(10)
+	for range 10 {
+		work.Go(context.Background(), func(context.Context) {
+			defer wg.Done()
+			spy.Func()("a")
+		})
+	}
+	wg.Wait()
+	Equal(t, 10, spy.Count())
+}
+
+func TestSpyPanicsOnNonFunc(t *testing.T) {
+	PanicsWithMessage(t, "test: spy type int is not a func", func() { NewSpy(1) })
+}
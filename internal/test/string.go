@@ -0,0 +1,53 @@
+package test
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// Contains checks that s contains substr
+func Contains(t testing.TB, s, substr string) {
+	t.Helper()
+	if !strings.Contains(s, substr) {
+		t.Fatalf("got %q, want it to contain %q", s, substr)
+	}
+}
+
+// Regexp checks that s matches the regular expression pattern, the pattern is not anchored so
+// use ^ and $ to match the whole string, for example `^[0-9a-f]{32}$` for an ID
+func Regexp(t testing.TB, pattern, s string) {
+	t.Helper()
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		t.Fatalf("invalid pattern %q: %v", pattern, err)
+		return
+	}
+	if !re.MatchString(s) {
+		t.Fatalf("got %q, want match for %q", s, pattern)
+	}
+}
+
+// HasPrefix checks that s starts with prefix
+func HasPrefix(t testing.TB, s, prefix string) {
+	t.Helper()
+	if !strings.HasPrefix(s, prefix) {
+		t.Fatalf("got %q, want prefix %q", s, prefix)
+	}
+}
+
+// HasSuffix checks that s ends with suffix
+func HasSuffix(t testing.TB, s, suffix string) {
+	t.Helper()
+	if !strings.HasSuffix(s, suffix) {
+		t.Fatalf("got %q, want suffix %q", s, suffix)
+	}
+}
+
+// EqualFold checks that got is equal to want ignoring case, for example for header values
+func EqualFold(t testing.TB, want, got string) {
+	t.Helper()
+	if !strings.EqualFold(want, got) {
+		t.Fatalf("got %q, want %q ignoring case", got, want)
+	}
+}
@@ -0,0 +1,21 @@
+package test
+
+import "testing"
+
+func TestStringAssertions(t *testing.T) {
+	line := `level=INFO msg="request" id=0190b0e2d4c87c3a`
+
+	runAssertions(t, []assertion{
+		{"Contains", func(t testing.TB) { Contains(t, line, `msg="request"`) }, false},
+		{"Contains fails", func(t testing.TB) { Contains(t, line, "ERROR") }, true},
+		{"Regexp", func(t testing.TB) { Regexp(t, `id=[0-9a-f]{16}$`, line) }, false},
+		{"Regexp fails", func(t testing.TB) { Regexp(t, `^id=`, line) }, true},
+		{"Regexp invalid pattern", func(t testing.TB) { Regexp(t, `(`, line) }, true},
+		{"HasPrefix", func(t testing.TB) { HasPrefix(t, line, "level=INFO") }, false},
+		{"HasPrefix fails", func(t testing.TB) { HasPrefix(t, line, "msg=") }, true},
+		{"HasSuffix", func(t testing.TB) { HasSuffix(t, "report.csv", ".csv") }, false},
+		{"HasSuffix fails", func(t testing.TB) { HasSuffix(t, "report.csv", ".json") }, true},
+		{"EqualFold", func(t testing.TB) { EqualFold(t, "application/json", "Application/JSON") }, false},
+		{"EqualFold fails", func(t testing.TB) { EqualFold(t, "gzip", "br") }, true},
+	})
+}
@@ -0,0 +1,191 @@
+package test
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Equal checks that got is deeply equal to want, when structs, maps or slices are not equal
+// the failure lists each differing field, element or key with its path instead of the whole
+// values
+func Equal[T any](t testing.TB, want, got T) {
+	t.Helper()
+	if reflect.DeepEqual(want, got) {
+		return
+	}
+	if composite(want) {
+		if lines := diff(want, got); len(lines) > 0 {
+			t.Fatalf("got %T not equal to want:\n\t%s", got, strings.Join(lines, "\n\t"))
+			return
+		}
+	}
+	t.Fatalf("got %s, want %s", show(got), show(want))
+}
+
+// NotEqual checks that got is not deeply equal to want
+func NotEqual[T any](t testing.TB, want, got T) {
+	t.Helper()
+	if reflect.DeepEqual(want, got) {
+		t.Fatalf("got %s, want a different value", show(got))
+	}
+}
+
+// True checks that v is true
+func True(t testing.TB, v bool) {
+	t.Helper()
+	if !v {
+		t.Fatalf("got false, want true")
+	}
+}
+
+// False checks that v is false
+func False(t testing.TB, v bool) {
+	t.Helper()
+	if v {
+		t.Fatalf("got true, want false")
+	}
+}
+
+// Nil checks that v is nil, typed nil pointers, slices, maps, chans and funcs are nil
+func Nil(t testing.TB, v any) {
+	t.Helper()
+	if !isNil(v) {
+		t.Fatalf("got %s, want nil", show(v))
+	}
+}
+
+// NotNil checks that v is not nil
+func NotNil(t testing.TB, v any) {
+	t.Helper()
+	if isNil(v) {
+		t.Fatalf("got nil, want not nil")
+	}
+}
+
+// NoError checks that err is nil
+func NoError(t testing.TB, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("got error %q, want no error", err)
+	}
+}
+
+// Error checks that err is not nil
+func Error(t testing.TB, err error) {
+	t.Helper()
+	if err == nil {
+		t.Fatalf("got no error, want error")
+	}
+}
+
+// ErrorIs checks that err matches target using errors.Is
+func ErrorIs(t testing.TB, err, target error) {
+	t.Helper()
+	if !errors.Is(err, target) {
+		t.Fatalf("got error %v, want %q", err, target)
+	}
+}
+
+// ErrorAs checks that err matches the type E using errors.As and returns the matched error
+func ErrorAs[E error](t testing.TB, err error) E {
+	t.Helper()
+	var e E
+	if !errors.As(err, &e) {
+		t.Fatalf("got error %v, want %T", err, e)
+	}
+	return e
+}
+
+// Empty checks that v is empty, strings, slices, maps and chans are empty when their length is
+// 0, pointers and interfaces when they are nil and other values when they are the zero value
+func Empty[T any](t testing.TB, v T) {
+	t.Helper()
+	if !isEmpty(v) {
+		t.Fatalf("got %s, want empty", show(v))
+	}
+}
+
+// NotEmpty checks that v is not empty, see Empty
+func NotEmpty[T any](t testing.TB, v T) {
+	t.Helper()
+	if isEmpty(v) {
+		t.Fatalf("got %s, want not empty", show(v))
+	}
+}
+
+// Zero checks that v is the zero value of its type, an empty non nil slice or map is not zero
+func Zero[T any](t testing.TB, v T) {
+	t.Helper()
+	if !isZero(v) {
+		t.Fatalf("got %s, want zero value", show(v))
+	}
+}
+
+// NotZero checks that v is not the zero value of its type
+func NotZero[T any](t testing.TB, v T) {
+	t.Helper()
+	if isZero(v) {
+		t.Fatalf("got zero value %s, want not zero", show(v))
+	}
+}
+
+// isZero returns true when v is nil or the zero value of its dynamic type
+func isZero(v any) bool {
+	return v == nil || reflect.ValueOf(v).IsZero()
+}
+
+// isEmpty returns true when v is empty, see Empty
+func isEmpty(v any) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Chan, reflect.Map, reflect.Slice, reflect.String:
+		return rv.Len() == 0
+	case reflect.Interface, reflect.Pointer:
+		return rv.IsNil()
+	}
+	return rv.IsZero()
+}
+
+// isNil returns true when v is nil or a nil pointer, slice, map, chan, func or interface
+func isNil(v any) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Chan, reflect.Func, reflect.Interface, reflect.Map, reflect.Pointer,
+		reflect.Slice, reflect.UnsafePointer:
+		return rv.IsNil()
+	}
+	return false
+}
+
+// composite returns true when v is a struct, map, slice, array or a pointer to one
+func composite(v any) bool {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil {
+		return false
+	}
+	switch t.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.Struct:
+		return true
+	}
+	return false
+}
+
+// show formats a value for a failure message, strings are quoted
+func show(v any) string {
+	if s, ok := v.(string); ok {
+		return fmt.Sprintf("%q", s)
+	}
+	return fmt.Sprintf("%+v", v)
+}
@@ -0,0 +1,154 @@
+package test
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"testing"
+)
+
+// recorder is a testing.TB recording failures instead of failing the test
+type recorder struct {
+	testing.TB
+	failed bool
+	fatal  bool
+	msg    string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Error(args ...any) {
+	r.failed = true
+	r.msg = fmt.Sprint(args...)
+}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.failed = true
+	r.msg = fmt.Sprintf(format, args...)
+}
+
+func (r *recorder) Fail() {
+	r.failed = true
+}
+
+func (r *recorder) FailNow() {
+	r.failed = true
+	r.fatal = true
+}
+
+func (r *recorder) Fatal(args ...any) {
+	r.Error(args...)
+	r.fatal = true
+}
+
+func (r *recorder) Fatalf(format string, args ...any) {
+	r.Errorf(format, args...)
+	r.fatal = true
+}
+
+// assertion is a test case calling assertions with a recorder
+type assertion struct {
+	name string
+	fn   func(t testing.TB)
+	fail bool
+}
+
+// runAssertions runs the test cases and checks whether each one failed
+func runAssertions(t *testing.T, tests []assertion) {
+	t.Helper()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &recorder{TB: t}
+			tt.fn(r)
+			if r.failed != tt.fail {
+				t.Errorf("got failed %v, want %v, message %q", r.failed, tt.fail, r.msg)
+			}
+		})
+	}
+}
+
+type pathError struct {
+	path string
+}
+
+func (e *pathError) Error() string {
+	return "invalid path " + e.path
+}
+
+func TestAssertions(t *testing.T) {
+	var nilMap map[string]int
+	var nilPtr *int
+	wrapped := fmt.Errorf("read: %w", fs.ErrNotExist)
+
+	runAssertions(t, []assertion{
+		{"Equal", func(t testing.TB) { Equal(t, []int{1, 2}, []int{1, 2}) }, false},
+		{"Equal fails", func(t testing.TB) { Equal(t, "a", "b") }, true},
+		{"NotEqual", func(t testing.TB) { NotEqual(t, 1, 2) }, false},
+		{"NotEqual fails", func(t testing.TB) { NotEqual(t, 1, 1) }, true},
+		{"True", func(t testing.TB) { True(t, true) }, false},
+		{"True fails", func(t testing.TB) { True(t, false) }, true},
+		{"False", func(t testing.TB) { False(t, false) }, false},
+		{"False fails", func(t testing.TB) { False(t, true) }, true},
+		{"Nil", func(t testing.TB) { Nil(t, nil) }, false},
+		{"Nil typed nil map", func(t testing.TB) { Nil(t, nilMap) }, false},
+		{"Nil typed nil pointer", func(t testing.TB) { Nil(t, nilPtr) }, false},
+		{"Nil fails", func(t testing.TB) { Nil(t, 0) }, true},
+		{"NotNil", func(t testing.TB) { NotNil(t, 0) }, false},
+		{"NotNil fails", func(t testing.TB) { NotNil(t, nilPtr) }, true},
+		{"NoError", func(t testing.TB) { NoError(t, nil) }, false},
+		{"NoError fails", func(t testing.TB) { NoError(t, wrapped) }, true},
+		{"Error", func(t testing.TB) { Error(t, wrapped) }, false},
+		{"Error fails", func(t testing.TB) { Error(t, nil) }, true},
+		{"ErrorIs", func(t testing.TB) { ErrorIs(t, wrapped, fs.ErrNotExist) }, false},
+		{"ErrorIs fails", func(t testing.TB) { ErrorIs(t, wrapped, fs.ErrExist) }, true},
+	})
+}
+
+func TestEmptyAndZero(t *testing.T) {
+	type point struct{ X, Y int }
+	var nilSlice []int
+	var nilErr error
+	ch := make(chan int, 1)
+	full := make(chan int, 1)
+	full <- 1
+
+	runAssertions(t, []assertion{
+		{"Empty string", func(t testing.TB) { Empty(t, "") }, false},
+		{"Empty string fails", func(t testing.TB) { Empty(t, "a") }, true},
+		{"Empty nil slice", func(t testing.TB) { Empty(t, nilSlice) }, false},
+		{"Empty slice", func(t testing.TB) { Empty(t, []int{}) }, false},
+		{"Empty slice fails", func(t testing.TB) { Empty(t, []int{0}) }, true},
+		{"Empty map", func(t testing.TB) { Empty(t, map[string]int{}) }, false},
+		{"Empty map fails", func(t testing.TB) { Empty(t, map[string]int{"a": 0}) }, true},
+		{"Empty chan", func(t testing.TB) { Empty(t, ch) }, false},
+		{"Empty chan fails", func(t testing.TB) { Empty(t, full) }, true},
+		{"Empty nil pointer", func(t testing.TB) { Empty(t, (*point)(nil)) }, false},
+		{"Empty pointer fails", func(t testing.TB) { Empty(t, &point{}) }, true},
+		{"Empty nil error", func(t testing.TB) { Empty(t, nilErr) }, false},
+		{"Empty zero struct", func(t testing.TB) { Empty(t, point{}) }, false},
+		{"Empty struct fails", func(t testing.TB) { Empty(t, point{X: 1}) }, true},
+		{"Empty zero int", func(t testing.TB) { Empty(t, 0) }, false},
+		{"NotEmpty", func(t testing.TB) { NotEmpty(t, []string{"a"}) }, false},
+		{"NotEmpty fails", func(t testing.TB) { NotEmpty(t, "") }, true},
+		{"Zero", func(t testing.TB) { Zero(t, point{}) }, false},
+		{"Zero nil error", func(t testing.TB) { Zero(t, nilErr) }, false},
+		{"Zero nil slice", func(t testing.TB) { Zero(t, nilSlice) }, false},
+		{"Zero empty slice fails", func(t testing.TB) { Zero(t, []int{}) }, true},
+		{"Zero fails", func(t testing.TB) { Zero(t, point{Y: 1}) }, true},
+		{"NotZero", func(t testing.TB) { NotZero(t, 1) }, false},
+		{"NotZero fails", func(t testing.TB) { NotZero(t, "") }, true},
+	})
+}
+
+func TestErrorAs(t *testing.T) {
+	err := fmt.Errorf("open: %w", &pathError{path: "a"})
+	if e := ErrorAs[*pathError](t, err); e.path != "a" {
+		t.Errorf("got path %q, want %q", e.path, "a")
+	}
+
+	r := &recorder{TB: t}
+	ErrorAs[*pathError](r, errors.New("x"))
+	if !r.failed {
+		t.Error("got no failure, want failure")
+	}
+}
@@ -0,0 +1,167 @@
+package timex
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/shayanderson/go-project/server"
+)
+
+// Time is a time that is serialized as RFC3339 in UTC, use it for entity timestamps so all
+// responses share one format regardless of the request or server time zone
+type Time struct {
+	time.Time
+}
+
+// Now returns the current time
+func Now() Time {
+	return Time{time.Now()}
+}
+
+// MarshalJSON implements the json.Marshaler interface, the zero time is serialized as null
+func (t Time) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return []byte("null"), nil
+	}
+	return []byte(`"` + t.UTC().Format(time.RFC3339Nano) + `"`), nil
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface, null is parsed as the zero time
+func (t *Time) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		t.Time = time.Time{}
+		return nil
+	}
+	v, err := time.Parse(`"`+time.RFC3339Nano+`"`, string(b))
+	if err != nil {
+		return err
+	}
+	t.Time = v.UTC()
+	return nil
+}
+
+// ZoneResolver resolves the time zone name for a request, for example "America/New_York",
+// returns an empty string when the request has no time zone
+type ZoneResolver func(*http.Request) string
+
+// FromHeader resolves the time zone name from a request header
+func FromHeader(name string) ZoneResolver {
+	return func(r *http.Request) string {
+		return r.Header.Get(name)
+	}
+}
+
+// Middleware returns middleware that resolves the request time zone using the resolvers in
+// order and stores the location in the request context, the default location is used when
+// no time zone is resolved and requests with an unknown time zone receive a 400 response
+// resolvers reading the user profile from the request context must run after the
+// authentication middleware
+func Middleware(def *time.Location, resolvers ...ZoneResolver) server.Middleware {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			loc := def
+			for _, resolve := range resolvers {
+				name := resolve(r)
+				if name == "" {
+					continue
+				}
+				l, err := locations.load(name)
+				if err != nil {
+					_ = server.WriteJSON(
+						w,
+						http.StatusBadRequest,
+						map[string]string{"error": "invalid time zone"},
+					)
+					return
+				}
+				loc = l
+				break
+			}
+
+			next.ServeHTTP(w, r.WithContext(WithLocation(r.Context(), loc)))
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// maxLocations is the max number of cached locations, there are about 600 time zone names so
+// the cache only evicts when it is filled with aliases
+const maxLocations = 1024
+
+// locations is the cache of locations loaded by Middleware
+var locations = &locationCache{cache: map[string]*time.Location{}}
+
+// locationCache caches loaded locations by name, time.LoadLocation reads and parses the
+// zoneinfo data on every call and request time zone names are client input
+type locationCache struct {
+	cache map[string]*time.Location
+	mu    sync.RWMutex
+}
+
+// load returns the location with the name, only valid names are cached so invalid names
+// cannot evict valid ones, names longer than 64 chars are invalid, when the cache is full an
+// arbitrary location is evicted
+func (c *locationCache) load(name string) (*time.Location, error) {
+	c.mu.RLock()
+	loc, ok := c.cache[name]
+	c.mu.RUnlock()
+	if ok {
+		return loc, nil
+	}
+
+	if len(name) > 64 {
+		return nil, errors.New("timex: invalid time zone name")
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.cache) >= maxLocations {
+		for k := range c.cache {
+			delete(c.cache, k)
+			break
+		}
+	}
+	c.cache[name] = loc
+	return loc, nil
+}
+
+// locationKey is the context key for the location
+type locationKey struct{}
+
+// Location returns the location from the context, returns UTC when the context has no
+// location
+func Location(ctx context.Context) *time.Location {
+	if loc, ok := ctx.Value(locationKey{}).(*time.Location); ok && loc != nil {
+		return loc
+	}
+	return time.UTC
+}
+
+// WithLocation returns a copy of the context with the location
+func WithLocation(ctx context.Context, loc *time.Location) context.Context {
+	return context.WithValue(ctx, locationKey{}, loc)
+}
+
+// Format formats the time in the context location
+func Format(ctx context.Context, t time.Time, layout string) string {
+	return t.In(Location(ctx)).Format(layout)
+}
+
+// In returns the time in the context location
+func In(ctx context.Context, t time.Time) time.Time {
+	return t.In(Location(ctx))
+}
+
+// Parse parses a time in the context location, values with a zone offset keep their offset,
+// for example a date only value "2024-01-02" is parsed as midnight in the request time zone
+func Parse(ctx context.Context, layout, value string) (time.Time, error) {
+	return time.ParseInLocation(layout, value, Location(ctx))
+}
@@ -0,0 +1,82 @@
+package timex
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMiddleware(t *testing.T) {
+	tests := []struct {
+		name   string
+		zone   string
+		status int
+		want   string
+	}{
+		{"default", "", http.StatusOK, "UTC"},
+		{"zone", "America/New_York", http.StatusOK, "America/New_York"},
+		{"cached zone", "America/New_York", http.StatusOK, "America/New_York"},
+		{"unknown zone", "Mars/Olympus", http.StatusBadRequest, ""},
+		{"path traversal", "../../etc/passwd", http.StatusBadRequest, ""},
+		{"long name", strings.Repeat("a", 65), http.StatusBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			h := Middleware(time.UTC, FromHeader("Time-Zone"))(http.HandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) {
+					got = Location(r.Context()).String()
+				},
+			))
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.zone != "" {
+				r.Header.Set("Time-Zone", tt.zone)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			if w.Code != tt.status {
+				t.Errorf("got status %d, want %d", w.Code, tt.status)
+			}
+			if got != tt.want {
+				t.Errorf("got location %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocationCache(t *testing.T) {
+	c := &locationCache{cache: map[string]*time.Location{}}
+	a, err := c.load("Europe/Paris")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := c.load("Europe/Paris")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a != b {
+		t.Error("got a new location, want the cached location")
+	}
+	if _, err := c.load("Mars/Olympus"); err == nil {
+		t.Error("got no error for an unknown zone")
+	}
+	if len(c.cache) != 1 {
+		t.Errorf("got %d cached locations, want 1", len(c.cache))
+	}
+
+	for i := range maxLocations - len(c.cache) {
+		c.cache[strings.Repeat("x", i+1)] = time.UTC
+	}
+	if _, err := c.load("Asia/Tokyo"); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.cache) > maxLocations {
+		t.Errorf("got %d cached locations, want at most %d", len(c.cache), maxLocations)
+	}
+	if _, ok := c.cache["Asia/Tokyo"]; !ok {
+		t.Error("location not cached when the cache is full")
+	}
+}
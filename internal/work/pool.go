@@ -0,0 +1,152 @@
+package work
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrPoolClosed is returned when acquiring from a closed pool
+var ErrPoolClosed = errors.New("work: pool closed")
+
+// PoolOptions are the pool options
+type PoolOptions[T any] struct {
+	// Destroy destroys a resource, for example closes a connection, optional
+	Destroy func(T) error
+
+	// HealthCheck checks an idle resource before it is acquired, unhealthy resources are
+	// destroyed, optional
+	HealthCheck func(ctx context.Context, v T) error
+
+	// MaxIdle is the max number of idle resources kept for reuse, default 2
+	MaxIdle int
+
+	// MaxSize is the max number of resources in use and idle, Acquire waits for a resource
+	// when reached, 0 is no limit
+	MaxSize int
+
+	// New creates a resource
+	New func(ctx context.Context) (T, error)
+}
+
+// Pool is a pool of expensive resources like TCP clients or SMTP connections
+type Pool[T any] struct {
+	closed bool
+	idle   []T
+	mu     sync.Mutex
+	opts   PoolOptions[T]
+	sem    chan struct{}
+}
+
+// NewPool creates a new Pool
+func NewPool[T any](opts PoolOptions[T]) *Pool[T] {
+	if opts.MaxIdle <= 0 {
+		opts.MaxIdle = 2
+	}
+	p := &Pool[T]{opts: opts}
+	if opts.MaxSize > 0 {
+		p.sem = make(chan struct{}, opts.MaxSize)
+		p.opts.MaxIdle = min(opts.MaxIdle, opts.MaxSize)
+	}
+	return p
+}
+
+// Acquire returns an idle resource or creates a new one, it waits for a resource to be
+// released when the pool is at its max size, acquired resources must be returned with
+// Release or Discard
+func (p *Pool[T]) Acquire(ctx context.Context) (T, error) {
+	var zero T
+	if p.sem != nil {
+		select {
+		case p.sem <- struct{}{}:
+		case <-ctx.Done():
+			return zero, ctx.Err()
+		}
+	}
+
+	for {
+		p.mu.Lock()
+		if p.closed {
+			p.mu.Unlock()
+			p.release()
+			return zero, ErrPoolClosed
+		}
+		if len(p.idle) == 0 {
+			p.mu.Unlock()
+			break
+		}
+		v := p.idle[len(p.idle)-1]
+		p.idle = p.idle[:len(p.idle)-1]
+		p.mu.Unlock()
+
+		if p.opts.HealthCheck == nil {
+			return v, nil
+		}
+		if err := p.opts.HealthCheck(ctx, v); err == nil {
+			return v, nil
+		}
+		p.destroy(v)
+	}
+
+	v, err := p.opts.New(ctx)
+	if err != nil {
+		p.release()
+		return zero, err
+	}
+	return v, nil
+}
+
+// Release returns a resource to the pool for reuse, it is destroyed when the pool has max
+// idle resources or is closed
+func (p *Pool[T]) Release(v T) {
+	p.mu.Lock()
+	if !p.closed && len(p.idle) < p.opts.MaxIdle {
+		p.idle = append(p.idle, v)
+		p.mu.Unlock()
+		p.release()
+		return
+	}
+	p.mu.Unlock()
+
+	p.destroy(v)
+	p.release()
+}
+
+// Discard destroys a resource instead of returning it to the pool, for example after an I/O
+// error left a connection in an unknown state
+func (p *Pool[T]) Discard(v T) {
+	p.destroy(v)
+	p.release()
+}
+
+// Close closes the pool and destroys the idle resources, resources in use are destroyed
+// when released
+func (p *Pool[T]) Close() error {
+	p.mu.Lock()
+	p.closed = true
+	idle := p.idle
+	p.idle = nil
+	p.mu.Unlock()
+
+	var errs []error
+	for _, v := range idle {
+		if p.opts.Destroy != nil {
+			errs = append(errs, p.opts.Destroy(v))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// destroy destroys a resource
+func (p *Pool[T]) destroy(v T) {
+	if p.opts.Destroy != nil {
+		_ = p.opts.Destroy(v)
+	}
+}
+
+// release releases a size slot
+func (p *Pool[T]) release() {
+	if p.sem != nil {
+		<-p.sem
+	}
+}
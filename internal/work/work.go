@@ -0,0 +1,65 @@
+package work
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+)
+
+// PanicHandler is called for every panic recovered by Go and Run, for example to report
+// crashes to an error tracker, it must be set before goroutines are started
+var PanicHandler func(ctx context.Context, err *PanicError)
+
+// PanicError is a recovered panic
+type PanicError struct {
+	// Stack is the stack of the panicking goroutine
+	Stack []byte
+
+	// Value is the recovered value
+	Value any
+}
+
+// Error implements the error interface
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Unwrap returns the recovered value if it is an error
+func (e *PanicError) Unwrap() error {
+	if err, ok := e.Value.(error); ok {
+		return err
+	}
+	return nil
+}
+
+// Go runs fn in a new goroutine, a panic is recovered, logged with its stack and reported to
+// the PanicHandler instead of crashing the process, use it instead of a bare go statement
+func Go(ctx context.Context, fn func(ctx context.Context)) {
+	go func() {
+		_ = Run(ctx, func(ctx context.Context) error {
+			fn(ctx)
+			return nil
+		})
+	}()
+}
+
+// Run runs fn in the current goroutine and returns its error, a panic is recovered, logged
+// with its stack, reported to the PanicHandler and returned as a *PanicError
+func Run(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			p := &PanicError{
+				Stack: debug.Stack(),
+				Value: v,
+			}
+			slog.Error("recovering from panic", "err", v, "trace", string(p.Stack))
+			if PanicHandler != nil {
+				PanicHandler(ctx, p)
+			}
+			err = p
+		}
+	}()
+
+	return fn(ctx)
+}
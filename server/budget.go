@@ -0,0 +1,43 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Budget returns the time remaining until the context deadline, false when the context has no
+// deadline
+func Budget(ctx context.Context) (time.Duration, bool) {
+	d, ok := ctx.Deadline()
+	if !ok {
+		return 0, false
+	}
+	return time.Until(d), true
+}
+
+// TimeoutMiddleware returns middleware that sets a request context deadline of d, a shorter
+// existing deadline is kept, handlers derive outbound call contexts with WithBudget
+func TimeoutMiddleware(d time.Duration) Middleware {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), d)
+			defer cancel()
+			next.ServeHTTP(w, r.WithContext(ctx))
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// WithBudget returns a child context for an outbound call whose deadline is the parent
+// deadline minus the margin, leaving the handler time to write the response, when the
+// remaining budget is less than the margin the returned context is already done, the parent
+// is returned with a cancel func when it has no deadline
+func WithBudget(ctx context.Context, margin time.Duration) (context.Context, context.CancelFunc) {
+	d, ok := ctx.Deadline()
+	if !ok {
+		return context.WithCancel(ctx)
+	}
+	return context.WithDeadline(ctx, d.Add(-margin))
+}
@@ -0,0 +1,134 @@
+package server
+
+import (
+	"log/slog"
+	"net/http"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// nameProbe is a handler passed to middleware to read the name set by Named
+type nameProbe struct {
+	name string
+}
+
+// ServeHTTP implements the http.Handler interface
+func (*nameProbe) ServeHTTP(http.ResponseWriter, *http.Request) {}
+
+// Named names a middleware, the name is used by the middleware chain diagnostics instead of
+// the name of the middleware function, which is not useful for closures
+func Named(name string, mw Middleware) Middleware {
+	return func(next http.Handler) http.Handler {
+		if p, ok := next.(*nameProbe); ok {
+			p.name = name
+			return next
+		}
+		return mw(next)
+	}
+}
+
+// middlewareName returns the middleware name set by Named, or the middleware function name,
+// for example "server.LoggerMiddleware"
+func middlewareName(mw Middleware) string {
+	p := &nameProbe{}
+	mw(p)
+	if p.name != "" {
+		return p.name
+	}
+
+	fn := runtime.FuncForPC(reflect.ValueOf(mw).Pointer())
+	if fn == nil {
+		return "unknown"
+	}
+	name := fn.Name()
+	if i := strings.LastIndexByte(name, '/'); i >= 0 {
+		name = name[i+1:]
+	}
+	// closures returned by middleware constructors are named like "pkg.Func.func1"
+	if i := strings.Index(name, ".func"); i >= 0 {
+		name = name[:i]
+	}
+	return name
+}
+
+// chainRule is a middleware ordering rule, middleware matching first must come before
+// middleware matching then
+type chainRule struct {
+	first   []string
+	then    []string
+	message string
+}
+
+// chainRules are the rules for common middleware ordering mistakes, middleware are matched
+// by name ignoring case
+var chainRules = []chainRule{
+	{
+		first:   []string{"Recover"},
+		then:    []string{""},
+		message: "recover middleware is not outermost",
+	},
+	{
+		first:   []string{"Auth", "Permission", "Tenant"},
+		then:    []string{"Bind", "Body", "Decompress", "Override", "Sanitize"},
+		message: "auth middleware runs after body parsing",
+	},
+}
+
+// chainWarnings returns warnings for middleware ordering mistakes in a middleware chain,
+// logger middleware may come before recover middleware so panics are logged
+func chainWarnings(names []string) []string {
+	var warnings []string
+	for _, rule := range chainRules {
+		for i, name := range names {
+			if !containsAny(name, rule.first) {
+				continue
+			}
+			for _, before := range names[:i] {
+				if containsAny(before, rule.first) || containsAny(before, []string{"Logger"}) {
+					continue
+				}
+				if containsAny(before, rule.then) {
+					warnings = append(warnings, rule.message+": "+before+" before "+name)
+				}
+			}
+		}
+	}
+	return warnings
+}
+
+// containsAny checks if s contains any of the substrings, ignoring case
+func containsAny(s string, substrs []string) bool {
+	s = strings.ToLower(s)
+	for _, sub := range substrs {
+		if strings.Contains(s, strings.ToLower(sub)) {
+			return true
+		}
+	}
+	return false
+}
+
+// logChains logs the effective middleware chain of each route, outermost first, and warns on
+// middleware ordering mistakes
+func (r *router) logChains() {
+	global := make([]string, 0, len(r.mw))
+	for _, mw := range r.mw {
+		global = append(global, middlewareName(mw))
+	}
+
+	for _, ri := range r.Routes() {
+		names := append([]string{}, global...)
+		for _, mw := range ri.middleware {
+			names = append(names, middlewareName(mw))
+		}
+
+		slog.Debug(
+			"[http] middleware chain",
+			"route", ri.Method+" "+ri.Pattern,
+			"chain", strings.Join(names, " > "),
+		)
+		for _, w := range chainWarnings(names) {
+			slog.Warn("[http] middleware order", "route", ri.Method+" "+ri.Pattern, "warning", w)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package server
+
+import "context"
+
+// claimsKey is the request context key for the verified token claims
+type claimsKey struct{}
+
+// Claims returns the verified token claims from the context
+func Claims(ctx context.Context) (map[string]any, bool) {
+	claims, ok := ctx.Value(claimsKey{}).(map[string]any)
+	return claims, ok
+}
+
+// WithClaims returns a copy of the context with the verified token claims, authentication
+// middleware sets the claims after verifying the request token so later middleware and
+// handlers can read them
+func WithClaims(ctx context.Context, claims map[string]any) context.Context {
+	return context.WithValue(ctx, claimsKey{}, claims)
+}
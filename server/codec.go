@@ -0,0 +1,113 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/shayanderson/go-project/internal/errs"
+)
+
+// Codec encodes and decodes request and response bodies
+type Codec interface {
+	// Decode decodes the next value from r into v
+	Decode(r io.Reader, v any) error
+
+	// Encode writes the encoding of v to w, JSON codecs must write a newline after the value
+	Encode(w io.Writer, v any) error
+}
+
+// JSONCodec is the codec used by ReadJSON, WriteJSON and WriteNDJSON, it can be replaced with
+// a faster encoder for high-throughput deployments, it must be set before the server starts
+var JSONCodec Codec = stdCodec{}
+
+// stdCodec is a Codec using encoding/json
+type stdCodec struct{}
+
+// Decode implements the Codec interface
+func (stdCodec) Decode(r io.Reader, v any) error {
+	return json.NewDecoder(r).Decode(v)
+}
+
+// Encode implements the Codec interface
+func (stdCodec) Encode(w io.Writer, v any) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
+// codecs are the codecs by media type, JSON is resolved at call time so JSONCodec can be
+// replaced
+var codecs = map[string]func() Codec{
+	"application/json":      func() Codec { return JSONCodec },
+	"application/msgpack":   func() Codec { return MsgpackCodec },
+	"application/x-msgpack": func() Codec { return MsgpackCodec },
+}
+
+// RegisterCodec adds a codec for a media type to ReadBody and WriteBody, for example a
+// protobuf codec for "application/x-protobuf" using the generated message types of the app,
+// it must be called before the server starts
+func RegisterCodec(mediaType string, c Codec) {
+	codecs[mediaType] = func() Codec { return c }
+}
+
+// ReadBody reads a request body using the codec of the request content type, JSON is used
+// when the request has no content type, other content types return an unsupported errs error
+func ReadBody(r *http.Request, v any) error {
+	mt := "application/json"
+	if ct := r.Header.Get("Content-Type"); ct != "" {
+		var err error
+		if mt, _, err = mime.ParseMediaType(ct); err != nil {
+			return errs.New(errs.Unsupported, "unsupported_media_type", "unsupported content type")
+		}
+	}
+	c, ok := codecs[mt]
+	if !ok {
+		return errs.New(errs.Unsupported, "unsupported_media_type", "unsupported content type").
+			With("content_type", mt)
+	}
+	if err := c().Decode(r.Body, v); err != nil {
+		return errs.Wrap(err, errs.Invalid, "invalid_body", "invalid request body")
+	}
+	return nil
+}
+
+// WriteBody writes a response using the codec with the highest quality in the request Accept
+// header, media types with q=0 are not acceptable, JSON is used when no codec is accepted so
+// existing clients are unaffected
+func WriteBody(w http.ResponseWriter, r *http.Request, code int, payload any) error {
+	w.Header().Add("Vary", "Accept")
+	mt := acceptedCodec(r.Header.Get("Accept"))
+	if mt == "" || mt == "application/json" {
+		return WriteJSON(w, code, payload)
+	}
+	w.Header().Set("Content-Type", mt)
+	w.WriteHeader(code)
+	return codecs[mt]().Encode(w, payload)
+}
+
+// acceptedCodec returns the codec media type with the highest quality in an Accept header,
+// the first one wins on equal quality, returns an empty string when no codec is accepted
+func acceptedCodec(accept string) string {
+	best, bestQ := "", 0.0
+	for _, a := range strings.Split(accept, ",") {
+		mt, params, err := mime.ParseMediaType(strings.TrimSpace(a))
+		if err != nil {
+			continue
+		}
+		if _, ok := codecs[mt]; !ok {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		if q > bestQ {
+			best, bestQ = mt, q
+		}
+	}
+	return best
+}
@@ -0,0 +1,109 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type benchItem struct {
+	Attrs   map[string]string `json:"attrs"`
+	Created time.Time         `json:"created"`
+	ID      int64             `json:"id"`
+	Name    string            `json:"name"`
+	Price   float64           `json:"price"`
+	Tags    []string          `json:"tags"`
+}
+
+// benchPayloads are typical response payloads
+var benchPayloads = func() []struct {
+	name string
+	v    any
+} {
+	item := func(i int) benchItem {
+		return benchItem{
+			Attrs:   map[string]string{"color": "red", "size": "m"},
+			Created: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+			ID:      int64(i),
+			Name:    fmt.Sprintf("item %d", i),
+			Price:   float64(i) + 0.99,
+			Tags:    []string{"a", "b", "c"},
+		}
+	}
+	list := make([]benchItem, 100)
+	for i := range list {
+		list[i] = item(i)
+	}
+	return []struct {
+		name string
+		v    any
+	}{
+		{"small", map[string]string{"status": "ok"}},
+		{"item", item(1)},
+		{"list", map[string]any{"items": list, "total": len(list)}},
+	}
+}()
+
+// benchCodecs are the default and pluggable codecs
+var benchCodecs = []struct {
+	name string
+	c    Codec
+}{
+	{"json", stdCodec{}},
+	{"msgpack", MsgpackCodec},
+}
+
+func BenchmarkCodecEncode(b *testing.B) {
+	for _, c := range benchCodecs {
+		for _, p := range benchPayloads {
+			b.Run(c.name+"/"+p.name, func(b *testing.B) {
+				b.ReportAllocs()
+				for range b.N {
+					if err := c.c.Encode(io.Discard, p.v); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkCodecDecode(b *testing.B) {
+	for _, c := range benchCodecs {
+		for _, p := range benchPayloads {
+			var buf bytes.Buffer
+			if err := c.c.Encode(&buf, p.v); err != nil {
+				b.Fatal(err)
+			}
+			data := buf.Bytes()
+			b.Run(c.name+"/"+p.name, func(b *testing.B) {
+				b.ReportAllocs()
+				b.SetBytes(int64(len(data)))
+				for range b.N {
+					var v any
+					if err := c.c.Decode(bytes.NewReader(data), &v); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkWriteJSON(b *testing.B) {
+	for _, p := range benchPayloads {
+		b.Run(p.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for range b.N {
+				w := httptest.NewRecorder()
+				if err := WriteJSON(w, http.StatusOK, p.v); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package server
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/shayanderson/go-project/internal/errs"
+)
+
+func FuzzReadBody(f *testing.F) {
+	for _, s := range []struct {
+		ct   string
+		body []byte
+	}{
+		{"", []byte(`{"name":"a","tags":["x"],"n":1.5}`)},
+		{"application/json; charset=utf-8", []byte(`[1,null,true,"é"]`)},
+		{"application/json", []byte(`{"a":`)},
+		{"application/msgpack", []byte{0x81, 0xa1, 'k', 0x92, 0x01, 0xc3}},
+		{"application/x-msgpack", []byte{0xdb, 0x00, 0xff, 0xff, 0xff}},
+		{"text/plain", []byte("a")},
+		{"application/json; =", []byte("{}")},
+	} {
+		f.Add(s.ct, s.body)
+	}
+	f.Fuzz(func(t *testing.T, ct string, body []byte) {
+		r := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+		r.Header.Set("Content-Type", ct)
+
+		var v any
+		err := ReadBody(r, &v)
+		if err != nil {
+			// unsupported content types are unsupported errors and invalid bodies are invalid
+			// errors
+			want := errs.Invalid
+			if mt, _, err := mime.ParseMediaType(ct); ct != "" && (err != nil || codecs[mt] == nil) {
+				want = errs.Unsupported
+			}
+			var e *errs.Error
+			if !errors.As(err, &e) || e.Kind != want {
+				t.Fatalf("got error %v, want %v errs error", err, want)
+			}
+			return
+		}
+
+		// decoded JSON values must encode and decode to the same value
+		if mt, _, _ := mime.ParseMediaType(ct); ct != "" && mt != "application/json" {
+			return
+		}
+		var b bytes.Buffer
+		if err := JSONCodec.Encode(&b, v); err != nil {
+			t.Fatalf("encode %#v: %v", v, err)
+		}
+		var got any
+		if err := JSONCodec.Decode(&b, &got); err != nil {
+			t.Fatalf("decode %s: %v", b.Bytes(), err)
+		}
+		if !reflect.DeepEqual(got, v) {
+			t.Fatalf("got %#v, want %#v", got, v)
+		}
+	})
+}
+
+func FuzzReadJSON(f *testing.F) {
+	for _, s := range []string{`{}`, `{"a":[1,2,{"b":null}]}`, `"x"`, `1e400`, `{"a":1}{`, `[`} {
+		f.Add([]byte(s))
+	}
+	f.Fuzz(func(t *testing.T, body []byte) {
+		r := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+		var v any
+		if err := ReadJSON(r, &v); err != nil {
+			return
+		}
+		if err := JSONCodec.Encode(io.Discard, v); err != nil {
+			t.Fatalf("encode %#v: %v", v, err)
+		}
+	})
+}
+
+func TestReadBody(t *testing.T) {
+	tests := []struct {
+		name    string
+		ct      string
+		body    []byte
+		want    any
+		wantErr bool
+		kind    errs.Kind
+	}{
+		{"no content type", "", []byte(`{"a":1}`), map[string]any{"a": 1.0}, false, 0},
+		{"json", "application/json", []byte(`"a"`), "a", false, 0},
+		{"msgpack", "application/msgpack", []byte{0xa1, 'a'}, "a", false, 0},
+		{"invalid body", "application/json", []byte(`{`), nil, true, errs.Invalid},
+		{"unsupported", "text/plain", []byte("a"), nil, true, errs.Unsupported},
+		{"invalid content type", "application/json; =", []byte("{}"), nil, true, errs.Unsupported},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(tt.body))
+			if tt.ct != "" {
+				r.Header.Set("Content-Type", tt.ct)
+			}
+			var v any
+			err := ReadBody(r, &v)
+			if tt.wantErr {
+				var e *errs.Error
+				if !errors.As(err, &e) || e.Kind != tt.kind {
+					t.Fatalf("got error %v, want %v errs error", err, tt.kind)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(v, tt.want) {
+				t.Errorf("got %#v, want %#v", v, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteBody(t *testing.T) {
+	tests := []struct {
+		name   string
+		accept string
+		want   string
+	}{
+		{"no accept", "", "application/json"},
+		{"msgpack", "application/msgpack", "application/msgpack"},
+		{"json first", "application/json, application/msgpack", "application/json"},
+		{"msgpack first", "application/x-msgpack, application/json", "application/x-msgpack"},
+		{"q=0", "application/msgpack;q=0", "application/json"},
+		{"q=0 with json", "application/msgpack; q=0, application/json", "application/json"},
+		{"higher quality", "application/json;q=0.5, application/msgpack", "application/msgpack"},
+		{"lower quality", "application/msgpack;q=0.1, application/json", "application/json"},
+		{"invalid quality", "application/msgpack;q=x", "application/json"},
+		{"unknown", "text/html, */*", "application/json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			if err := WriteBody(w, r, http.StatusOK, map[string]any{"a": "b"}); err != nil {
+				t.Fatal(err)
+			}
+			mt, _, _ := mime.ParseMediaType(w.Header().Get("Content-Type"))
+			if mt != tt.want {
+				t.Errorf("got content type %q, want %q", mt, tt.want)
+			}
+			if got := w.Header().Get("Vary"); got != "Accept" {
+				t.Errorf("got vary %q, want %q", got, "Accept")
+			}
+
+			var v any
+			r = httptest.NewRequest(http.MethodPost, "/", w.Body)
+			r.Header.Set("Content-Type", mt)
+			if err := ReadBody(r, &v); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(v, map[string]any{"a": "b"}) {
+				t.Errorf("got body %#v", v)
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSOptions are the CORS options
+type CORSOptions struct {
+	// AllowCredentials allows requests with cookies and HTTP authentication, it requires an
+	// explicit origin list without "*"
+	AllowCredentials bool
+
+	// AllowedHeaders are the request headers allowed in cross-origin requests, "*" allows
+	// any header, default Accept, Authorization and Content-Type
+	AllowedHeaders []string
+
+	// AllowedMethods are the methods allowed in cross-origin requests, default GET, HEAD and
+	// POST
+	AllowedMethods []string
+
+	// AllowedOrigins are the allowed origins, for example "https://example.com", "*" allows
+	// any origin and "https://*.example.com" allows any subdomain
+	AllowedOrigins []string
+
+	// ExposedHeaders are the response headers readable by the client
+	ExposedHeaders []string
+
+	// MaxAge is how long preflight responses can be cached, 0 is not sent
+	MaxAge time.Duration
+}
+
+// CORSMiddleware handles cross-origin requests, preflight requests are answered with a 204
+// response without calling the next handler, preflight requests with a disallowed origin,
+// method or header receive a 403 response and other requests with a disallowed origin are
+// handled without CORS headers so the browser blocks the response
+// the middleware must be added to the router middleware stack so preflight requests are
+// answered before routing, panics when credentials are allowed for any origin
+func CORSMiddleware(opts CORSOptions) Middleware {
+	if len(opts.AllowedHeaders) == 0 {
+		opts.AllowedHeaders = []string{"Accept", "Authorization", "Content-Type"}
+	}
+	if len(opts.AllowedMethods) == 0 {
+		opts.AllowedMethods = []string{http.MethodGet, http.MethodHead, http.MethodPost}
+	}
+
+	anyHeader := false
+	headers := map[string]bool{}
+	for _, h := range opts.AllowedHeaders {
+		if h == "*" {
+			anyHeader = true
+		}
+		headers[http.CanonicalHeaderKey(h)] = true
+	}
+	methods := map[string]bool{}
+	allowMethods := make([]string, 0, len(opts.AllowedMethods))
+	for _, m := range opts.AllowedMethods {
+		m = strings.ToUpper(m)
+		methods[m] = true
+		allowMethods = append(allowMethods, m)
+	}
+	anyOrigin := false
+	for _, o := range opts.AllowedOrigins {
+		if o == "*" {
+			anyOrigin = true
+		}
+	}
+	if anyOrigin && opts.AllowCredentials {
+		// any site could make credentialed cross-origin reads
+		panic("server: CORS credentials require an explicit origin list")
+	}
+
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			preflight := r.Method == http.MethodOptions &&
+				r.Header.Get("Access-Control-Request-Method") != ""
+
+			h := w.Header()
+			h.Add("Vary", "Origin")
+			if preflight {
+				h.Add("Vary", "Access-Control-Request-Method")
+				h.Add("Vary", "Access-Control-Request-Headers")
+			}
+			if origin == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if !corsOriginAllowed(origin, opts.AllowedOrigins) {
+				if preflight {
+					_ = WriteJSON(
+						w,
+						http.StatusForbidden,
+						map[string]string{"error": "origin not allowed"},
+					)
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if anyOrigin {
+				h.Set("Access-Control-Allow-Origin", "*")
+			} else {
+				h.Set("Access-Control-Allow-Origin", origin)
+			}
+			if opts.AllowCredentials {
+				h.Set("Access-Control-Allow-Credentials", "true")
+			}
+
+			if !preflight {
+				if len(opts.ExposedHeaders) > 0 {
+					h.Set("Access-Control-Expose-Headers", strings.Join(opts.ExposedHeaders, ", "))
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			method := strings.ToUpper(r.Header.Get("Access-Control-Request-Method"))
+			reqHeaders := corsHeaderList(r.Header.Values("Access-Control-Request-Headers"))
+			if !methods[method] {
+				_ = WriteJSON(
+					w,
+					http.StatusForbidden,
+					map[string]string{"error": "method not allowed"},
+				)
+				return
+			}
+			if !anyHeader {
+				for _, rh := range reqHeaders {
+					if !headers[http.CanonicalHeaderKey(rh)] {
+						_ = WriteJSON(
+							w,
+							http.StatusForbidden,
+							map[string]string{"error": "header not allowed"},
+						)
+						return
+					}
+				}
+			}
+
+			h.Set("Access-Control-Allow-Methods", strings.Join(allowMethods, ", "))
+			if len(reqHeaders) > 0 {
+				// echo the requested headers, "*" is not supported with credentials
+				h.Set("Access-Control-Allow-Headers", strings.Join(reqHeaders, ", "))
+			}
+			if opts.MaxAge > 0 {
+				h.Set("Access-Control-Max-Age", strconv.Itoa(int(opts.MaxAge.Seconds())))
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// corsOriginAllowed checks if an origin matches the allowed origins
+func corsOriginAllowed(origin string, allowed []string) bool {
+	origin = strings.ToLower(origin)
+	for _, a := range allowed {
+		a = strings.ToLower(a)
+		if a == "*" || a == origin {
+			return true
+		}
+		if scheme, host, ok := strings.Cut(a, "://*."); ok {
+			// subdomain wildcard, the origin must have at least one subdomain label
+			prefix := scheme + "://"
+			if strings.HasPrefix(origin, prefix) &&
+				strings.HasSuffix(origin, "."+host) &&
+				len(origin) > len(prefix)+len(host)+1 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// corsHeaderList parses comma separated header names
+func corsHeaderList(values []string) []string {
+	var list []string
+	for _, v := range values {
+		for _, h := range strings.Split(v, ",") {
+			if h = strings.TrimSpace(h); h != "" {
+				list = append(list, h)
+			}
+		}
+	}
+	return list
+}
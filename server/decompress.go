@@ -0,0 +1,69 @@
+package server
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// gzipBody is a decompressed request body that closes the original body
+type gzipBody struct {
+	io.Reader
+	gz   *gzip.Reader
+	body io.ReadCloser
+}
+
+// Close implements the io.Closer interface
+func (b *gzipBody) Close() error {
+	b.gz.Close()
+	return b.body.Close()
+}
+
+// DecompressMiddleware transparently decompresses request bodies sent with
+// "Content-Encoding: gzip" so handlers read the decoded body, the decompressed body is
+// limited to max bytes, default 10MB so small bodies cannot expand without bound, and reads
+// over the limit return an *http.MaxBytesError, which is written as a 413 response, requests
+// with an invalid gzip body receive a 400 response and requests with another content encoding
+// receive a 415 response
+func DecompressMiddleware(max int64) Middleware {
+	if max <= 0 {
+		max = 10 << 20 // 10MB
+	}
+
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			switch strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding"))) {
+			case "", "identity":
+				next.ServeHTTP(w, r)
+				return
+			case "gzip", "x-gzip":
+			default:
+				_ = WriteJSON(
+					w,
+					http.StatusUnsupportedMediaType,
+					map[string]string{"error": "unsupported content encoding"},
+				)
+				return
+			}
+
+			gz, err := gzip.NewReader(r.Body)
+			if err != nil {
+				_ = WriteJSON(
+					w,
+					http.StatusBadRequest,
+					map[string]string{"error": "invalid gzip body"},
+				)
+				return
+			}
+
+			r.Body = http.MaxBytesReader(w, &gzipBody{Reader: gz, gz: gz, body: r.Body}, max)
+			r.ContentLength = -1
+			r.Header.Del("Content-Encoding")
+			r.Header.Del("Content-Length")
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
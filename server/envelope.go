@@ -0,0 +1,98 @@
+package server
+
+import (
+	"net/http"
+)
+
+// Envelope is the uniform response envelope, success responses set Data and error responses
+// set Error, Meta holds response metadata like pagination cursors
+type Envelope struct {
+	// Data is the success response payload
+	Data any `json:"data,omitempty"`
+
+	// Error is the error response payload
+	Error any `json:"error,omitempty"`
+
+	// Meta is the response metadata
+	Meta any `json:"meta,omitempty"`
+}
+
+// envelopeWriter is a http.ResponseWriter wrapper that marks the response as enveloped
+type envelopeWriter struct {
+	http.ResponseWriter
+	meta any
+}
+
+// Unwrap returns the underlying http.ResponseWriter, used by http.ResponseController
+func (e *envelopeWriter) Unwrap() http.ResponseWriter {
+	return e.ResponseWriter
+}
+
+// wrap wraps a payload written with the status code in an envelope
+func (e *envelopeWriter) wrap(code int, payload any) Envelope {
+	if env, ok := payload.(Envelope); ok {
+		return env
+	}
+	if code < http.StatusBadRequest {
+		return Envelope{Data: payload, Meta: e.meta}
+	}
+
+	// error payloads are written as {"error": "message", "code": "...", "meta": {...}}
+	env := Envelope{Error: payload, Meta: e.meta}
+	switch p := payload.(type) {
+	case map[string]string:
+		if msg, ok := p["error"]; ok {
+			err := map[string]string{"message": msg}
+			if code, ok := p["code"]; ok {
+				err["code"] = code
+			}
+			env.Error = err
+		}
+	case map[string]any:
+		if msg, ok := p["error"]; ok {
+			err := map[string]any{"message": msg}
+			if code, ok := p["code"]; ok {
+				err["code"] = code
+			}
+			env.Error = err
+			if meta, ok := p["meta"]; ok {
+				env.Meta = meta
+			}
+		}
+	}
+	return env
+}
+
+// envelopeOf returns the envelope writer wrapped by a response writer, or nil when the
+// response is not enveloped
+func envelopeOf(w http.ResponseWriter) *envelopeWriter {
+	for {
+		if e, ok := w.(*envelopeWriter); ok {
+			return e
+		}
+		u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return nil
+		}
+		w = u.Unwrap()
+	}
+}
+
+// EnvelopeMiddleware wraps JSON responses written with WriteJSON in an Envelope so success
+// and error responses share one shape, it can be added to the router middleware stack for
+// all routes or to single routes
+func EnvelopeMiddleware(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(&envelopeWriter{ResponseWriter: w}, r)
+	}
+
+	return http.HandlerFunc(fn)
+}
+
+// SetMeta sets the envelope metadata of the response, for example the next page cursor,
+// it must be called before WriteJSON and does nothing when the response is not enveloped
+func SetMeta(w http.ResponseWriter, meta any) {
+	if e := envelopeOf(w); e != nil {
+		e.meta = meta
+	}
+}
@@ -0,0 +1,84 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Group is a group of routes with a shared path prefix and middleware
+type Group struct {
+	mw     []Middleware
+	prefix string
+	r      *router
+}
+
+// Group returns a group of routes prefixed by prefix, for example "/api/v1", the group
+// middleware runs before the route middleware
+func (r *router) Group(prefix string, middleware ...Middleware) *Group {
+	return &Group{
+		mw:     append([]Middleware{}, middleware...),
+		prefix: strings.TrimSuffix(prefix, "/"),
+		r:      r,
+	}
+}
+
+// Group returns a sub-group of the group, the sub-group prefix and middleware are added to
+// the group prefix and middleware
+func (g *Group) Group(prefix string, middleware ...Middleware) *Group {
+	mw := make([]Middleware, 0, len(g.mw)+len(middleware))
+	mw = append(append(mw, g.mw...), middleware...)
+	return &Group{
+		mw:     mw,
+		prefix: g.prefix + strings.TrimSuffix(prefix, "/"),
+		r:      g.r,
+	}
+}
+
+// Use adds middleware to the group middleware stack, it applies to routes added after
+func (g *Group) Use(mw ...Middleware) {
+	g.mw = append(g.mw, mw...)
+}
+
+// handle adds a handler to the group
+func (g *Group) handle(
+	method, pattern string,
+	handler Handler,
+	middleware ...Middleware,
+) *RouteInfo {
+	return g.r.handle(method, g.prefix+pattern, handler, g.mw, middleware...)
+}
+
+// Delete adds a DELETE handler to the group
+func (g *Group) Delete(pattern string, handler Handler, middleware ...Middleware) *RouteInfo {
+	return g.handle(http.MethodDelete, pattern, handler, middleware...)
+}
+
+// Get adds a GET handler to the group
+func (g *Group) Get(pattern string, handler Handler, middleware ...Middleware) *RouteInfo {
+	return g.handle(http.MethodGet, pattern, handler, middleware...)
+}
+
+// Handle adds a handler to the group
+func (g *Group) Handle(
+	method string,
+	pattern string,
+	handler Handler,
+	middleware ...Middleware,
+) *RouteInfo {
+	return g.handle(method, pattern, handler, middleware...)
+}
+
+// Patch adds a PATCH handler to the group
+func (g *Group) Patch(pattern string, handler Handler, middleware ...Middleware) *RouteInfo {
+	return g.handle(http.MethodPatch, pattern, handler, middleware...)
+}
+
+// Post adds a POST handler to the group
+func (g *Group) Post(pattern string, handler Handler, middleware ...Middleware) *RouteInfo {
+	return g.handle(http.MethodPost, pattern, handler, middleware...)
+}
+
+// Put adds a PUT handler to the group
+func (g *Group) Put(pattern string, handler Handler, middleware ...Middleware) *RouteInfo {
+	return g.handle(http.MethodPut, pattern, handler, middleware...)
+}
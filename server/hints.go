@@ -0,0 +1,19 @@
+package server
+
+import (
+	"net/http"
+)
+
+// EarlyHints sends a 103 Early Hints response with Link headers so clients can preload
+// assets while the handler prepares the final response, links are Link header values like
+// "</app.css>; rel=preload; as=style", it must be called before the final response is
+// written, the Link headers are also sent with the final response
+func EarlyHints(w http.ResponseWriter, links ...string) {
+	if len(links) == 0 {
+		return
+	}
+	for _, l := range links {
+		w.Header().Add("Link", l)
+	}
+	w.WriteHeader(http.StatusEarlyHints)
+}
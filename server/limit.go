@@ -0,0 +1,73 @@
+package server
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// limiter limits the number of requests handled concurrently
+type limiter struct {
+	sem  chan struct{}
+	wait time.Duration
+}
+
+// newLimiter creates a new limiter with max in-flight requests, a request waits up to wait
+// for a free slot
+func newLimiter(max int, wait time.Duration) *limiter {
+	return &limiter{
+		sem:  make(chan struct{}, max),
+		wait: wait,
+	}
+}
+
+// acquire acquires a slot, returns false if no slot is available before the wait timeout or
+// the request is cancelled
+func (l *limiter) acquire(r *http.Request) bool {
+	select {
+	case l.sem <- struct{}{}:
+		return true
+	default:
+	}
+	if l.wait <= 0 {
+		return false
+	}
+
+	t := time.NewTimer(l.wait)
+	defer t.Stop()
+	select {
+	case l.sem <- struct{}{}:
+		return true
+	case <-t.C:
+		return false
+	case <-r.Context().Done():
+		return false
+	}
+}
+
+// release releases a slot
+func (l *limiter) release() {
+	<-l.sem
+}
+
+// handler wraps a handler with the limiter, requests over the limit receive a 503 response
+func (l *limiter) handler(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		if !l.acquire(r) {
+			slog.Warn("[http] max in-flight requests exceeded", "path", r.URL.Path)
+			w.Header().Set("Retry-After", strconv.Itoa(max(1, int(l.wait.Seconds()))))
+			_ = WriteJSON(
+				w,
+				http.StatusServiceUnavailable,
+				map[string]string{"error": "service unavailable"},
+			)
+			return
+		}
+		defer l.release()
+
+		next.ServeHTTP(w, r)
+	}
+
+	return http.HandlerFunc(fn)
+}
@@ -0,0 +1,43 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Matcher matches a request
+type Matcher func(*http.Request) bool
+
+// PathPrefix matches requests with a path equal to the prefix or below it, for example
+// "/metrics" matches "/metrics" and "/metrics/runtime" but not "/metricsx"
+func PathPrefix(prefix string) Matcher {
+	dir := strings.TrimSuffix(prefix, "/") + "/"
+	return func(r *http.Request) bool {
+		return r.URL.Path == prefix || strings.HasPrefix(r.URL.Path, dir)
+	}
+}
+
+// Only applies the middleware to requests matched by the matcher, other requests skip it
+func Only(match Matcher, mw Middleware) Middleware {
+	return func(next http.Handler) http.Handler {
+		h := mw(next)
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			if match(r) {
+				h.ServeHTTP(w, r)
+				return
+			}
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// Unless applies the middleware to all requests except requests with a path under the
+// prefix, for example to exclude health and metrics endpoints from auth or logging
+func Unless(prefix string, mw Middleware) Middleware {
+	match := PathPrefix(prefix)
+	return Only(func(r *http.Request) bool {
+		return !match(r)
+	}, mw)
+}
@@ -1,11 +1,16 @@
 package server
 
 import (
+	"bufio"
 	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
 	"runtime/debug"
+	"strconv"
 	"time"
+
+	"github.com/shayanderson/go-project/internal/metrics"
 )
 
 // Middleware is a http middleware
@@ -27,6 +32,21 @@ func (r responseWriter) Write(b []byte) (int, error) {
 	return (*r.w).Write(b)
 }
 
+// Hijack implements the http.Hijacker interface, the status is logged as 101 for upgraded
+// connections
+func (r responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, brw, err := http.NewResponseController(*r.w).Hijack()
+	if err == nil {
+		*r.status = http.StatusSwitchingProtocols
+	}
+	return conn, brw, err
+}
+
+// Unwrap returns the underlying http.ResponseWriter, used by http.ResponseController
+func (r responseWriter) Unwrap() http.ResponseWriter {
+	return *r.w
+}
+
 // WriteHeader implements the http.ResponseWriter interface
 func (r responseWriter) WriteHeader(status int) {
 	(*r.status) = status
@@ -70,6 +90,111 @@ func LoggerMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(fn)
 }
 
+// MetricsMiddleware returns middleware that records request count, duration and in-flight
+// requests in the metrics registry, count and duration are labeled by route pattern, requests
+// that match no route use the route label "unmatched"
+func MetricsMiddleware(reg *metrics.Registry) Middleware {
+	inFlight := reg.Gauge("http_requests_in_flight", "Number of in-flight HTTP requests", nil)
+
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			status := 0
+			rw := responseWriter{
+				w:      &w,
+				status: &status,
+			}
+			inFlight.Add(1)
+			done := false
+
+			defer func() {
+				inFlight.Add(-1)
+				switch {
+				case !done:
+					// panicking, the recover middleware responds with a 500
+					status = http.StatusInternalServerError
+				case status == 0:
+					status = http.StatusOK
+				}
+				route := routeLabel(r)
+				reg.Counter(
+					"http_requests_total",
+					"Total number of HTTP requests",
+					metrics.Labels{
+						"method": methodLabel(r.Method),
+						"route":  route,
+						"status": strconv.Itoa(status),
+					},
+				).Inc()
+				reg.Histogram(
+					"http_request_duration_seconds",
+					"HTTP request duration in seconds",
+					nil,
+					metrics.Labels{"method": methodLabel(r.Method), "route": route},
+				).Observe(time.Since(start).Seconds())
+			}()
+
+			next.ServeHTTP(rw, r)
+			done = true
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// unmatchedRoute is the metrics route label shared by requests that match no route
+const unmatchedRoute = "unmatched"
+
+// routeLabel returns the metrics label of the request route, the route pattern or
+// unmatchedRoute, so paths of unmatched requests never become labels
+func routeLabel(r *http.Request) string {
+	if route := Route(r); route != "" {
+		return route
+	}
+	return unmatchedRoute
+}
+
+// methodLabel returns the metrics label of a request method, clients can send any method so
+// non-standard methods share the label "other" to bound the label cardinality
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodConnect, http.MethodDelete, http.MethodGet, http.MethodHead,
+		http.MethodOptions, http.MethodPatch, http.MethodPost, http.MethodPut, http.MethodTrace:
+		return method
+	}
+	return "other"
+}
+
+// SLOMiddleware returns middleware that records request outcomes by route pattern in the SLO
+// evaluator, responses with a 5xx status are failures
+func SLOMiddleware(ev *metrics.SLOEvaluator) Middleware {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			status := 0
+			rw := responseWriter{
+				w:      &w,
+				status: &status,
+			}
+
+			done := false
+
+			defer func() {
+				if route := Route(r); route != "" {
+					// a panicking handler is a failure
+					ok := done && status < http.StatusInternalServerError
+					ev.Record(route, ok, time.Since(start))
+				}
+			}()
+
+			next.ServeHTTP(rw, r)
+			done = true
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
 // RecoverMiddleware recovers from panics
 func RecoverMiddleware(next http.Handler) http.Handler {
 	fn := func(w http.ResponseWriter, r *http.Request) {
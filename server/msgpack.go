@@ -0,0 +1,333 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// errMsgpack is returned for invalid or unsupported MessagePack data
+var errMsgpack = errors.New("msgpack: invalid or unsupported data")
+
+// msgpackMaxLen is the max length of a decoded MessagePack string, array or map, it guards
+// allocations on untrusted input
+const msgpackMaxLen = 16 << 20
+
+// MsgpackCodec is a Codec for MessagePack, values are converted through their JSON encoding
+// so the same json struct tags and Marshaler implementations apply, byte strings are decoded
+// as base64 strings like encoding/json encodes []byte
+var MsgpackCodec Codec = msgpackCodec{}
+
+// msgpackCodec is a MessagePack Codec
+type msgpackCodec struct{}
+
+// Decode implements the Codec interface
+func (msgpackCodec) Decode(r io.Reader, v any) error {
+	mr, ok := r.(msgpackReader)
+	if !ok {
+		mr = bufio.NewReader(r)
+	}
+	val, err := decodeMsgpack(mr, 0)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(val)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// Encode implements the Codec interface
+func (msgpackCodec) Encode(w io.Writer, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var val any
+	if err := dec.Decode(&val); err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := encodeMsgpack(&buf, val); err != nil {
+		return err
+	}
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// encodeMsgpack encodes a value decoded from JSON
+func encodeMsgpack(buf *bytes.Buffer, v any) error {
+	switch v := v.(type) {
+	case nil:
+		buf.WriteByte(0xc0)
+	case bool:
+		if v {
+			buf.WriteByte(0xc3)
+		} else {
+			buf.WriteByte(0xc2)
+		}
+	case json.Number:
+		if i, err := strconv.ParseInt(string(v), 10, 64); err == nil {
+			encodeMsgpackInt(buf, i)
+			return nil
+		}
+		if u, err := strconv.ParseUint(string(v), 10, 64); err == nil {
+			buf.WriteByte(0xcf)
+			buf.Write(binary.BigEndian.AppendUint64(nil, u))
+			return nil
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return err
+		}
+		buf.WriteByte(0xcb)
+		buf.Write(binary.BigEndian.AppendUint64(nil, math.Float64bits(f)))
+	case string:
+		encodeMsgpackLen(buf, len(v), 0xa0, 32, 0xd9, 0xda, 0xdb)
+		buf.WriteString(v)
+	case []any:
+		encodeMsgpackLen(buf, len(v), 0x90, 16, 0, 0xdc, 0xdd)
+		for _, e := range v {
+			if err := encodeMsgpack(buf, e); err != nil {
+				return err
+			}
+		}
+	case map[string]any:
+		encodeMsgpackLen(buf, len(v), 0x80, 16, 0, 0xde, 0xdf)
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			_ = encodeMsgpack(buf, k)
+			if err := encodeMsgpack(buf, v[k]); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("msgpack: unsupported type %T", v)
+	}
+	return nil
+}
+
+// encodeMsgpackInt encodes an integer in the smallest format
+func encodeMsgpackInt(buf *bytes.Buffer, i int64) {
+	switch {
+	case i >= 0 && i <= math.MaxInt8:
+		buf.WriteByte(byte(i))
+	case i >= -32 && i < 0:
+		buf.WriteByte(byte(int8(i)))
+	case i >= math.MinInt8 && i <= math.MaxInt8:
+		buf.WriteByte(0xd0)
+		buf.WriteByte(byte(int8(i)))
+	case i >= math.MinInt16 && i <= math.MaxInt16:
+		buf.WriteByte(0xd1)
+		buf.Write(binary.BigEndian.AppendUint16(nil, uint16(int16(i))))
+	case i >= math.MinInt32 && i <= math.MaxInt32:
+		buf.WriteByte(0xd2)
+		buf.Write(binary.BigEndian.AppendUint32(nil, uint32(int32(i))))
+	default:
+		buf.WriteByte(0xd3)
+		buf.Write(binary.BigEndian.AppendUint64(nil, uint64(i)))
+	}
+}
+
+// encodeMsgpackLen encodes a string, array or map length header, fix is the fixed format
+// prefix for lengths under fixMax and the 8 bit format is skipped when l8 is 0
+func encodeMsgpackLen(buf *bytes.Buffer, n int, fix byte, fixMax int, l8, l16, l32 byte) {
+	switch {
+	case n < fixMax:
+		buf.WriteByte(fix | byte(n))
+	case l8 != 0 && n <= math.MaxUint8:
+		buf.WriteByte(l8)
+		buf.WriteByte(byte(n))
+	case n <= math.MaxUint16:
+		buf.WriteByte(l16)
+		buf.Write(binary.BigEndian.AppendUint16(nil, uint16(n)))
+	default:
+		buf.WriteByte(l32)
+		buf.Write(binary.BigEndian.AppendUint32(nil, uint32(n)))
+	}
+}
+
+// msgpackReader reads MessagePack data
+type msgpackReader interface {
+	io.ByteReader
+	io.Reader
+}
+
+// decodeMsgpack decodes a MessagePack value into JSON compatible values
+func decodeMsgpack(r msgpackReader, depth int) (any, error) {
+	if depth > 100 {
+		return nil, errMsgpack
+	}
+	c, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case c <= 0x7f:
+		return int64(c), nil
+	case c >= 0xe0:
+		return int64(int8(c)), nil
+	case c&0xe0 == 0xa0:
+		return decodeMsgpackString(r, int(c&0x1f))
+	case c&0xf0 == 0x90:
+		return decodeMsgpackArray(r, int(c&0x0f), depth)
+	case c&0xf0 == 0x80:
+		return decodeMsgpackMap(r, int(c&0x0f), depth)
+	}
+
+	switch c {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6:
+		n, err := readMsgpackUint(r, 1<<(c-0xc4))
+		if err != nil {
+			return nil, err
+		}
+		b, err := readMsgpackBytes(r, n)
+		if err != nil {
+			return nil, err
+		}
+		return base64.StdEncoding.EncodeToString(b), nil
+	case 0xca:
+		u, err := readMsgpackUint(r, 4)
+		return float64(math.Float32frombits(uint32(u))), err
+	case 0xcb:
+		u, err := readMsgpackUint(r, 8)
+		return math.Float64frombits(u), err
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		return readMsgpackUint(r, 1<<(c-0xcc))
+	case 0xd0:
+		u, err := readMsgpackUint(r, 1)
+		return int64(int8(u)), err
+	case 0xd1:
+		u, err := readMsgpackUint(r, 2)
+		return int64(int16(u)), err
+	case 0xd2:
+		u, err := readMsgpackUint(r, 4)
+		return int64(int32(u)), err
+	case 0xd3:
+		u, err := readMsgpackUint(r, 8)
+		return int64(u), err
+	case 0xd9, 0xda, 0xdb:
+		n, err := readMsgpackUint(r, 1<<(c-0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return decodeMsgpackString(r, int(n))
+	case 0xdc, 0xdd:
+		n, err := readMsgpackUint(r, 2<<(c-0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return decodeMsgpackArray(r, int(n), depth)
+	case 0xde, 0xdf:
+		n, err := readMsgpackUint(r, 2<<(c-0xde))
+		if err != nil {
+			return nil, err
+		}
+		return decodeMsgpackMap(r, int(n), depth)
+	}
+	return nil, errMsgpack
+}
+
+// decodeMsgpackString decodes a string of n bytes
+func decodeMsgpackString(r msgpackReader, n int) (any, error) {
+	b, err := readMsgpackBytes(r, uint64(n))
+	return string(b), err
+}
+
+// decodeMsgpackArray decodes an array of n values
+func decodeMsgpackArray(r msgpackReader, n int, depth int) (any, error) {
+	// every value is at least one byte
+	if n > msgpackMaxLen || !msgpackRemaining(r, uint64(n)) {
+		return nil, errMsgpack
+	}
+	a := make([]any, 0, min(n, 1024))
+	for range n {
+		v, err := decodeMsgpack(r, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		a = append(a, v)
+	}
+	return a, nil
+}
+
+// decodeMsgpackMap decodes a map of n key value pairs, keys must be strings
+func decodeMsgpackMap(r msgpackReader, n int, depth int) (any, error) {
+	// every key and value is at least one byte
+	if n > msgpackMaxLen || !msgpackRemaining(r, 2*uint64(n)) {
+		return nil, errMsgpack
+	}
+	m := make(map[string]any, min(n, 1024))
+	for range n {
+		k, err := decodeMsgpack(r, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		ks, ok := k.(string)
+		if !ok {
+			return nil, errMsgpack
+		}
+		if m[ks], err = decodeMsgpack(r, depth+1); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// readMsgpackUint reads a big endian unsigned integer of size bytes
+func readMsgpackUint(r msgpackReader, size int) (uint64, error) {
+	var b [8]byte
+	if _, err := io.ReadFull(r, b[8-size:]); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(b[:]), nil
+}
+
+// readMsgpackBytes reads n bytes, the buffer grows with the bytes actually read so a length
+// prefix larger than the input does not allocate its length up front
+func readMsgpackBytes(r msgpackReader, n uint64) ([]byte, error) {
+	if n > msgpackMaxLen || !msgpackRemaining(r, n) {
+		return nil, errMsgpack
+	}
+	var b bytes.Buffer
+	b.Grow(int(min(n, 64<<10)))
+	if _, err := io.CopyN(&b, r, int64(n)); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// msgpackRemaining checks if at least n bytes remain when the reader knows its remaining
+// length, for example a *bytes.Reader, other readers are checked while reading
+func msgpackRemaining(r msgpackReader, n uint64) bool {
+	if l, ok := r.(interface{ Len() int }); ok {
+		return n <= uint64(l.Len())
+	}
+	return true
+}
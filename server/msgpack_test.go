@@ -0,0 +1,143 @@
+package server
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+type msgpackValue struct {
+	Bin   []byte         `json:"bin"`
+	Float float64        `json:"float"`
+	Int   int64          `json:"int"`
+	List  []any          `json:"list"`
+	Map   map[string]int `json:"map"`
+	Name  string         `json:"name"`
+	Nil   *string        `json:"nil"`
+	OK    bool           `json:"ok"`
+	Uint  uint64         `json:"uint"`
+}
+
+func TestMsgpackRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		v    msgpackValue
+	}{
+		{"zero", msgpackValue{}},
+		{
+			"values",
+			msgpackValue{
+				Bin:   []byte{0, 1, 2},
+				Float: 1.5,
+				Int:   -129,
+				List:  []any{"a", true, nil, 1.25},
+				Map:   map[string]int{"a": 1, "b": -1},
+				Name:  "name",
+				OK:    true,
+				Uint:  1<<64 - 1,
+			},
+		},
+		{"long string", msgpackValue{Name: strings.Repeat("x", 70000)}},
+		{"int bounds", msgpackValue{Int: -1 << 63, Uint: 1 << 32}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := MsgpackCodec.Encode(&buf, tt.v); err != nil {
+				t.Fatalf("encode: %v", err)
+			}
+			var got msgpackValue
+			if err := MsgpackCodec.Decode(&buf, &got); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.v) {
+				t.Errorf("got %+v, want %+v", got, tt.v)
+			}
+		})
+	}
+}
+
+func TestMsgpackDecode(t *testing.T) {
+	tests := []struct {
+		name string
+		in   []byte
+		want any
+	}{
+		{"positive fixint", []byte{0x05}, 5.0},
+		{"negative fixint", []byte{0xff}, -1.0},
+		{"fixstr", []byte{0xa2, 'h', 'i'}, "hi"},
+		{"str8", []byte{0xd9, 0x01, 'a'}, "a"},
+		{"nil", []byte{0xc0}, nil},
+		{"true", []byte{0xc3}, true},
+		{"uint16", []byte{0xcd, 0x01, 0x00}, 256.0},
+		{"int8", []byte{0xd0, 0x80}, -128.0},
+		{"fixarray", []byte{0x92, 0x01, 0xc2}, []any{1.0, false}},
+		{"fixmap", []byte{0x81, 0xa1, 'k', 0x02}, map[string]any{"k": 2.0}},
+		{"bin8", []byte{0xc4, 0x02, 0x01, 0x02}, "AQI="},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got any
+			if err := MsgpackCodec.Decode(bytes.NewReader(tt.in), &got); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMsgpackDecodeInvalid(t *testing.T) {
+	deep := append(bytes.Repeat([]byte{0x91}, 200), 0xc0)
+	tests := []struct {
+		name string
+		in   []byte
+	}{
+		{"empty", nil},
+		{"unsupported type", []byte{0xc1}},
+		{"truncated str", []byte{0xa3, 'a'}},
+		{"truncated length", []byte{0xda, 0x01}},
+		{"str32 over remaining input", []byte{0xdb, 0x00, 0xff, 0xff, 0xff, 'a'}},
+		{"bin32 over max length", []byte{0xc6, 0xff, 0xff, 0xff, 0xff}},
+		{"array32 over remaining input", []byte{0xdd, 0x00, 0x10, 0x00, 0x00, 0xc0}},
+		{"map32 over max length", []byte{0xdf, 0xff, 0xff, 0xff, 0xff}},
+		{"non string key", []byte{0x81, 0x01, 0x02}},
+		{"too deep", deep},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got any
+			if err := MsgpackCodec.Decode(bytes.NewReader(tt.in), &got); err == nil {
+				t.Errorf("expected error, got %#v", got)
+			}
+		})
+	}
+}
+
+func TestMsgpackDecodeStreamLength(t *testing.T) {
+	// a reader without a known length is read incrementally, so a large length prefix with
+	// little data fails without allocating the prefix length
+	r := io.MultiReader(
+		bytes.NewReader([]byte{0xdb, 0x00, 0xff, 0xff, 0xff}),
+		strings.NewReader("ab"),
+	)
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	var got any
+	err := MsgpackCodec.Decode(r, &got)
+	runtime.ReadMemStats(&after)
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("got error %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+	if n := after.TotalAlloc - before.TotalAlloc; n > 1<<20 {
+		t.Errorf("allocated %d bytes", n)
+	}
+}
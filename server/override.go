@@ -0,0 +1,70 @@
+package server
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// MethodOverrideHeader is the request header used to override the request method
+const MethodOverrideHeader = "X-HTTP-Method-Override"
+
+// MethodOverrideField is the form field used to override the request method
+const MethodOverrideField = "_method"
+
+// MethodOverrideMiddleware overrides the method of POST requests using the
+// X-HTTP-Method-Override header or the _method form field, for clients that can only send
+// GET and POST requests
+// only POST requests are overridden and only to the allowed methods, which default to PUT,
+// PATCH and DELETE, requests with any other override receive a 400 response
+// the middleware must be added to the router middleware stack so the override is applied
+// before routing
+func MethodOverrideMiddleware(methods ...string) Middleware {
+	if len(methods) == 0 {
+		methods = []string{http.MethodPut, http.MethodPatch, http.MethodDelete}
+	}
+	allowed := make(map[string]bool, len(methods))
+	for _, m := range methods {
+		allowed[strings.ToUpper(m)] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			m := r.Header.Get(MethodOverrideHeader)
+			if m == "" && isFormRequest(r) {
+				m = r.PostFormValue(MethodOverrideField)
+			}
+			if m == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			m = strings.ToUpper(strings.TrimSpace(m))
+			if !allowed[m] {
+				_ = WriteJSON(
+					w,
+					http.StatusBadRequest,
+					map[string]string{"error": "invalid method override"},
+				)
+				return
+			}
+
+			r.Method = m
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// isFormRequest checks if the request body is URL encoded form data, multipart forms are not
+// parsed to avoid buffering uploads before the handler runs
+func isFormRequest(r *http.Request) bool {
+	ct, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && ct == "application/x-www-form-urlencoded"
+}
@@ -0,0 +1,163 @@
+package server
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/shayanderson/go-project/internal/errs"
+	"github.com/shayanderson/go-project/internal/patch"
+)
+
+// ReadPatch reads a PATCH request body and applies it to v, a pointer to the existing
+// entity, "application/merge-patch+json" and "application/json" bodies are RFC 7386 merge
+// patches and "application/json-patch+json" bodies are RFC 6902 JSON Patches, fields are
+// matched by their JSON names and handlers must validate v after the patch is applied, for
+// example to reject changes of read-only fields, invalid patches return an invalid errs error
+// and v is not changed
+func ReadPatch(r *http.Request, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return errors.New("server: ReadPatch requires a non-nil pointer")
+	}
+
+	mt := "application/merge-patch+json"
+	if ct := r.Header.Get("Content-Type"); ct != "" {
+		mt, _, _ = mime.ParseMediaType(ct)
+	}
+	var apply func(doc, patch []byte) ([]byte, error)
+	switch mt {
+	case "application/merge-patch+json", "application/json":
+		apply = patch.Merge
+	case "application/json-patch+json":
+		apply = patch.Apply
+	default:
+		return errs.New(errs.Unsupported, "unsupported_media_type", "unsupported content type").
+			With("content_type", mt)
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	doc, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	patched, err := apply(doc, body)
+	if err != nil {
+		if errors.Is(err, patch.ErrTest) {
+			return errs.Wrap(err, errs.Conflict, "patch_test_failed", "patch test failed")
+		}
+		return errs.Wrap(err, errs.Invalid, "invalid_patch", "invalid patch")
+	}
+
+	// decode into a copy with the JSON fields removed by the patch reset, so fields JSON
+	// cannot carry, like unexported and "-" fields, are kept
+	nv := reflect.New(rv.Elem().Type())
+	nv.Elem().Set(rv.Elem())
+	resetJSONFields(nv.Elem(), patched)
+	if err := json.Unmarshal(patched, nv.Interface()); err != nil {
+		return errs.Wrap(err, errs.Invalid, "invalid_patch", "invalid patch")
+	}
+	rv.Elem().Set(nv.Elem())
+	return nil
+}
+
+// unmarshalerTypes are the interfaces of types decoding themselves
+var unmarshalerTypes = []reflect.Type{
+	reflect.TypeFor[json.Unmarshaler](),
+	reflect.TypeFor[encoding.TextUnmarshaler](),
+}
+
+// resetJSONFields prepares v for decoding doc, JSON fields missing from doc are reset,
+// nested structs are prepared field by field so their unexported and "-" fields are kept,
+// other values are reset so they are decoded fresh, nested struct pointers are copied first
+// so the original value is never changed
+func resetJSONFields(v reflect.Value, doc json.RawMessage) {
+	if v.Kind() != reflect.Struct || decodesItself(v.Type()) {
+		v.SetZero()
+		return
+	}
+	var fields map[string]json.RawMessage
+	_ = json.Unmarshal(doc, &fields)
+
+	t := v.Type()
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		f := v.Field(i)
+		tag := sf.Tag.Get("json")
+		if !f.CanSet() || tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		if sf.Anonymous && name == "" {
+			// embedded struct fields are promoted to the parent object
+			if f.Kind() == reflect.Pointer {
+				resetJSONPointer(f, doc)
+			} else {
+				resetJSONFields(f, doc)
+			}
+			continue
+		}
+
+		if name == "" {
+			name = sf.Name
+		}
+		raw, ok := lookupField(fields, name)
+		switch {
+		case !ok || string(raw) == "null":
+			f.SetZero()
+		case f.Kind() == reflect.Struct:
+			resetJSONFields(f, raw)
+		case f.Kind() == reflect.Pointer:
+			resetJSONPointer(f, raw)
+		default:
+			f.SetZero()
+		}
+	}
+}
+
+// resetJSONPointer prepares a pointer for decoding doc, a struct is copied to a new pointer
+// before it is prepared, other pointers are reset
+func resetJSONPointer(f reflect.Value, doc json.RawMessage) {
+	et := f.Type().Elem()
+	if f.IsNil() || et.Kind() != reflect.Struct || decodesItself(et) {
+		f.SetZero()
+		return
+	}
+	p := reflect.New(et)
+	p.Elem().Set(f.Elem())
+	resetJSONFields(p.Elem(), doc)
+	f.Set(p)
+}
+
+// lookupField returns the value of an object key, matched like encoding/json, exact match
+// first, then case-insensitive
+func lookupField(fields map[string]json.RawMessage, name string) (json.RawMessage, bool) {
+	if raw, ok := fields[name]; ok {
+		return raw, true
+	}
+	for k, raw := range fields {
+		if strings.EqualFold(k, name) {
+			return raw, true
+		}
+	}
+	return nil, false
+}
+
+// decodesItself checks if a type or its pointer implements a JSON or text unmarshaler
+func decodesItself(t reflect.Type) bool {
+	for _, u := range unmarshalerTypes {
+		if t.Implements(u) || reflect.PointerTo(t).Implements(u) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,153 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shayanderson/go-project/internal/errs"
+)
+
+type patchAddress struct {
+	City string `json:"city"`
+	Zip  string `json:"zip,omitempty"`
+	geo  string
+}
+
+type patchEntity struct {
+	Address *patchAddress     `json:"address,omitempty"`
+	Home    patchAddress      `json:"home"`
+	Name    string            `json:"name"`
+	Secret  string            `json:"-"`
+	Tags    map[string]string `json:"tags,omitempty"`
+	Updated time.Time         `json:"updated"`
+	owner   string
+}
+
+func patchRequest(ct, body string) *http.Request {
+	r := httptest.NewRequest(http.MethodPatch, "/", strings.NewReader(body))
+	if ct != "" {
+		r.Header.Set("Content-Type", ct)
+	}
+	return r
+}
+
+func newPatchEntity() patchEntity {
+	return patchEntity{
+		Address: &patchAddress{City: "a", Zip: "1", geo: "g"},
+		Home:    patchAddress{City: "h", geo: "hg"},
+		Name:    "a",
+		Secret:  "s",
+		Tags:    map[string]string{"x": "1", "y": "2"},
+		Updated: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		owner:   "o",
+	}
+}
+
+func TestReadPatch(t *testing.T) {
+	tests := []struct {
+		name string
+		ct   string
+		body string
+		want func(e *patchEntity)
+	}{
+		{
+			name: "merge keeps non JSON fields",
+			ct:   "application/merge-patch+json",
+			body: `{"name":"b"}`,
+			want: func(e *patchEntity) { e.Name = "b" },
+		},
+		{
+			name: "merge removes field",
+			ct:   "application/merge-patch+json",
+			body: `{"address":{"zip":null},"tags":{"x":null}}`,
+			want: func(e *patchEntity) {
+				e.Address.Zip = ""
+				e.Tags = map[string]string{"y": "2"}
+			},
+		},
+		{
+			name: "merge removes pointer",
+			ct:   "application/merge-patch+json",
+			body: `{"address":null}`,
+			want: func(e *patchEntity) { e.Address = nil },
+		},
+		{
+			name: "merge replaces time",
+			ct:   "application/json",
+			body: `{"updated":"2025-01-01T00:00:00Z"}`,
+			want: func(e *patchEntity) { e.Updated = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC) },
+		},
+		{
+			name: "json patch",
+			ct:   "application/json-patch+json",
+			body: `[{"op":"replace","path":"/home/city","value":"n"},` +
+				`{"op":"remove","path":"/address/zip"}]`,
+			want: func(e *patchEntity) {
+				e.Home.City = "n"
+				e.Address.Zip = ""
+			},
+		},
+		{
+			name: "default content type",
+			body: `{"name":"c"}`,
+			want: func(e *patchEntity) { e.Name = "c" },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newPatchEntity()
+			orig := e.Address
+			if err := ReadPatch(patchRequest(tt.ct, tt.body), &e); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			want := newPatchEntity()
+			tt.want(&want)
+			if !reflect.DeepEqual(e, want) {
+				t.Errorf("got %+v, want %+v", e, want)
+			}
+			if *orig != *newPatchEntity().Address {
+				t.Errorf("original nested value changed: %+v", *orig)
+			}
+		})
+	}
+}
+
+func TestReadPatchErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		ct   string
+		body string
+		kind errs.Kind
+	}{
+		{"unsupported content type", "text/plain", `{}`, errs.Unsupported},
+		{"invalid merge patch", "application/merge-patch+json", `{`, errs.Invalid},
+		{"invalid json patch", "application/json-patch+json", `{}`, errs.Invalid},
+		{"invalid type", "application/merge-patch+json", `{"name":1}`, errs.Invalid},
+		{
+			"failed test op",
+			"application/json-patch+json",
+			`[{"op":"test","path":"/name","value":"x"}]`,
+			errs.Conflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newPatchEntity()
+			err := ReadPatch(patchRequest(tt.ct, tt.body), &e)
+			var ee *errs.Error
+			if !errors.As(err, &ee) || ee.Kind != tt.kind {
+				t.Fatalf("got error %v, want kind %s", err, tt.kind)
+			}
+			if !reflect.DeepEqual(e, newPatchEntity()) {
+				t.Errorf("value changed on error: %+v", e)
+			}
+		})
+	}
+}
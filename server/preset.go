@@ -0,0 +1,55 @@
+package server
+
+import "time"
+
+// the timeouts are layered, ReadHeaderTimeout (3s) bounds slow headers, ReadTimeout bounds
+// the whole request read, WriteTimeout bounds the handler and response and IdleTimeout
+// bounds keep-alive connections between requests, MaxBodySize bounds what ReadTimeout has to
+// cover, the presets below set coherent combinations and can be adjusted before use
+
+// PresetPublicAPI returns options for an API exposed to the internet, with short timeouts and
+// small body and header limits so slow or oversized clients cannot hold connections
+func PresetPublicAPI() Options {
+	return Options{
+		IdleTimeout:    60 * time.Second,
+		MaxBodySize:    1 << 20,  // 1MB
+		MaxHeaderBytes: 64 << 10, // 64KB
+		MaxHeaderCount: 100,
+		ReadTimeout:    10 * time.Second,
+		WriteTimeout:   15 * time.Second,
+	}
+}
+
+// PresetInternalAPI returns options for an API called by trusted services, with more
+// generous timeouts and body limit and long lived keep-alive connections
+func PresetInternalAPI() Options {
+	return Options{
+		IdleTimeout:  5 * time.Minute,
+		MaxBodySize:  10 << 20, // 10MB
+		ReadTimeout:  30 * time.Second,
+		WriteTimeout: 60 * time.Second,
+	}
+}
+
+// PresetLongPolling returns options for long polling or streaming responses, the write
+// timeout covers the longest poll so handlers must return or flush before it, request
+// bodies are expected to be small
+func PresetLongPolling() Options {
+	return Options{
+		IdleTimeout:  2 * time.Minute,
+		MaxBodySize:  64 << 10, // 64KB
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 2 * time.Minute,
+	}
+}
+
+// PresetUpload returns options for large uploads, the read timeout allows a 100MB body at
+// roughly 200KB/s and the write timeout covers storing it after the read
+func PresetUpload() Options {
+	return Options{
+		IdleTimeout:  60 * time.Second,
+		MaxBodySize:  100 << 20, // 100MB
+		ReadTimeout:  10 * time.Minute,
+		WriteTimeout: 12 * time.Minute,
+	}
+}
@@ -1,58 +1,239 @@
 package server
 
-import "net/http"
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sort"
+)
+
+// routeKey is the request context key for the matched route holder
+type routeKey struct{}
+
+// route holds the pattern of the route matched for a request
+type route struct {
+	pattern string
+}
+
+// Route returns the pattern of the route matched for the request, for example
+// "GET /example/{name}", returns an empty string when no route matched or when called before
+// routing, so middleware must call it after the next handler returns
+func Route(r *http.Request) string {
+	if rt, ok := r.Context().Value(routeKey{}).(*route); ok {
+		return rt.pattern
+	}
+	return ""
+}
+
+// RouteDoc is route documentation metadata
+type RouteDoc struct {
+	// Deprecated marks the route as deprecated
+	Deprecated bool
+
+	// Description is the long route description
+	Description string
+
+	// Request is an example value of the request body model
+	Request any
+
+	// Response is an example value of the response body model
+	Response any
+
+	// Summary is the short route summary
+	Summary string
+
+	// Tags are the route tags used to group routes
+	Tags []string
+}
+
+// RouteInfo is a registered route
+type RouteInfo struct {
+	// Doc is the route documentation
+	Doc RouteDoc
+
+	// Method is the route HTTP method
+	Method string
+
+	// Pattern is the route path pattern
+	Pattern string
+
+	// middleware is the route middleware
+	middleware []Middleware
+}
+
+// Describe sets the route documentation
+func (ri *RouteInfo) Describe(doc RouteDoc) *RouteInfo {
+	ri.Doc = doc
+	return ri
+}
+
+// routeHandler is the swappable handler of a registered route, the mux cannot unregister
+// patterns so Override replaces the handler instead
+type routeHandler struct {
+	group []Middleware
+	h     http.Handler
+	ri    *RouteInfo
+}
 
 // router is an http router
 type router struct {
-	mux *http.ServeMux
-	mw  []Middleware
+	handlers        map[string]*routeHandler
+	mux             *http.ServeMux
+	mw              []Middleware
+	routes          []*RouteInfo
+	versionFallback string
+	versionHeader   string
+	versions        map[string]bool
 }
 
 // newRouter creates a new router
 func newRouter(mux *http.ServeMux) *router {
 	return &router{
-		mux: mux,
-		mw:  []Middleware{},
+		handlers: map[string]*routeHandler{},
+		mux:      mux,
+		mw:       []Middleware{},
 	}
 }
 
-// handle adds a handler to the router
-func (r *router) handle(method, pattern string, handler Handler, middleware ...Middleware) {
+// chain wraps a handler with route middleware
+func chain(handler Handler, middleware []Middleware) http.Handler {
 	var h http.Handler = handler
 	for i := len(middleware) - 1; i >= 0; i-- {
 		h = middleware[i](h)
 	}
-	r.mux.Handle(method+" "+pattern, h)
+	return h
+}
+
+// routeMiddleware returns the group middleware followed by the route middleware
+func routeMiddleware(group, middleware []Middleware) []Middleware {
+	mw := make([]Middleware, 0, len(group)+len(middleware))
+	return append(append(mw, group...), middleware...)
+}
+
+// handle adds a handler to the router, the group middleware runs before the route middleware
+// and is kept when the route is overridden
+func (r *router) handle(
+	method, pattern string,
+	handler Handler,
+	group []Middleware,
+	middleware ...Middleware,
+) *RouteInfo {
+	p := method + " " + pattern
+	mw := routeMiddleware(group, middleware)
+	rh := &routeHandler{group: group, h: chain(handler, mw)}
+	r.mux.Handle(p, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if rt, ok := req.Context().Value(routeKey{}).(*route); ok {
+			rt.pattern = p
+		}
+		rh.h.ServeHTTP(w, req)
+	}))
+
+	rh.ri = &RouteInfo{
+		Method:     method,
+		Pattern:    pattern,
+		middleware: mw,
+	}
+	r.handlers[p] = rh
+	r.routes = append(r.routes, rh.ri)
+	return rh.ri
 }
 
 // Delete adds a DELETE handler to the router
-func (r *router) Delete(pattern string, handler Handler, middleware ...Middleware) {
-	r.handle(http.MethodDelete, pattern, handler, middleware...)
+func (r *router) Delete(pattern string, handler Handler, middleware ...Middleware) *RouteInfo {
+	return r.handle(http.MethodDelete, pattern, handler, nil, middleware...)
 }
 
 // Get adds a GET handler to the router
-func (r *router) Get(pattern string, handler Handler, middleware ...Middleware) {
-	r.handle(http.MethodGet, pattern, handler, middleware...)
+func (r *router) Get(pattern string, handler Handler, middleware ...Middleware) *RouteInfo {
+	return r.handle(http.MethodGet, pattern, handler, nil, middleware...)
 }
 
 // Handle adds a handler to the router
-func (r *router) Handle(method string, pattern string, handler Handler, middleware ...Middleware) {
-	r.handle(method, pattern, handler, middleware...)
+func (r *router) Handle(
+	method string,
+	pattern string,
+	handler Handler,
+	middleware ...Middleware,
+) *RouteInfo {
+	return r.handle(method, pattern, handler, nil, middleware...)
+}
+
+// HandleIfAbsent adds a handler to the router unless the pattern is already registered or
+// conflicts with a registered pattern, for optional routes like debug endpoints that must
+// not collide with app routes, returns false when the handler was not added
+func (r *router) HandleIfAbsent(
+	method string,
+	pattern string,
+	handler Handler,
+	middleware ...Middleware,
+) (ri *RouteInfo, ok bool) {
+	p := method + " " + pattern
+	if _, exists := r.handlers[p]; exists {
+		slog.Debug("route already registered", "pattern", p)
+		return nil, false
+	}
+	// invalid patterns panic on an empty mux like they do in Handle, so only conflicts with
+	// registered patterns are recovered below
+	http.NewServeMux().Handle(p, http.NotFoundHandler())
+	defer func() {
+		// the mux panics on conflicting patterns before any route state is changed
+		if err := recover(); err != nil {
+			slog.Debug("route conflicts with registered route", "pattern", p, "err", err)
+			ri, ok = nil, false
+		}
+	}()
+	return r.handle(method, pattern, handler, nil, middleware...), true
+}
+
+// Override replaces the handler and route middleware of a registered route, or adds the
+// handler when the pattern is not registered, the middleware of the group the route was added
+// to, like authentication, is kept, the route documentation is reset, routes must be
+// overridden before the server starts
+func (r *router) Override(
+	method string,
+	pattern string,
+	handler Handler,
+	middleware ...Middleware,
+) *RouteInfo {
+	rh, ok := r.handlers[method+" "+pattern]
+	if !ok {
+		return r.handle(method, pattern, handler, nil, middleware...)
+	}
+	mw := routeMiddleware(rh.group, middleware)
+	rh.h = chain(handler, mw)
+	rh.ri.Doc = RouteDoc{}
+	rh.ri.middleware = mw
+	return rh.ri
 }
 
 // Patch adds a PATCH handler to the router
-func (r *router) Patch(pattern string, handler Handler, middleware ...Middleware) {
-	r.handle(http.MethodPatch, pattern, handler, middleware...)
+func (r *router) Patch(pattern string, handler Handler, middleware ...Middleware) *RouteInfo {
+	return r.handle(http.MethodPatch, pattern, handler, nil, middleware...)
 }
 
 // Post adds a POST handler to the router
-func (r *router) Post(pattern string, handler Handler, middleware ...Middleware) {
-	r.handle(http.MethodPost, pattern, handler, middleware...)
+func (r *router) Post(pattern string, handler Handler, middleware ...Middleware) *RouteInfo {
+	return r.handle(http.MethodPost, pattern, handler, nil, middleware...)
 }
 
 // Put adds a PUT handler to the router
-func (r *router) Put(pattern string, handler Handler, middleware ...Middleware) {
-	r.handle(http.MethodPut, pattern, handler, middleware...)
+func (r *router) Put(pattern string, handler Handler, middleware ...Middleware) *RouteInfo {
+	return r.handle(http.MethodPut, pattern, handler, nil, middleware...)
+}
+
+// Routes returns the registered routes sorted by pattern and method
+func (r *router) Routes() []RouteInfo {
+	routes := make([]RouteInfo, 0, len(r.routes))
+	for _, ri := range r.routes {
+		routes = append(routes, *ri)
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Pattern != routes[j].Pattern {
+			return routes[i].Pattern < routes[j].Pattern
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	return routes
 }
 
 // Use adds middleware to the router middleware stack
@@ -63,8 +244,11 @@ func (r *router) Use(mw ...Middleware) {
 // ServeHTTP implements the http.Handler interface
 func (r *router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	var h http.Handler = r.mux
+	if r.versionHeader != "" {
+		h = r.negotiateVersion(h)
+	}
 	for i := len(r.mw) - 1; i >= 0; i-- {
 		h = r.mw[i](h)
 	}
-	h.ServeHTTP(w, req)
+	h.ServeHTTP(w, req.WithContext(context.WithValue(req.Context(), routeKey{}, &route{})))
 }
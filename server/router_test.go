@@ -0,0 +1,119 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// requireHeader is a middleware rejecting requests without the X-Token header
+func requireHeader(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Token") == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// setHeader returns a middleware setting a response header
+func setHeader(k, v string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set(k, v)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// text returns a handler writing s
+func text(s string) Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		_, err := w.Write([]byte(s))
+		return err
+	}
+}
+
+func TestOverride(t *testing.T) {
+	r := newRouter(http.NewServeMux())
+	g := r.Group("/admin", requireHeader)
+	g.Get("/config", text("old"), setHeader("X-Route", "old"))
+	r.Get("/public", text("old"), setHeader("X-Route", "old"))
+	r.Override(http.MethodGet, "/admin/config", text("new"), setHeader("X-Route", "new"))
+	r.Override(http.MethodGet, "/public", text("new"))
+	r.Override(http.MethodGet, "/added", text("added"))
+
+	tests := []struct {
+		name   string
+		path   string
+		token  string
+		status int
+		body   string
+		route  string
+	}{
+		{"group middleware kept", "/admin/config", "", http.StatusUnauthorized, "", ""},
+		{"group route overridden", "/admin/config", "t", http.StatusOK, "new", "new"},
+		{"route middleware replaced", "/public", "", http.StatusOK, "new", ""},
+		{"absent route added", "/added", "", http.StatusOK, "added", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.token != "" {
+				req.Header.Set("X-Token", tt.token)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.status {
+				t.Errorf("got status %d, want %d", w.Code, tt.status)
+			}
+			if w.Body.String() != tt.body {
+				t.Errorf("got body %q, want %q", w.Body.String(), tt.body)
+			}
+			if got := w.Header().Get("X-Route"); got != tt.route {
+				t.Errorf("got route header %q, want %q", got, tt.route)
+			}
+		})
+	}
+
+	for _, ri := range r.Routes() {
+		if ri.Pattern == "/admin/config" && len(ri.middleware) != 2 {
+			t.Errorf("got %d middleware, want 2", len(ri.middleware))
+		}
+	}
+}
+
+func TestHandleIfAbsent(t *testing.T) {
+	r := newRouter(http.NewServeMux())
+	r.Get("/items/{id}", text("item"))
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		want   bool
+	}{
+		{"registered", http.MethodGet, "/items/{id}", false},
+		{"conflict", http.MethodGet, "/items/{name}", false},
+		{"absent", http.MethodGet, "/debug", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := r.HandleIfAbsent(tt.method, tt.path, text("x")); ok != tt.want {
+				t.Errorf("got %v, want %v", ok, tt.want)
+			}
+		})
+	}
+
+	t.Run("invalid pattern panics", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("got no panic, want panic")
+			}
+		}()
+		r.HandleIfAbsent(http.MethodGet, "/items/{", text("x"))
+	})
+}
@@ -2,13 +2,17 @@ package server
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/shayanderson/go-project/app/config"
+	"github.com/shayanderson/go-project/internal/errs"
 )
 
 // Handler is a http handler that returns an error
@@ -17,58 +21,329 @@ type Handler func(http.ResponseWriter, *http.Request) error
 // ServeHTTP implements the http.Handler interface
 func (r Handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	if err := r(w, req); err != nil {
-		// #todo use cust error handler
+		writeError(w, err)
+	}
+}
+
+// errorStatus maps error kinds to HTTP status codes
+var errorStatus = map[errs.Kind]int{
+	errs.Conflict:     http.StatusConflict,
+	errs.Forbidden:    http.StatusForbidden,
+	errs.Internal:     http.StatusInternalServerError,
+	errs.Invalid:      http.StatusBadRequest,
+	errs.NotFound:     http.StatusNotFound,
+	errs.Unauthorized: http.StatusUnauthorized,
+	errs.Unsupported:  http.StatusUnsupportedMediaType,
+}
+
+// writeError writes an error response, body size limit errors are written as a 413 response,
+// typed errors are written with the status of their kind and their code, message and
+// metadata, other errors and internal errors are logged and written as a generic 500 response
+func writeError(w http.ResponseWriter, err error) {
+	var mbe *http.MaxBytesError
+	if errors.As(err, &mbe) {
+		slog.Debug("http handler error", "err", err, "status", http.StatusRequestEntityTooLarge)
+		_ = WriteJSON(
+			w,
+			http.StatusRequestEntityTooLarge,
+			map[string]string{"error": "request body too large"},
+		)
+		return
+	}
+
+	e, ok := errs.As(err)
+	if !ok || e.Kind == errs.Internal {
 		slog.Error("http handler error", "err", err)
 		_ = WriteJSON(
 			w,
 			http.StatusInternalServerError,
 			map[string]string{"error": "internal server error"},
 		)
+		return
+	}
+
+	status, ok := errorStatus[e.Kind]
+	if !ok {
+		status = http.StatusInternalServerError
+	}
+	slog.Debug("http handler error", "err", err, "status", status)
+
+	res := map[string]any{"error": e.Message}
+	if e.Code != "" {
+		res["code"] = e.Code
 	}
+	if len(e.Meta) > 0 {
+		res["meta"] = e.Meta
+	}
+	_ = WriteJSON(w, status, res)
+}
+
+// Options are the server options
+type Options struct {
+	// DrainDelay is how long Stop waits after disabling keep-alives before shutting down, so
+	// clients behind load balancers have time to move off the instance, requires
+	// DrainKeepAlives
+	DrainDelay time.Duration
+
+	// DrainKeepAlives disables keep-alives and sends "Connection: close" on responses once
+	// Stop begins
+	DrainKeepAlives bool
+
+	// IdleTimeout is the max time to wait for the next request on a keep-alive connection, 0
+	// uses ReadTimeout
+	IdleTimeout time.Duration
+
+	// MaxBodySize is the max request body size in bytes, reads over the limit return an
+	// *http.MaxBytesError which responds 413, 0 is no limit
+	MaxBodySize int64
+
+	// MaxHeaderBytes is the max size of the request line and headers in bytes, larger
+	// requests receive a 431 response, 0 uses http.DefaultMaxHeaderBytes (1MB)
+	MaxHeaderBytes int
+
+	// MaxHeaderCount is the max number of request header values, requests with more receive a
+	// 431 response, 0 is no limit
+	MaxHeaderCount int
+
+	// MaxInFlight is the max number of requests handled concurrently, requests over the limit
+	// wait up to MaxInFlightWait for a free slot before receiving a 503 response, 0 is no limit
+	MaxInFlight int
+
+	// MaxInFlightWait is how long a request waits for a free slot when MaxInFlight is reached
+	MaxInFlightWait time.Duration
+
+	// ReadTimeout is the max duration for reading the entire request including the body, 0 is
+	// no timeout
+	ReadTimeout time.Duration
+
+	// WriteTimeout is the max duration of a request from the end of the request header read
+	// to the end of the response write, it is also set as the request context deadline so
+	// handlers can budget outbound calls, 0 is no timeout
+	WriteTimeout time.Duration
 }
 
 // Server is an http server
 type Server struct {
-	Router *router
-	server *http.Server
+	Router    *router
+	addr      net.Addr
+	opts      Options
+	ready     chan struct{}
+	readyOnce sync.Once
+	server    *http.Server
+	sockets   map[*WebSocket]bool
+	socketsMu sync.Mutex
+	stopping  atomic.Bool
 }
 
+// serverKey is the request context key for the Server
+type serverKey struct{}
+
 // New creates a new Server
 func New(port int) *Server {
+	return NewWithOptions(port, Options{})
+}
+
+// NewWithOptions creates a new Server with options
+func NewWithOptions(port int, opts Options) *Server {
 	s := &Server{
-		Router: newRouter(http.NewServeMux()),
+		Router:  newRouter(http.NewServeMux()),
+		opts:    opts,
+		ready:   make(chan struct{}),
+		sockets: map[*WebSocket]bool{},
+	}
+
+	var h http.Handler = s.Router
+	if opts.DrainKeepAlives {
+		h = s.drainHandler(h)
+	}
+	if opts.MaxInFlight > 0 {
+		h = newLimiter(opts.MaxInFlight, opts.MaxInFlightWait).handler(h)
+	}
+	if opts.MaxBodySize > 0 {
+		h = maxBodyHandler(opts.MaxBodySize, h)
+	}
+	if opts.MaxHeaderCount > 0 {
+		h = maxHeaderHandler(opts.MaxHeaderCount, h)
+	}
+	if opts.WriteTimeout > 0 {
+		// outermost so time spent waiting for an in-flight slot counts against the budget
+		h = TimeoutMiddleware(opts.WriteTimeout)(h)
 	}
+
 	s.server = &http.Server{
 		Addr:              fmt.Sprintf(":%d", port),
-		Handler:           s.Router,
+		Handler:           h,
+		IdleTimeout:       opts.IdleTimeout,
+		MaxHeaderBytes:    opts.MaxHeaderBytes,
 		ReadHeaderTimeout: 3 * time.Second,
+		ReadTimeout:       opts.ReadTimeout,
+		WriteTimeout:      opts.WriteTimeout,
+		BaseContext: func(net.Listener) context.Context {
+			return context.WithValue(context.Background(), serverKey{}, s)
+		},
 	}
+	// hijacked connections are not closed by Shutdown
+	s.server.RegisterOnShutdown(s.closeWebSockets)
 	return s
 }
 
-// Start starts the server
+// Start starts the server, returns http.ErrServerClosed after Stop
 func (s *Server) Start() error {
-	slog.Info("starting server", "port", config.Config.ServerPort)
-	return s.server.ListenAndServe()
+	return s.StartCtx(context.Background())
+}
+
+// StartCtx starts the server and stops it with Stop when ctx is done, so cancelling ctx drains
+// the server like calling Stop does, returns http.ErrServerClosed after Stop or when ctx is
+// done, call Stop directly to bound the drain delay with a context
+func (s *Server) StartCtx(ctx context.Context) error {
+	if config.Config.Debug {
+		s.Router.logChains()
+	}
+
+	ln, err := net.Listen("tcp", s.server.Addr)
+	if err != nil {
+		return err
+	}
+	s.readyOnce.Do(func() {
+		s.addr = ln.Addr()
+		close(s.ready)
+	})
+	slog.Info("starting server", "addr", ln.Addr().String())
+
+	stop := context.AfterFunc(ctx, func() {
+		_ = s.Stop(context.WithoutCancel(ctx))
+	})
+	defer stop()
+	return s.server.Serve(ln)
+}
+
+// Addr returns the address the server listens on, for example the port picked for port 0,
+// returns nil before the listener is bound, see Ready
+func (s *Server) Addr() net.Addr {
+	select {
+	case <-s.ready:
+		return s.addr
+	default:
+		return nil
+	}
+}
+
+// Ready returns a channel that is closed once the server listener is bound and requests
+// are accepted, so callers and tests can wait for the server without polling
+func (s *Server) Ready() <-chan struct{} {
+	return s.ready
 }
 
 // Stop stops the server
 func (s *Server) Stop(ctx context.Context) error {
 	slog.Info("stopping server")
+	if s.opts.DrainKeepAlives {
+		s.stopping.Store(true)
+		s.server.SetKeepAlivesEnabled(false)
+		if s.opts.DrainDelay > 0 {
+			t := time.NewTimer(s.opts.DrainDelay)
+			select {
+			case <-t.C:
+			case <-ctx.Done():
+				t.Stop()
+			}
+		}
+	}
 	ctx, cancel := context.WithTimeout(ctx, 500*time.Millisecond)
 	defer cancel()
 	return s.server.Shutdown(ctx)
 }
 
+// closeWebSockets closes the open WebSocket connections with CloseGoingAway
+func (s *Server) closeWebSockets() {
+	s.socketsMu.Lock()
+	sockets := make([]*WebSocket, 0, len(s.sockets))
+	for ws := range s.sockets {
+		sockets = append(sockets, ws)
+	}
+	s.socketsMu.Unlock()
+
+	for _, ws := range sockets {
+		_ = ws.Close(CloseGoingAway, "server shutting down")
+	}
+}
+
+// trackWebSocket adds or removes an open WebSocket connection
+func (s *Server) trackWebSocket(ws *WebSocket, open bool) {
+	s.socketsMu.Lock()
+	defer s.socketsMu.Unlock()
+	if open {
+		s.sockets[ws] = true
+	} else {
+		delete(s.sockets, ws)
+	}
+}
+
+// drainHandler wraps a handler to send "Connection: close" once Stop begins
+func (s *Server) drainHandler(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		if s.stopping.Load() {
+			w.Header().Set("Connection", "close")
+		}
+		next.ServeHTTP(w, r)
+	}
+
+	return http.HandlerFunc(fn)
+}
+
+// maxBodyHandler limits request bodies to max bytes, requests with a larger Content-Length
+// receive a 413 response before the body is read
+func maxBodyHandler(max int64, next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength > max {
+			_ = WriteJSON(
+				w,
+				http.StatusRequestEntityTooLarge,
+				map[string]string{"error": "request body too large"},
+			)
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, max)
+		next.ServeHTTP(w, r)
+	}
+
+	return http.HandlerFunc(fn)
+}
+
+// maxHeaderHandler rejects requests with more than max header values with a 431 response
+func maxHeaderHandler(max int, next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		n := 0
+		for _, v := range r.Header {
+			n += len(v)
+		}
+		if n > max {
+			_ = WriteJSON(
+				w,
+				http.StatusRequestHeaderFieldsTooLarge,
+				map[string]string{"error": "too many request headers"},
+			)
+			return
+		}
+		next.ServeHTTP(w, r)
+	}
+
+	return http.HandlerFunc(fn)
+}
+
 // ReadJSON reads a JSON request
 func ReadJSON(r *http.Request, payload *any) error {
-	return json.NewDecoder(r.Body).Decode(payload)
+	return JSONCodec.Decode(r.Body, payload)
 }
 
 // WriteJSON writes a JSON response, with status code and sets content type to application/json
+// the payload is wrapped in an Envelope when the response is enveloped by EnvelopeMiddleware
 func WriteJSON(w http.ResponseWriter, code int, payload any) error {
+	if e := envelopeOf(w); e != nil {
+		payload = e.wrap(code, payload)
+	}
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(code)
 
-	return json.NewEncoder(w).Encode(payload)
+	return JSONCodec.Encode(w, payload)
 }
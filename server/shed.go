@@ -0,0 +1,157 @@
+package server
+
+import (
+	"log/slog"
+	"net/http"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Priority is a route priority used by the Shedder, lower priority routes are shed first
+type Priority int
+
+const (
+	// PriorityLow routes are shed as soon as the server is overloaded
+	PriorityLow Priority = iota
+	// PriorityNormal routes are shed when the server is heavily overloaded
+	PriorityNormal
+	// PriorityCritical routes are never shed
+	PriorityCritical
+)
+
+// ShedderOptions are the Shedder options
+type ShedderOptions struct {
+	// MaxGoroutines is the goroutine count threshold, 0 disables the check
+	MaxGoroutines int
+
+	// MaxLatency is the p99 latency threshold, 0 disables the check
+	MaxLatency time.Duration
+
+	// Window is the rolling window of latency samples, defaults to 10 seconds
+	Window time.Duration
+}
+
+// latencySample is a request latency sample
+type latencySample struct {
+	at time.Time
+	d  time.Duration
+}
+
+// Shedder sheds requests based on rolling p99 latency and goroutine count, the overload
+// level is the highest ratio of a measurement to its threshold, at 1 low priority routes
+// are shed and at 1.5 normal priority routes are shed
+type Shedder struct {
+	checked time.Time
+	level   float64
+	mu      sync.Mutex
+	next    int
+	opts    ShedderOptions
+	samples []latencySample
+}
+
+// shedderSamples is the max number of latency samples kept
+const shedderSamples = 1024
+
+// NewShedder creates a new Shedder
+func NewShedder(opts ShedderOptions) *Shedder {
+	if opts.Window <= 0 {
+		opts.Window = 10 * time.Second
+	}
+	return &Shedder{
+		opts:    opts,
+		samples: make([]latencySample, 0, shedderSamples),
+	}
+}
+
+// Middleware returns middleware that sheds requests with the priority when overloaded
+func (s *Shedder) Middleware(p Priority) Middleware {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			if s.shed(p) {
+				slog.Warn("[http] shedding request", "path", r.URL.Path, "priority", p)
+				w.Header().Set("Retry-After", "1")
+				_ = WriteJSON(
+					w,
+					http.StatusServiceUnavailable,
+					map[string]string{"error": "service unavailable"},
+				)
+				return
+			}
+
+			start := time.Now()
+			defer func() {
+				s.record(start, time.Since(start))
+			}()
+
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// P99 returns the p99 latency of the samples in the window
+func (s *Shedder) P99() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.p99(time.Now())
+}
+
+// p99 returns the p99 latency, the caller must hold the lock
+func (s *Shedder) p99(now time.Time) time.Duration {
+	d := make([]time.Duration, 0, len(s.samples))
+	for _, v := range s.samples {
+		if now.Sub(v.at) <= s.opts.Window {
+			d = append(d, v.d)
+		}
+	}
+	if len(d) == 0 {
+		return 0
+	}
+	sort.Slice(d, func(i, j int) bool { return d[i] < d[j] })
+	return d[(len(d)*99)/100]
+}
+
+// record records a latency sample
+func (s *Shedder) record(at time.Time, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.samples) < shedderSamples {
+		s.samples = append(s.samples, latencySample{at: at, d: d})
+		return
+	}
+	s.samples[s.next] = latencySample{at: at, d: d}
+	s.next = (s.next + 1) % shedderSamples
+}
+
+// shed returns true if a request with the priority should be shed, the overload level is
+// recalculated at most once per second
+func (s *Shedder) shed(p Priority) bool {
+	if p >= PriorityCritical {
+		return false
+	}
+
+	s.mu.Lock()
+	now := time.Now()
+	if now.Sub(s.checked) >= time.Second {
+		s.checked = now
+		s.level = 0
+		if s.opts.MaxLatency > 0 {
+			s.level = float64(s.p99(now)) / float64(s.opts.MaxLatency)
+		}
+		if s.opts.MaxGoroutines > 0 {
+			s.level = max(s.level, float64(runtime.NumGoroutine())/float64(s.opts.MaxGoroutines))
+		}
+	}
+	level := s.level
+	s.mu.Unlock()
+
+	switch p {
+	case PriorityLow:
+		return level >= 1
+	default:
+		return level >= 1.5
+	}
+}
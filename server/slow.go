@@ -0,0 +1,157 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SlowRequestOptions are the SlowRequestMiddleware options
+type SlowRequestOptions struct {
+	// ProfileDir is the directory goroutine profiles are written to, profiling is disabled
+	// when empty
+	ProfileDir string
+
+	// ProfilesPerHour is the max number of profiles written per hour, defaults to 5
+	ProfilesPerHour int
+
+	// Threshold is the duration after which a request is slow, default 1s
+	Threshold time.Duration
+}
+
+// slowProfiler writes rate limited goroutine profiles
+type slowProfiler struct {
+	count  int
+	dir    string
+	max    int
+	mu     sync.Mutex
+	window time.Time
+}
+
+// allow returns true if a profile can be written in the current hour
+func (p *slowProfiler) allow() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if now := time.Now(); now.Sub(p.window) >= time.Hour {
+		p.window = now
+		p.count = 0
+	}
+	if p.count >= p.max {
+		return false
+	}
+	p.count++
+	return true
+}
+
+// write writes a goroutine profile and returns the file path
+func (p *slowProfiler) write() (string, error) {
+	path := filepath.Join(p.dir, fmt.Sprintf("slow-%d.goroutine.pb.gz", time.Now().UnixNano()))
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if err := pprof.Lookup("goroutine").WriteTo(f, 0); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// SlowRequestMiddleware returns middleware that logs requests still running after the
+// threshold with the stack of the handler goroutine and the number of in-flight requests,
+// and logs their total duration when they complete
+func SlowRequestMiddleware(opts SlowRequestOptions) Middleware {
+	if opts.Threshold <= 0 {
+		opts.Threshold = time.Second
+	}
+	var inFlight atomic.Int64
+	var profiler *slowProfiler
+	if opts.ProfileDir != "" {
+		if opts.ProfilesPerHour <= 0 {
+			opts.ProfilesPerHour = 5
+		}
+		profiler = &slowProfiler{dir: opts.ProfileDir, max: opts.ProfilesPerHour}
+	}
+
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			inFlight.Add(1)
+			defer inFlight.Add(-1)
+
+			start := time.Now()
+			gid := goroutineID()
+			var slow atomic.Bool
+			t := time.AfterFunc(opts.Threshold, func() {
+				slow.Store(true)
+				attrs := []any{
+					"method", r.Method,
+					"path", r.URL.Path,
+					"threshold", opts.Threshold.String(),
+					"in_flight", inFlight.Load(),
+					"stack", goroutineStack(gid),
+				}
+				if profiler != nil && profiler.allow() {
+					path, err := profiler.write()
+					if err != nil {
+						slog.Error("[http] slow request profile failed", "err", err)
+					} else {
+						attrs = append(attrs, "profile", path)
+					}
+				}
+				slog.Warn("[http] slow request running", attrs...)
+			})
+
+			defer func() {
+				t.Stop()
+				if slow.Load() {
+					slog.Warn(
+						"[http] slow request completed",
+						"method", r.Method,
+						"path", r.URL.Path,
+						"took", time.Since(start).String(),
+					)
+				}
+			}()
+
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// goroutineID returns the ID of the current goroutine from its stack header
+func goroutineID() string {
+	buf := make([]byte, 64)
+	buf = buf[:runtime.Stack(buf, false)]
+	buf = bytes.TrimPrefix(buf, []byte("goroutine "))
+	if i := bytes.IndexByte(buf, ' '); i > 0 {
+		return string(buf[:i])
+	}
+	return ""
+}
+
+// goroutineStack returns the stack of the goroutine with the ID, or an empty string if it is
+// no longer running
+func goroutineStack(id string) string {
+	if id == "" {
+		return ""
+	}
+	buf := make([]byte, 1<<20)
+	buf = buf[:runtime.Stack(buf, true)]
+	prefix := []byte("goroutine " + id + " ")
+	for _, g := range bytes.Split(buf, []byte("\n\n")) {
+		if bytes.HasPrefix(g, prefix) {
+			return string(g)
+		}
+	}
+	return ""
+}
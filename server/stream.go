@@ -0,0 +1,87 @@
+package server
+
+import (
+	"encoding/csv"
+	"errors"
+	"net/http"
+)
+
+// WriteCSV writes a streaming CSV response with status code and header row, rows are written
+// from seq and flushed as they are written, writing stops when the request context is done
+func WriteCSV(
+	w http.ResponseWriter,
+	r *http.Request,
+	code int,
+	header []string,
+	seq func(yield func(row []string) bool),
+) error {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.WriteHeader(code)
+
+	rc := http.NewResponseController(w)
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	var err error
+	seq(func(row []string) bool {
+		if err = r.Context().Err(); err != nil {
+			return false
+		}
+		if err = cw.Write(row); err != nil {
+			return false
+		}
+		cw.Flush()
+		if err = cw.Error(); err != nil {
+			return false
+		}
+		err = flush(rc)
+		return err == nil
+	})
+	if err != nil {
+		return err
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return err
+	}
+	return flush(rc)
+}
+
+// WriteNDJSON writes a streaming newline delimited JSON response with status code, values are
+// written from seq and flushed as they are written, writing stops when the request context is
+// done
+func WriteNDJSON(
+	w http.ResponseWriter,
+	r *http.Request,
+	code int,
+	seq func(yield func(v any) bool),
+) error {
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(code)
+
+	rc := http.NewResponseController(w)
+
+	var err error
+	seq(func(v any) bool {
+		if err = r.Context().Err(); err != nil {
+			return false
+		}
+		if err = JSONCodec.Encode(w, v); err != nil {
+			return false
+		}
+		err = flush(rc)
+		return err == nil
+	})
+	return err
+}
+
+// flush flushes the response, writers that do not support flushing are ignored
+func flush(rc *http.ResponseController) error {
+	if err := rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	return nil
+}
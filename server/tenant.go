@@ -0,0 +1,140 @@
+package server
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// tenantKey is the request context key for the tenant ID
+type tenantKey struct{}
+
+// TenantResolver resolves the tenant ID for a request, returns an empty string when the
+// request has no tenant
+type TenantResolver func(*http.Request) string
+
+// TenantFromClaims resolves the tenant ID from a string claim of the verified token claims
+// stored in the request context with WithClaims, the middleware must run after the
+// authentication middleware setting the claims
+func TenantFromClaims(name string) TenantResolver {
+	return func(r *http.Request) string {
+		claims, ok := Claims(r.Context())
+		if !ok {
+			return ""
+		}
+		id, _ := claims[name].(string)
+		return id
+	}
+}
+
+// TenantFromHeader resolves the tenant ID from a request header, the header is set by the
+// client and is not authenticated, so use it only behind a gateway that sets the header or
+// with handlers that check the caller belongs to the tenant, otherwise prefer
+// TenantFromClaims
+func TenantFromHeader(name string) TenantResolver {
+	return func(r *http.Request) string {
+		return r.Header.Get(name)
+	}
+}
+
+// TenantFromSubdomain resolves the tenant ID from the first label of the externally visible
+// request host when the host is a subdomain of the domain, for example "acme.example.com"
+// resolves to "acme"
+func TenantFromSubdomain(domain string) TenantResolver {
+	suffix := "." + strings.ToLower(strings.TrimPrefix(domain, "."))
+	return func(r *http.Request) string {
+		host, _, err := net.SplitHostPort(Host(r))
+		if err != nil {
+			host = Host(r)
+		}
+		host = strings.ToLower(host)
+		if !strings.HasSuffix(host, suffix) {
+			return ""
+		}
+		sub := strings.TrimSuffix(host, suffix)
+		if i := strings.LastIndexByte(sub, '.'); i >= 0 {
+			sub = sub[i+1:]
+		}
+		return sub
+	}
+}
+
+// TenantMiddleware resolves the request tenant using the resolvers in order and stores the
+// tenant ID in the request context, requests with an invalid tenant ID, or without a tenant
+// when required, receive a 400 response
+func TenantMiddleware(required bool, resolvers ...TenantResolver) Middleware {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			id := ""
+			for _, resolve := range resolvers {
+				if id = resolve(r); id != "" {
+					break
+				}
+			}
+
+			if id == "" {
+				if required {
+					_ = WriteJSON(
+						w,
+						http.StatusBadRequest,
+						map[string]string{"error": "tenant required"},
+					)
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if !validTenant(id) {
+				_ = WriteJSON(
+					w,
+					http.StatusBadRequest,
+					map[string]string{"error": "invalid tenant"},
+				)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(WithTenant(r.Context(), id)))
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// Tenant returns the tenant ID from the context
+func Tenant(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(tenantKey{}).(string)
+	return id, ok
+}
+
+// TenantKey returns the key prefixed with the context tenant ID, for tenant scoped cache and
+// store keys, the key is returned unchanged when the context has no tenant
+func TenantKey(ctx context.Context, key string) string {
+	id, ok := Tenant(ctx)
+	if !ok {
+		return key
+	}
+	return id + ":" + key
+}
+
+// WithTenant returns a copy of the context with the tenant ID
+func WithTenant(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, id)
+}
+
+// validTenant returns true if the tenant ID contains only letters, digits, '-' and '_' and
+// is at most 63 chars
+func validTenant(id string) bool {
+	if len(id) > 63 {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '_':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,122 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTenantMiddleware(t *testing.T) {
+	tests := []struct {
+		name      string
+		required  bool
+		resolvers []TenantResolver
+		host      string
+		remote    string
+		header    map[string]string
+		claims    map[string]any
+		status    int
+		want      string
+	}{
+		{
+			name:      "subdomain",
+			resolvers: []TenantResolver{TenantFromSubdomain("example.com")},
+			host:      "acme.example.com:8080",
+			status:    http.StatusOK,
+			want:      "acme",
+		},
+		{
+			name:      "subdomain of forwarded host",
+			resolvers: []TenantResolver{TenantFromSubdomain("example.com")},
+			host:      "internal:8080",
+			remote:    "10.0.0.1:1234",
+			header:    map[string]string{"X-Forwarded-Host": "acme.example.com"},
+			status:    http.StatusOK,
+			want:      "acme",
+		},
+		{
+			name:      "forwarded host from untrusted peer",
+			resolvers: []TenantResolver{TenantFromSubdomain("example.com")},
+			host:      "internal:8080",
+			remote:    "203.0.113.1:1234",
+			header:    map[string]string{"X-Forwarded-Host": "acme.example.com"},
+			status:    http.StatusOK,
+		},
+		{
+			name:      "header",
+			resolvers: []TenantResolver{TenantFromHeader("X-Tenant")},
+			header:    map[string]string{"X-Tenant": "acme"},
+			status:    http.StatusOK,
+			want:      "acme",
+		},
+		{
+			name:      "claims",
+			resolvers: []TenantResolver{TenantFromClaims("tenant")},
+			claims:    map[string]any{"tenant": "acme"},
+			status:    http.StatusOK,
+			want:      "acme",
+		},
+		{
+			name:      "claim not a string",
+			resolvers: []TenantResolver{TenantFromClaims("tenant")},
+			claims:    map[string]any{"tenant": 1.0},
+			status:    http.StatusOK,
+		},
+		{
+			name: "first resolved wins",
+			resolvers: []TenantResolver{
+				TenantFromClaims("tenant"),
+				TenantFromHeader("X-Tenant"),
+			},
+			header: map[string]string{"X-Tenant": "other"},
+			claims: map[string]any{"tenant": "acme"},
+			status: http.StatusOK,
+			want:   "acme",
+		},
+		{
+			name:      "required",
+			required:  true,
+			resolvers: []TenantResolver{TenantFromClaims("tenant")},
+			status:    http.StatusBadRequest,
+		},
+		{
+			name:      "invalid",
+			resolvers: []TenantResolver{TenantFromHeader("X-Tenant")},
+			header:    map[string]string{"X-Tenant": "a:b"},
+			status:    http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			h := ForwardedMiddleware("10.0.0.0/8")(TenantMiddleware(tt.required, tt.resolvers...)(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					got, _ = Tenant(r.Context())
+				}),
+			))
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.host != "" {
+				r.Host = tt.host
+			}
+			if tt.remote != "" {
+				r.RemoteAddr = tt.remote
+			}
+			for k, v := range tt.header {
+				r.Header.Set(k, v)
+			}
+			if tt.claims != nil {
+				r = r.WithContext(WithClaims(context.Background(), tt.claims))
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			if w.Code != tt.status {
+				t.Errorf("got status %d, want %d", w.Code, tt.status)
+			}
+			if got != tt.want {
+				t.Errorf("got tenant %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package server
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/shayanderson/go-project/internal/errs"
+)
+
+// UploadOptions are the file upload options
+type UploadOptions struct {
+	// Field is the multipart form field name of the file, empty accepts the first file
+	Field string
+
+	// MaxSize is the max file size in bytes, 0 is no limit
+	MaxSize int64
+
+	// Types are the allowed content types detected from the file content, for example
+	// "image/png" or "image/*", empty allows all types
+	Types []string
+}
+
+// UploadedFile is a file received by ReceiveFile and stored in a temp file
+type UploadedFile struct {
+	// Checksum is the hex encoded SHA-256 checksum of the file content
+	Checksum string `json:"checksum"`
+
+	// ContentType is the content type detected from the file content
+	ContentType string `json:"content_type"`
+
+	// Filename is the client file name
+	Filename string `json:"filename"`
+
+	// Path is the temp file path
+	Path string `json:"-"`
+
+	// Size is the file size in bytes
+	Size int64 `json:"size"`
+}
+
+// Open opens the temp file for reading
+func (f *UploadedFile) Open() (*os.File, error) {
+	return os.Open(f.Path)
+}
+
+// Remove removes the temp file, callers must remove the file once it is stored
+func (f *UploadedFile) Remove() error {
+	return os.Remove(f.Path)
+}
+
+// ReceiveFile streams a file from a multipart request to a temp file while computing its
+// checksum, the file is not buffered in memory, invalid requests, files over the max size and
+// files with a type that is not allowed return an invalid errs error
+func ReceiveFile(r *http.Request, opts UploadOptions) (*UploadedFile, error) {
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return nil, errs.New(errs.Invalid, "invalid_upload", "invalid multipart request")
+	}
+
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			return nil, errs.New(errs.Invalid, "missing_file", "missing file")
+		}
+		if err != nil {
+			return nil, errs.New(errs.Invalid, "invalid_upload", "invalid multipart request")
+		}
+		if part.FileName() == "" || (opts.Field != "" && part.FormName() != opts.Field) {
+			part.Close()
+			continue
+		}
+
+		f, err := receivePart(part, opts)
+		part.Close()
+		return f, err
+	}
+}
+
+// receivePart writes a multipart file part to a temp file
+func receivePart(part *multipart.Part, opts UploadOptions) (*UploadedFile, error) {
+	// sniff the content type from the first 512 bytes, see http.DetectContentType
+	head := make([]byte, 512)
+	n, err := io.ReadFull(part, head)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, errs.New(errs.Invalid, "invalid_upload", "invalid multipart request")
+	}
+	head = head[:n]
+
+	ct, _, _ := mime.ParseMediaType(http.DetectContentType(head))
+	if !allowedType(ct, opts.Types) {
+		return nil, errs.New(errs.Invalid, "invalid_file_type", "file type is not allowed").
+			With("content_type", ct)
+	}
+
+	tmp, err := os.CreateTemp("", "upload-*")
+	if err != nil {
+		return nil, err
+	}
+	ok := false
+	defer func() {
+		if !ok {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	h := sha256.New()
+	src := io.MultiReader(bytes.NewReader(head), part)
+	if opts.MaxSize > 0 {
+		src = io.LimitReader(src, opts.MaxSize+1)
+	}
+	size, err := io.Copy(io.MultiWriter(tmp, h), src)
+	if err != nil {
+		return nil, errs.New(errs.Invalid, "invalid_upload", "invalid multipart request")
+	}
+	if opts.MaxSize > 0 && size > opts.MaxSize {
+		return nil, errs.New(errs.Invalid, "file_too_large", "file is too large").
+			With("max_size", opts.MaxSize)
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, err
+	}
+
+	ok = true
+	return &UploadedFile{
+		Checksum:    hex.EncodeToString(h.Sum(nil)),
+		ContentType: ct,
+		Filename:    filepath.Base(part.FileName()),
+		Path:        tmp.Name(),
+		Size:        size,
+	}, nil
+}
+
+// allowedType checks if the content type matches any of the allowed types, "type/*" matches
+// any subtype
+func allowedType(ct string, types []string) bool {
+	if len(types) == 0 {
+		return true
+	}
+	for _, t := range types {
+		if t == ct {
+			return true
+		}
+		if base, ok := strings.CutSuffix(t, "/*"); ok && strings.HasPrefix(ct, base+"/") {
+			return true
+		}
+	}
+	return false
+}
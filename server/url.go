@@ -0,0 +1,174 @@
+package server
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// forwardedKey is the request context key for the forwarded scheme and host
+type forwardedKey struct{}
+
+// forwarded is the externally visible scheme and host of a request behind a proxy
+type forwarded struct {
+	host   string
+	scheme string
+}
+
+// ForwardedMiddleware honors the X-Forwarded-Proto and X-Forwarded-Host headers of requests
+// from trusted proxies so Scheme, Host and the URL builders return the externally visible
+// values, trusted proxies are IP addresses or CIDR prefixes like "10.0.0.0/8", headers from
+// other clients are ignored, trusted proxies must set or append to the headers since only
+// values added by trusted proxies are used, panics if a trusted proxy is invalid
+func ForwardedMiddleware(trusted ...string) Middleware {
+	prefixes := make([]netip.Prefix, 0, len(trusted))
+	for _, t := range trusted {
+		if !strings.Contains(t, "/") {
+			addr, err := netip.ParseAddr(t)
+			if err != nil {
+				panic("invalid trusted proxy " + t)
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		p, err := netip.ParsePrefix(t)
+		if err != nil {
+			panic("invalid trusted proxy " + t)
+		}
+		prefixes = append(prefixes, p.Masked())
+	}
+
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			if !trustedProxy(r.RemoteAddr, prefixes) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			hops := trustedHops(r.Header.Values("X-Forwarded-For"), prefixes)
+			fw := forwarded{}
+			p := strings.ToLower(forwardedValue(r.Header.Values("X-Forwarded-Proto"), hops))
+			switch p {
+			case "http", "https":
+				fw.scheme = p
+			}
+			if h := forwardedValue(r.Header.Values("X-Forwarded-Host"), hops); validHost(h) {
+				fw.host = h
+			}
+			if fw == (forwarded{}) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), forwardedKey{}, fw)))
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// trustedProxy checks if the remote address is in any of the trusted prefixes
+func trustedProxy(remoteAddr string, prefixes []netip.Prefix) bool {
+	ap, err := netip.ParseAddrPort(remoteAddr)
+	if err != nil {
+		return false
+	}
+	return trustedAddr(ap.Addr(), prefixes)
+}
+
+// trustedAddr checks if the address is in any of the trusted prefixes
+func trustedAddr(addr netip.Addr, prefixes []netip.Prefix) bool {
+	addr = addr.Unmap()
+	for _, p := range prefixes {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// headerList returns the values of comma separated header lines in order
+func headerList(lines []string) []string {
+	var list []string
+	for _, line := range lines {
+		for _, v := range strings.Split(line, ",") {
+			list = append(list, strings.TrimSpace(v))
+		}
+	}
+	return list
+}
+
+// trustedHops returns the number of trusted proxies before the peer, each proxy appends the
+// address it received the request from to X-Forwarded-For, so the list is read from the right
+// until an address is not a trusted proxy
+func trustedHops(xff []string, prefixes []netip.Prefix) int {
+	list := headerList(xff)
+	hops := 0
+	for i := len(list) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(list[i])
+		if err != nil || !trustedAddr(addr, prefixes) {
+			break
+		}
+		hops++
+	}
+	return hops
+}
+
+// forwardedValue returns the value of a comma separated forwarded header set by the client
+// facing trusted proxy, proxies append to the list so it is read from the right, skipping one
+// value for each trusted proxy hop before the peer, values left of it are sent by the client
+// and never used
+func forwardedValue(lines []string, hops int) string {
+	list := headerList(lines)
+	if len(list) == 0 {
+		return ""
+	}
+	return list[max(len(list)-1-hops, 0)]
+}
+
+// validHost checks if a host is a valid host or host:port without path or userinfo
+func validHost(h string) bool {
+	if h == "" || strings.ContainsAny(h, "/\\@?# ") {
+		return false
+	}
+	if host, _, err := net.SplitHostPort(h); err == nil {
+		return host != ""
+	}
+	return true
+}
+
+// Scheme returns the externally visible request scheme, "http" or "https"
+func Scheme(r *http.Request) string {
+	if fw, ok := r.Context().Value(forwardedKey{}).(forwarded); ok && fw.scheme != "" {
+		return fw.scheme
+	}
+	if r.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
+
+// Host returns the externally visible request host
+func Host(r *http.Request) string {
+	if fw, ok := r.Context().Value(forwardedKey{}).(forwarded); ok && fw.host != "" {
+		return fw.host
+	}
+	return r.Host
+}
+
+// BaseURL returns the externally visible base URL of the request, for example
+// "https://api.example.com"
+func BaseURL(r *http.Request) string {
+	return Scheme(r) + "://" + Host(r)
+}
+
+// AbsoluteURL returns the externally visible absolute URL of a path, for example for
+// Location headers of 201 responses, redirects and webhook callbacks
+func AbsoluteURL(r *http.Request, path string) string {
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return BaseURL(r) + path
+}
@@ -0,0 +1,111 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestForwardedMiddleware(t *testing.T) {
+	tests := []struct {
+		name    string
+		remote  string
+		headers map[string][]string
+		want    string
+	}{
+		{
+			name:   "untrusted peer",
+			remote: "203.0.113.1:1234",
+			headers: map[string][]string{
+				"X-Forwarded-Proto": {"https"},
+				"X-Forwarded-Host":  {"evil.example.com"},
+			},
+			want: "http://example.com/x",
+		},
+		{
+			name:   "trusted peer",
+			remote: "10.0.0.1:1234",
+			headers: map[string][]string{
+				"X-Forwarded-Proto": {"https"},
+				"X-Forwarded-Host":  {"api.example.com"},
+			},
+			want: "https://api.example.com/x",
+		},
+		{
+			name:   "trusted peer appended to client values",
+			remote: "10.0.0.1:1234",
+			headers: map[string][]string{
+				"X-Forwarded-For":   {"198.51.100.1, 203.0.113.1"},
+				"X-Forwarded-Proto": {"http, https"},
+				"X-Forwarded-Host":  {"evil.example.com, api.example.com"},
+			},
+			want: "https://api.example.com/x",
+		},
+		{
+			name:   "trusted peer appended to client header line",
+			remote: "10.0.0.1:1234",
+			headers: map[string][]string{
+				"X-Forwarded-Host": {"evil.example.com", "api.example.com"},
+			},
+			want: "http://api.example.com/x",
+		},
+		{
+			name:   "trusted proxy chain",
+			remote: "10.0.0.1:1234",
+			headers: map[string][]string{
+				"X-Forwarded-For":   {"198.51.100.1, 203.0.113.1, 10.0.0.2"},
+				"X-Forwarded-Proto": {"http, https, http"},
+				"X-Forwarded-Host":  {"evil.example.com, api.example.com, internal:8080"},
+			},
+			want: "https://api.example.com/x",
+		},
+		{
+			name:   "trusted proxy chain with spoofed trusted address",
+			remote: "10.0.0.1:1234",
+			headers: map[string][]string{
+				"X-Forwarded-For":   {"10.0.0.3, 203.0.113.1, 10.0.0.2"},
+				"X-Forwarded-Proto": {"http, https, http"},
+				"X-Forwarded-Host":  {"evil.example.com, api.example.com, internal:8080"},
+			},
+			want: "https://api.example.com/x",
+		},
+		{
+			name:   "proxy set single value",
+			remote: "10.0.0.1:1234",
+			headers: map[string][]string{
+				"X-Forwarded-For":  {"203.0.113.1, 10.0.0.2"},
+				"X-Forwarded-Host": {"api.example.com"},
+			},
+			want: "http://api.example.com/x",
+		},
+		{
+			name:   "invalid values ignored",
+			remote: "10.0.0.1:1234",
+			headers: map[string][]string{
+				"X-Forwarded-Proto": {"https, ftp"},
+				"X-Forwarded-Host":  {"api.example.com, evil.example.com/path"},
+			},
+			want: "http://example.com/x",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			h := ForwardedMiddleware("10.0.0.0/8")(http.HandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) {
+					got = AbsoluteURL(r, "x")
+				},
+			))
+			r := httptest.NewRequest(http.MethodGet, "http://example.com/", nil)
+			r.RemoteAddr = tt.remote
+			for k, v := range tt.headers {
+				r.Header[k] = v
+			}
+			h.ServeHTTP(httptest.NewRecorder(), r)
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
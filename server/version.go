@@ -0,0 +1,99 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// VersionOptions are the API version options
+type VersionOptions struct {
+	// Deprecated is when the version was deprecated, responses include the Deprecation
+	// header once set, zero is not deprecated
+	Deprecated time.Time
+
+	// Link is the URL of the version deprecation documentation, sent as a Link header with
+	// rel="deprecation" when the version is deprecated
+	Link string
+
+	// Sunset is when the version will be removed, responses include the Sunset header once
+	// set
+	Sunset time.Time
+}
+
+// Version returns a group of the API version routes prefixed by "/" + name, for example
+// "v1", routes of deprecated versions send Deprecation and Sunset headers
+func (r *router) Version(name string, opts VersionOptions) *Group {
+	name = strings.Trim(name, "/")
+	if r.versions == nil {
+		r.versions = map[string]bool{}
+	}
+	r.versions[name] = true
+	return r.Group("/"+name, deprecation(opts))
+}
+
+// VersionHeader enables version negotiation with a request header, for example
+// "API-Version: v2", requests without a version path prefix that match no unversioned route
+// are routed to the version in the header, or to the fallback version when the header is
+// empty, requests with an unknown version receive a 400 response
+func (r *router) VersionHeader(header, fallback string) {
+	r.versionHeader = header
+	r.versionFallback = strings.Trim(fallback, "/")
+}
+
+// negotiateVersion wraps a handler to route requests without a version path prefix to the
+// version of the version header, requests matching an unversioned route are not changed
+func (r *router) negotiateVersion(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, req *http.Request) {
+		seg, _, _ := strings.Cut(strings.TrimPrefix(req.URL.Path, "/"), "/")
+		if _, p := r.mux.Handler(req); r.versions[seg] || p != "" {
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		v := strings.Trim(req.Header.Get(r.versionHeader), "/")
+		if v == "" {
+			v = r.versionFallback
+		}
+		if v == "" {
+			next.ServeHTTP(w, req)
+			return
+		}
+		if !r.versions[v] {
+			_ = WriteJSON(
+				w,
+				http.StatusBadRequest,
+				map[string]string{"error": "unsupported API version"},
+			)
+			return
+		}
+
+		req.URL.Path = "/" + v + req.URL.Path
+		req.URL.RawPath = ""
+		w.Header().Add("Vary", r.versionHeader)
+		next.ServeHTTP(w, req)
+	}
+
+	return http.HandlerFunc(fn)
+}
+
+// deprecation returns middleware sending the version deprecation headers
+func deprecation(opts VersionOptions) Middleware {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			if !opts.Deprecated.IsZero() {
+				w.Header().Set("Deprecation", "@"+strconv.FormatInt(opts.Deprecated.Unix(), 10))
+				if opts.Link != "" {
+					w.Header().Add("Link", "<"+opts.Link+`>; rel="deprecation"`)
+				}
+			}
+			if !opts.Sunset.IsZero() {
+				w.Header().Set("Sunset", opts.Sunset.UTC().Format(http.TimeFormat))
+			}
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
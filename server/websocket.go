@@ -0,0 +1,483 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/shayanderson/go-project/internal/errs"
+	"github.com/shayanderson/go-project/internal/work"
+)
+
+// MessageType is a WebSocket message type
+type MessageType int
+
+// message types
+const (
+	TextMessage   MessageType = 1
+	BinaryMessage MessageType = 2
+)
+
+// close status codes
+const (
+	CloseNormal          = 1000
+	CloseGoingAway       = 1001
+	CloseProtocolError   = 1002
+	CloseNoStatus        = 1005
+	CloseInvalidPayload  = 1007
+	ClosePolicyViolation = 1008
+	CloseTooLarge        = 1009
+)
+
+// frame opcodes
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xa
+)
+
+// wsGUID is the handshake accept key GUID
+const wsGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// ErrWebSocketClosed is returned when writing to a closed WebSocket
+var ErrWebSocketClosed = errors.New("websocket: connection closed")
+
+// CloseError is returned by ReadMessage when the peer closes the connection
+type CloseError struct {
+	// Code is the close status code, CloseNoStatus when the peer sent none
+	Code int
+
+	// Reason is the close reason
+	Reason string
+}
+
+// Error implements the error interface
+func (e *CloseError) Error() string {
+	if e.Reason == "" {
+		return "websocket: closed with code " + strconv.Itoa(e.Code)
+	}
+	return "websocket: closed with code " + strconv.Itoa(e.Code) + ": " + e.Reason
+}
+
+// wsError is a protocol error closing the connection with a close status code
+type wsError struct {
+	code int
+	msg  string
+}
+
+// Error implements the error interface
+func (e *wsError) Error() string {
+	return "websocket: " + e.msg
+}
+
+// WebSocketOptions are the WebSocket options
+type WebSocketOptions struct {
+	// CheckOrigin checks the request Origin header, default allows requests without an Origin
+	// header and requests with an Origin host equal to the request host
+	CheckOrigin func(r *http.Request) bool
+
+	// MaxMessageSize is the max size of a received message in bytes, larger messages close the
+	// connection with CloseTooLarge, default 1MB
+	MaxMessageSize int64
+
+	// PingInterval is the interval pings are sent to keep the connection alive, default 30s
+	PingInterval time.Duration
+
+	// PongTimeout is how long to wait for a pong or any other frame after a ping before the
+	// connection is considered dead, default 10s
+	PongTimeout time.Duration
+
+	// Subprotocols are the supported subprotocols, the first subprotocol requested by the
+	// client that is supported is selected
+	Subprotocols []string
+
+	// WriteTimeout is the max duration of a frame write and of the close handshake,
+	// default 10s
+	WriteTimeout time.Duration
+}
+
+// WebSocket is a server WebSocket connection, ReadMessage must be called from a single
+// goroutine, which also answers pings and the close handshake, WriteMessage is safe for
+// concurrent use, the connection sends pings in the background and is closed with
+// CloseGoingAway when the server stops
+type WebSocket struct {
+	br        *bufio.Reader
+	closeOnce sync.Once
+	closeSent bool
+	conn      net.Conn
+	done      chan struct{}
+	opts      WebSocketOptions
+	protocol  string
+	srv       *Server
+	wmu       sync.Mutex
+}
+
+// Upgrade upgrades an HTTP request to a WebSocket connection, invalid handshakes return a
+// typed error, after a successful upgrade the handler must not write to w and should return
+// nil once the connection is done
+func Upgrade(w http.ResponseWriter, r *http.Request, opts WebSocketOptions) (*WebSocket, error) {
+	if opts.CheckOrigin == nil {
+		opts.CheckOrigin = sameOrigin
+	}
+	if opts.MaxMessageSize <= 0 {
+		opts.MaxMessageSize = 1 << 20 // 1MB
+	}
+	if opts.PingInterval <= 0 {
+		opts.PingInterval = 30 * time.Second
+	}
+	if opts.PongTimeout <= 0 {
+		opts.PongTimeout = 10 * time.Second
+	}
+	if opts.WriteTimeout <= 0 {
+		opts.WriteTimeout = 10 * time.Second
+	}
+
+	if r.Method != http.MethodGet ||
+		!headerHasToken(r.Header, "Connection", "upgrade") ||
+		!headerHasToken(r.Header, "Upgrade", "websocket") {
+		return nil, errs.New(errs.Invalid, "invalid_websocket", "invalid websocket handshake")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		return nil, errs.New(
+			errs.Invalid,
+			"unsupported_websocket_version",
+			"unsupported websocket version",
+		)
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if k, err := base64.StdEncoding.DecodeString(key); err != nil || len(k) != 16 {
+		return nil, errs.New(errs.Invalid, "invalid_websocket", "invalid websocket key")
+	}
+	if !opts.CheckOrigin(r) {
+		return nil, errs.New(errs.Forbidden, "invalid_origin", "origin not allowed")
+	}
+	protocol := selectSubprotocol(r, opts.Subprotocols)
+
+	conn, brw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		return nil, errs.Wrap(err, errs.Internal, "", "websocket hijack failed")
+	}
+	// clear the server read and write deadlines, the connection manages its own
+	_ = conn.SetDeadline(time.Time{})
+
+	sum := sha1.Sum([]byte(key + wsGUID))
+	var b bytes.Buffer
+	b.WriteString("HTTP/1.1 101 Switching Protocols\r\n")
+	b.WriteString("Upgrade: websocket\r\nConnection: Upgrade\r\n")
+	b.WriteString("Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(sum[:]) + "\r\n")
+	if protocol != "" {
+		b.WriteString("Sec-WebSocket-Protocol: " + protocol + "\r\n")
+	}
+	b.WriteString("\r\n")
+	_ = conn.SetWriteDeadline(time.Now().Add(opts.WriteTimeout))
+	if _, err := conn.Write(b.Bytes()); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	ws := &WebSocket{
+		br:       brw.Reader,
+		conn:     conn,
+		done:     make(chan struct{}),
+		opts:     opts,
+		protocol: protocol,
+	}
+	if s, ok := r.Context().Value(serverKey{}).(*Server); ok {
+		ws.srv = s
+		s.trackWebSocket(ws, true)
+	}
+	work.Go(context.Background(), func(context.Context) {
+		// a panic must not leave the connection open without keepalive
+		defer ws.closeConn()
+		ws.pump()
+	})
+	return ws, nil
+}
+
+// Done returns a channel that is closed when the connection is closed
+func (ws *WebSocket) Done() <-chan struct{} {
+	return ws.done
+}
+
+// Protocol returns the selected subprotocol, empty when none was selected
+func (ws *WebSocket) Protocol() string {
+	return ws.protocol
+}
+
+// ReadMessage reads the next message, pings are answered and a close frame from the peer
+// completes the close handshake and returns a *CloseError, the connection is closed on any
+// error
+func (ws *WebSocket) ReadMessage() (MessageType, []byte, error) {
+	var typ MessageType
+	var msg []byte
+	for {
+		// any frame, including a pong, proves the peer is alive
+		_ = ws.conn.SetReadDeadline(time.Now().Add(ws.opts.PingInterval + ws.opts.PongTimeout))
+		fin, op, payload, err := ws.readFrame()
+		if err != nil {
+			return 0, nil, ws.fail(err)
+		}
+
+		switch op {
+		case opPing:
+			if err := ws.writeFrame(opPong, payload); err != nil {
+				return 0, nil, ws.fail(err)
+			}
+			continue
+		case opPong:
+			continue
+		case opClose:
+			ce := &CloseError{Code: CloseNoStatus}
+			if len(payload) >= 2 {
+				ce.Code = int(binary.BigEndian.Uint16(payload))
+				ce.Reason = string(payload[2:])
+			}
+			code := ce.Code
+			if code == CloseNoStatus {
+				code = CloseNormal
+			}
+			// echo the close frame unless this side started the close handshake
+			_ = ws.writeFrame(opClose, closePayload(code, ""))
+			ws.closeConn()
+			return 0, nil, ce
+		case opText, opBinary:
+			if typ != 0 {
+				return 0, nil, ws.fail(&wsError{CloseProtocolError, "unexpected data frame"})
+			}
+			typ = MessageType(op)
+			msg = payload
+		case opContinuation:
+			if typ == 0 {
+				return 0, nil, ws.fail(&wsError{CloseProtocolError, "unexpected continuation"})
+			}
+			msg = append(msg, payload...)
+		default:
+			return 0, nil, ws.fail(&wsError{CloseProtocolError, "unknown opcode"})
+		}
+
+		if int64(len(msg)) > ws.opts.MaxMessageSize {
+			return 0, nil, ws.fail(&wsError{CloseTooLarge, "message too large"})
+		}
+		if fin {
+			if typ == TextMessage && !utf8.Valid(msg) {
+				return 0, nil, ws.fail(&wsError{CloseInvalidPayload, "invalid UTF-8 text"})
+			}
+			return typ, msg, nil
+		}
+	}
+}
+
+// WriteMessage writes a message
+func (ws *WebSocket) WriteMessage(typ MessageType, data []byte) error {
+	if typ != TextMessage && typ != BinaryMessage {
+		return errors.New("websocket: invalid message type")
+	}
+	return ws.writeFrame(byte(typ), data)
+}
+
+// WriteJSON writes v as a JSON text message using JSONCodec
+func (ws *WebSocket) WriteJSON(v any) error {
+	var b bytes.Buffer
+	if err := JSONCodec.Encode(&b, v); err != nil {
+		return err
+	}
+	return ws.WriteMessage(TextMessage, bytes.TrimSuffix(b.Bytes(), []byte("\n")))
+}
+
+// Close starts the close handshake, the connection is closed when ReadMessage receives the
+// close frame of the peer or after the write timeout
+func (ws *WebSocket) Close(code int, reason string) error {
+	err := ws.writeFrame(opClose, closePayload(code, reason))
+	time.AfterFunc(ws.opts.WriteTimeout, ws.closeConn)
+	if errors.Is(err, ErrWebSocketClosed) {
+		return nil
+	}
+	return err
+}
+
+// closeConn closes the connection
+func (ws *WebSocket) closeConn() {
+	ws.closeOnce.Do(func() {
+		close(ws.done)
+		ws.conn.Close()
+		if ws.srv != nil {
+			ws.srv.trackWebSocket(ws, false)
+		}
+	})
+}
+
+// fail closes the connection after a read error, protocol errors send a close frame with
+// their close status code
+func (ws *WebSocket) fail(err error) error {
+	var we *wsError
+	if errors.As(err, &we) {
+		_ = ws.writeFrame(opClose, closePayload(we.code, we.msg))
+	}
+	ws.closeConn()
+	return err
+}
+
+// pump sends pings until the connection is closed
+func (ws *WebSocket) pump() {
+	t := time.NewTicker(ws.opts.PingInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ws.done:
+			return
+		case <-t.C:
+			if err := ws.writeFrame(opPing, nil); err != nil {
+				if !errors.Is(err, ErrWebSocketClosed) {
+					ws.closeConn()
+				}
+				return
+			}
+		}
+	}
+}
+
+// readFrame reads a frame, client frames must be masked
+func (ws *WebSocket) readFrame() (fin bool, op byte, payload []byte, err error) {
+	var h [8]byte
+	if _, err := io.ReadFull(ws.br, h[:2]); err != nil {
+		return false, 0, nil, err
+	}
+	fin = h[0]&0x80 != 0
+	op = h[0] & 0x0f
+	if h[0]&0x70 != 0 {
+		return false, 0, nil, &wsError{CloseProtocolError, "reserved bits set"}
+	}
+	if h[1]&0x80 == 0 {
+		return false, 0, nil, &wsError{CloseProtocolError, "unmasked client frame"}
+	}
+
+	n := uint64(h[1] & 0x7f)
+	switch n {
+	case 126:
+		if _, err := io.ReadFull(ws.br, h[:2]); err != nil {
+			return false, 0, nil, err
+		}
+		n = uint64(binary.BigEndian.Uint16(h[:2]))
+	case 127:
+		if _, err := io.ReadFull(ws.br, h[:8]); err != nil {
+			return false, 0, nil, err
+		}
+		n = binary.BigEndian.Uint64(h[:8])
+	}
+	if op >= opClose && (!fin || n > 125) {
+		return false, 0, nil, &wsError{CloseProtocolError, "invalid control frame"}
+	}
+	if n > uint64(ws.opts.MaxMessageSize) {
+		return false, 0, nil, &wsError{CloseTooLarge, "message too large"}
+	}
+
+	var mask [4]byte
+	if _, err := io.ReadFull(ws.br, mask[:]); err != nil {
+		return false, 0, nil, err
+	}
+	payload = make([]byte, n)
+	if _, err := io.ReadFull(ws.br, payload); err != nil {
+		return false, 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return fin, op, payload, nil
+}
+
+// writeFrame writes an unmasked frame, no frames are written after a close frame
+func (ws *WebSocket) writeFrame(op byte, payload []byte) error {
+	ws.wmu.Lock()
+	defer ws.wmu.Unlock()
+	if ws.closeSent {
+		return ErrWebSocketClosed
+	}
+
+	b := make([]byte, 0, len(payload)+10)
+	b = append(b, 0x80|op)
+	switch n := len(payload); {
+	case n <= 125:
+		b = append(b, byte(n))
+	case n <= 0xffff:
+		b = append(b, 126)
+		b = binary.BigEndian.AppendUint16(b, uint16(n))
+	default:
+		b = append(b, 127)
+		b = binary.BigEndian.AppendUint64(b, uint64(n))
+	}
+	b = append(b, payload...)
+
+	if op == opClose {
+		ws.closeSent = true
+	}
+	_ = ws.conn.SetWriteDeadline(time.Now().Add(ws.opts.WriteTimeout))
+	_, err := ws.conn.Write(b)
+	return err
+}
+
+// closePayload returns a close frame payload
+func closePayload(code int, reason string) []byte {
+	if len(reason) > 123 {
+		reason = reason[:123]
+	}
+	b := binary.BigEndian.AppendUint16(nil, uint16(code))
+	return append(b, reason...)
+}
+
+// headerHasToken checks if a comma separated header contains a token, case-insensitive
+func headerHasToken(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for _, t := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// sameOrigin checks if the request has no Origin header or an Origin host equal to the
+// request host
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, Host(r))
+}
+
+// selectSubprotocol returns the first supported subprotocol requested by the client
+func selectSubprotocol(r *http.Request, supported []string) string {
+	for _, v := range r.Header.Values("Sec-WebSocket-Protocol") {
+		for _, p := range strings.Split(v, ",") {
+			p = strings.TrimSpace(p)
+			for _, s := range supported {
+				if p == s {
+					return p
+				}
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,391 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shayanderson/go-project/internal/errs"
+)
+
+// wsTestKey is a valid client handshake key
+const wsTestKey = "dGhlIHNhbXBsZSBub25jZQ=="
+
+// wsClient is a minimal test WebSocket client
+type wsClient struct {
+	br   *bufio.Reader
+	conn net.Conn
+	t    *testing.T
+}
+
+// dialWebSocket starts an echo server and completes the client handshake, the returned
+// channel receives the ReadMessage error that ended the echo loop
+func dialWebSocket(t *testing.T, opts WebSocketOptions) (*wsClient, <-chan error) {
+	t.Helper()
+	errc := make(chan error, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := Upgrade(w, r, opts)
+		if err != nil {
+			errc <- err
+			return
+		}
+		for {
+			typ, msg, err := ws.ReadMessage()
+			if err != nil {
+				errc <- err
+				return
+			}
+			if err := ws.WriteMessage(typ, msg); err != nil {
+				errc <- err
+				return
+			}
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	req := "GET / HTTP/1.1\r\nHost: " + srv.Listener.Addr().String() + "\r\n" +
+		"Connection: Upgrade\r\nUpgrade: websocket\r\nSec-WebSocket-Version: 13\r\n" +
+		"Sec-WebSocket-Key: " + wsTestKey + "\r\n\r\n"
+	if _, err := conn.Write([]byte(req)); err != nil {
+		t.Fatal(err)
+	}
+	br := bufio.NewReader(conn)
+	res, err := http.ReadResponse(br, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("got status %d, want %d", res.StatusCode, http.StatusSwitchingProtocols)
+	}
+	// accept key from RFC 6455 section 1.3
+	if got := res.Header.Get("Sec-WebSocket-Accept"); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("got accept key %q", got)
+	}
+	return &wsClient{br: br, conn: conn, t: t}, errc
+}
+
+// write writes a frame, masked frames use a fixed mask
+func (c *wsClient) write(fin bool, op byte, payload []byte, masked bool) {
+	c.t.Helper()
+	b0 := op
+	if fin {
+		b0 |= 0x80
+	}
+	b := []byte{b0}
+	var mb byte
+	if masked {
+		mb = 0x80
+	}
+	switch n := len(payload); {
+	case n <= 125:
+		b = append(b, mb|byte(n))
+	case n <= 0xffff:
+		b = append(b, mb|126)
+		b = binary.BigEndian.AppendUint16(b, uint16(n))
+	default:
+		b = append(b, mb|127)
+		b = binary.BigEndian.AppendUint64(b, uint64(n))
+	}
+	if masked {
+		mask := []byte{1, 2, 3, 4}
+		b = append(b, mask...)
+		for i, p := range payload {
+			b = append(b, p^mask[i%4])
+		}
+	} else {
+		b = append(b, payload...)
+	}
+	if _, err := c.conn.Write(b); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+// read reads an unmasked server frame
+func (c *wsClient) read() (op byte, payload []byte) {
+	c.t.Helper()
+	var h [8]byte
+	if _, err := io.ReadFull(c.br, h[:2]); err != nil {
+		c.t.Fatal(err)
+	}
+	op = h[0] & 0x0f
+	if h[1]&0x80 != 0 {
+		c.t.Fatal("server frame is masked")
+	}
+	n := uint64(h[1] & 0x7f)
+	switch n {
+	case 126:
+		if _, err := io.ReadFull(c.br, h[:2]); err != nil {
+			c.t.Fatal(err)
+		}
+		n = uint64(binary.BigEndian.Uint16(h[:2]))
+	case 127:
+		if _, err := io.ReadFull(c.br, h[:8]); err != nil {
+			c.t.Fatal(err)
+		}
+		n = binary.BigEndian.Uint64(h[:8])
+	}
+	payload = make([]byte, n)
+	if _, err := io.ReadFull(c.br, payload); err != nil {
+		c.t.Fatal(err)
+	}
+	return op, payload
+}
+
+// readClose reads a close frame and returns its status code
+func (c *wsClient) readClose() int {
+	c.t.Helper()
+	op, payload := c.read()
+	if op != opClose || len(payload) < 2 {
+		c.t.Fatalf("got frame %#x %q, want close", op, payload)
+	}
+	return int(binary.BigEndian.Uint16(payload))
+}
+
+func TestWebSocketEcho(t *testing.T) {
+	tests := []struct {
+		name   string
+		frames [][]byte
+		op     byte
+		want   []byte
+	}{
+		{"text", [][]byte{[]byte("hello")}, opText, []byte("hello")},
+		{"binary", [][]byte{{0, 1, 2}}, opBinary, []byte{0, 1, 2}},
+		{"empty", [][]byte{{}}, opText, []byte{}},
+		{"16 bit length", [][]byte{bytes.Repeat([]byte("a"), 300)}, opBinary, nil},
+		{"64 bit length", [][]byte{bytes.Repeat([]byte("b"), 70000)}, opBinary, nil},
+		{"fragmented", [][]byte{[]byte("hel"), []byte("lo")}, opText, []byte("hello")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := dialWebSocket(t, WebSocketOptions{})
+			want := tt.want
+			if want == nil {
+				want = tt.frames[0]
+			}
+			for i, f := range tt.frames {
+				op := byte(opContinuation)
+				if i == 0 {
+					op = tt.op
+				}
+				c.write(i == len(tt.frames)-1, op, f, true)
+			}
+			op, got := c.read()
+			if op != tt.op || !bytes.Equal(got, want) {
+				t.Errorf("got %#x %d bytes, want %#x %d bytes", op, len(got), tt.op, len(want))
+			}
+		})
+	}
+}
+
+func TestWebSocketPing(t *testing.T) {
+	c, _ := dialWebSocket(t, WebSocketOptions{})
+	c.write(true, opPing, []byte("p"), true)
+	if op, payload := c.read(); op != opPong || string(payload) != "p" {
+		t.Errorf("got %#x %q, want pong", op, payload)
+	}
+
+	// a ping between fragments is answered without breaking the message
+	c.write(false, opText, []byte("a"), true)
+	c.write(true, opPing, nil, true)
+	c.write(true, opContinuation, []byte("b"), true)
+	if op, _ := c.read(); op != opPong {
+		t.Errorf("got %#x, want pong", op)
+	}
+	if op, payload := c.read(); op != opText || string(payload) != "ab" {
+		t.Errorf("got %#x %q, want text ab", op, payload)
+	}
+}
+
+func TestWebSocketClose(t *testing.T) {
+	c, errc := dialWebSocket(t, WebSocketOptions{})
+	c.write(true, opClose, closePayload(CloseGoingAway, "bye"), true)
+	if code := c.readClose(); code != CloseGoingAway {
+		t.Errorf("got close code %d, want %d", code, CloseGoingAway)
+	}
+	var ce *CloseError
+	if err := <-errc; !errors.As(err, &ce) || ce.Code != CloseGoingAway || ce.Reason != "bye" {
+		t.Errorf("got error %v, want close error", err)
+	}
+}
+
+func TestWebSocketProtocolErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		write func(c *wsClient)
+		code  int
+	}{
+		{
+			"unmasked frame",
+			func(c *wsClient) { c.write(true, opText, []byte("x"), false) },
+			CloseProtocolError,
+		},
+		{
+			"oversized frame",
+			func(c *wsClient) { c.write(true, opBinary, make([]byte, 200), true) },
+			CloseTooLarge,
+		},
+		{
+			"oversized fragmented message",
+			func(c *wsClient) {
+				c.write(false, opBinary, make([]byte, 100), true)
+				c.write(true, opContinuation, make([]byte, 100), true)
+			},
+			CloseTooLarge,
+		},
+		{
+			"invalid UTF-8",
+			func(c *wsClient) { c.write(true, opText, []byte{0xff, 0xfe}, true) },
+			CloseInvalidPayload,
+		},
+		{
+			"unexpected continuation",
+			func(c *wsClient) { c.write(true, opContinuation, []byte("x"), true) },
+			CloseProtocolError,
+		},
+		{
+			"fragmented control frame",
+			func(c *wsClient) { c.write(false, opPing, nil, true) },
+			CloseProtocolError,
+		},
+		{
+			"unknown opcode",
+			func(c *wsClient) { c.write(true, 0x3, nil, true) },
+			CloseProtocolError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, errc := dialWebSocket(t, WebSocketOptions{MaxMessageSize: 150})
+			tt.write(c)
+			if code := c.readClose(); code != tt.code {
+				t.Errorf("got close code %d, want %d", code, tt.code)
+			}
+			if err := <-errc; err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestUpgradeErrors(t *testing.T) {
+	valid := func(r *http.Request) {
+		r.Header.Set("Connection", "keep-alive, Upgrade")
+		r.Header.Set("Upgrade", "websocket")
+		r.Header.Set("Sec-WebSocket-Version", "13")
+		r.Header.Set("Sec-WebSocket-Key", wsTestKey)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		modify func(r *http.Request)
+		kind   errs.Kind
+	}{
+		{"method", http.MethodPost, func(*http.Request) {}, errs.Invalid},
+		{
+			"missing upgrade",
+			http.MethodGet,
+			func(r *http.Request) { r.Header.Del("Upgrade") },
+			errs.Invalid,
+		},
+		{
+			"version",
+			http.MethodGet,
+			func(r *http.Request) { r.Header.Set("Sec-WebSocket-Version", "8") },
+			errs.Invalid,
+		},
+		{
+			"invalid key",
+			http.MethodGet,
+			func(r *http.Request) { r.Header.Set("Sec-WebSocket-Key", "abc") },
+			errs.Invalid,
+		},
+		{
+			"cross origin",
+			http.MethodGet,
+			func(r *http.Request) { r.Header.Set("Origin", "https://other.example") },
+			errs.Forbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "http://example.com/ws", nil)
+			valid(r)
+			tt.modify(r)
+			_, err := Upgrade(httptest.NewRecorder(), r, WebSocketOptions{})
+			var e *errs.Error
+			if !errors.As(err, &e) || e.Kind != tt.kind {
+				t.Errorf("got error %v, want kind %s", err, tt.kind)
+			}
+		})
+	}
+}
+
+func TestSelectSubprotocol(t *testing.T) {
+	tests := []struct {
+		header    string
+		supported []string
+		want      string
+	}{
+		{"chat, json", []string{"json", "chat"}, "chat"},
+		{"v2 , v1", []string{"v1"}, "v1"},
+		{"chat", []string{"json"}, ""},
+		{"", []string{"json"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				r.Header.Set("Sec-WebSocket-Protocol", tt.header)
+			}
+			if got := selectSubprotocol(r, tt.supported); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSameOrigin(t *testing.T) {
+	tests := []struct {
+		origin string
+		want   bool
+	}{
+		{"", true},
+		{"http://example.com", true},
+		{"https://EXAMPLE.com", true},
+		{"http://example.com:8080", false},
+		{"http://evil.com", false},
+		{strings.Repeat("%", 3), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.origin, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "http://example.com/", nil)
+			if tt.origin != "" {
+				r.Header.Set("Origin", tt.origin)
+			}
+			if got := sameOrigin(r); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}